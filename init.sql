@@ -11,14 +11,202 @@ CREATE TABLE IF NOT EXISTS `users` (
   UNIQUE KEY `idx_username` (`username`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
 
+-- Create refresh_tokens table for session refresh and revocation
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '刷新令牌记录ID',
+  `uid` int(11) NOT NULL COMMENT '所属用户ID',
+  `family_id` char(36) NOT NULL COMMENT '会话ID，同一次登录轮换出的令牌共享',
+  `token_hash` char(64) NOT NULL COMMENT '刷新令牌SHA-256哈希',
+  `expires_at` datetime NOT NULL COMMENT '过期时间',
+  `used_at` datetime DEFAULT NULL COMMENT '被轮换时间',
+  `revoked_at` datetime DEFAULT NULL COMMENT '吊销时间',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '签发时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_token_hash` (`token_hash`),
+  KEY `idx_family_id` (`family_id`),
+  KEY `idx_uid` (`uid`),
+  CONSTRAINT `fk_refresh_tokens_uid` FOREIGN KEY (`uid`) REFERENCES `users` (`uid`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='刷新令牌表';
+
 -- Create minio_files table for file upload metadata
 CREATE TABLE IF NOT EXISTS `minio_files` (
   `id` int(11) NOT NULL AUTO_INCREMENT COMMENT '文件记录ID',
   `uid` int(11) NOT NULL COMMENT '上传用户ID',
   `file_name` varchar(255) NOT NULL COMMENT '原始文件名',
-  `file_url` varchar(1024) NOT NULL COMMENT 'MinIO文件访问URL',
+  `bucket` varchar(63) NOT NULL COMMENT 'MinIO存储桶',
+  `object_key` varchar(1024) NOT NULL COMMENT 'MinIO对象键，访问URL按需预签名生成',
+  `content_type` varchar(255) NOT NULL DEFAULT 'application/octet-stream' COMMENT '文件MIME类型',
+  `file_size` bigint(20) NOT NULL DEFAULT 0 COMMENT '文件大小(字节)',
+  `sha256` char(64) DEFAULT NULL COMMENT '文件内容SHA-256（十六进制），同一用户内唯一',
+  `is_shared` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否共享给其他用户',
+  `scan_status` varchar(16) NOT NULL DEFAULT 'clean' COMMENT '恶意软件扫描状态(pending/clean/infected/skipped)，pending与infected为隔离状态',
+  `scan_result` varchar(255) NOT NULL DEFAULT '' COMMENT '命中的病毒特征或跳过扫描的原因',
   `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '上传时间',
   PRIMARY KEY (`id`),
   KEY `idx_uid` (`uid`),
+  UNIQUE KEY `idx_uid_sha256` (`uid`, `sha256`),
   CONSTRAINT `fk_minio_files_uid` FOREIGN KEY (`uid`) REFERENCES `users` (`uid`) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='MinIO文件上传记录表';
+
+-- Create file_blobs table for content-addressed object reference counting
+CREATE TABLE IF NOT EXISTS `file_blobs` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '对象ID',
+  `bucket` varchar(63) NOT NULL COMMENT 'MinIO存储桶',
+  `object_key` varchar(255) NOT NULL COMMENT '按内容哈希生成的对象键',
+  `sha256` char(64) NOT NULL COMMENT '对象内容SHA-256',
+  `file_size` bigint(20) NOT NULL DEFAULT 0 COMMENT '对象大小(字节)',
+  `ref_count` int(11) NOT NULL DEFAULT 0 COMMENT '引用该对象的 minio_files 记录数',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_bucket_object_key` (`bucket`, `object_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='内容寻址对象引用计数表';
+
+-- Create conversations table for chat history
+CREATE TABLE IF NOT EXISTS `conversations` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '会话ID',
+  `uid` int(11) NOT NULL COMMENT '所属用户ID',
+  `memory_id` varchar(64) NOT NULL COMMENT '客户端会话标识',
+  `title` varchar(255) NOT NULL DEFAULT '' COMMENT '会话标题',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后活跃时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_uid_memory_id` (`uid`, `memory_id`),
+  KEY `idx_uid_updated_at` (`uid`, `updated_at`),
+  CONSTRAINT `fk_conversations_uid` FOREIGN KEY (`uid`) REFERENCES `users` (`uid`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='聊天会话表';
+
+-- Create messages table for chat history
+CREATE TABLE IF NOT EXISTS `messages` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '消息ID',
+  `conversation_id` bigint(20) NOT NULL COMMENT '所属会话ID',
+  `role` varchar(16) NOT NULL COMMENT '角色(user/assistant)',
+  `content` mediumtext NOT NULL COMMENT '消息内容',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_conversation_id` (`conversation_id`, `id`),
+  CONSTRAINT `fk_messages_conversation_id` FOREIGN KEY (`conversation_id`) REFERENCES `conversations` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='聊天消息表';
+
+-- Create message_attachments table for files referenced by chat messages
+CREATE TABLE IF NOT EXISTS `message_attachments` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '附件引用ID',
+  `message_id` bigint(20) NOT NULL COMMENT '所属消息ID',
+  `file_id` int(11) DEFAULT NULL COMMENT '引用的文件记录ID，文件删除后为空',
+  `file_name` varchar(255) NOT NULL COMMENT '发送时的文件名',
+  `content_type` varchar(255) NOT NULL DEFAULT '' COMMENT '文件MIME类型',
+  PRIMARY KEY (`id`),
+  KEY `idx_message_id` (`message_id`),
+  KEY `idx_file_id` (`file_id`),
+  CONSTRAINT `fk_message_attachments_message_id` FOREIGN KEY (`message_id`) REFERENCES `messages` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_message_attachments_file_id` FOREIGN KEY (`file_id`) REFERENCES `minio_files` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='聊天消息附件表';
+
+-- Create multipart_uploads table for resumable uploads
+CREATE TABLE IF NOT EXISTS `multipart_uploads` (
+  `id` char(36) NOT NULL COMMENT '上传会话ID',
+  `uid` int(11) NOT NULL COMMENT '上传用户ID',
+  `storage_upload_id` varchar(255) NOT NULL COMMENT 'MinIO分片上传ID',
+  `object_key` varchar(1024) NOT NULL COMMENT '目标对象键',
+  `file_name` varchar(255) NOT NULL COMMENT '原始文件名',
+  `file_size` bigint(20) NOT NULL COMMENT '文件总大小(字节)',
+  `content_type` varchar(255) NOT NULL COMMENT '文件MIME类型',
+  `part_size` bigint(20) NOT NULL COMMENT '分片大小(字节)',
+  `status` varchar(16) NOT NULL DEFAULT 'uploading' COMMENT '状态(uploading/assembled/completed/aborted)',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后活跃时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_uid` (`uid`),
+  KEY `idx_status_updated_at` (`status`, `updated_at`),
+  CONSTRAINT `fk_multipart_uploads_uid` FOREIGN KEY (`uid`) REFERENCES `users` (`uid`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='分片上传会话表';
+
+-- Create user_storage_usage table for per-user quota accounting
+CREATE TABLE IF NOT EXISTS `user_storage_usage` (
+  `uid` int(11) NOT NULL COMMENT '用户ID',
+  `used_bytes` bigint(20) NOT NULL DEFAULT 0 COMMENT '已用存储空间(字节)，与 minio_files 的写入/删除在同一事务中维护',
+  `file_count` int(11) NOT NULL DEFAULT 0 COMMENT '文件数',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`uid`),
+  CONSTRAINT `fk_user_storage_usage_uid` FOREIGN KEY (`uid`) REFERENCES `users` (`uid`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户存储用量表';
+
+-- Create video_transcodes table for HLS transcoding state
+CREATE TABLE IF NOT EXISTS `video_transcodes` (
+  `file_id` int(11) NOT NULL COMMENT '视频文件记录ID',
+  `status` varchar(16) NOT NULL DEFAULT 'pending' COMMENT '状态(pending/processing/ready/failed)',
+  `playlist_key` varchar(1024) NOT NULL DEFAULT '' COMMENT 'HLS主播放列表对象键',
+  `error` varchar(1024) NOT NULL DEFAULT '' COMMENT '失败原因',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`file_id`),
+  CONSTRAINT `fk_video_transcodes_file_id` FOREIGN KEY (`file_id`) REFERENCES `minio_files` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='视频HLS转码表';
+
+-- Create file_thumbnails table for image/video previews
+CREATE TABLE IF NOT EXISTS `file_thumbnails` (
+  `file_id` int(11) NOT NULL COMMENT '文件记录ID',
+  `size` varchar(16) NOT NULL COMMENT '规格名称(small/medium/large)',
+  `width` int(11) NOT NULL COMMENT '宽度(像素)',
+  `height` int(11) NOT NULL COMMENT '高度(像素)',
+  `object_key` varchar(1024) NOT NULL COMMENT '缩略图对象键',
+  `file_size` bigint(20) NOT NULL DEFAULT 0 COMMENT '缩略图大小(字节)',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '生成时间',
+  PRIMARY KEY (`file_id`, `size`),
+  CONSTRAINT `fk_file_thumbnails_file_id` FOREIGN KEY (`file_id`) REFERENCES `minio_files` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文件缩略图表';
+
+-- Create file_metadata table for extracted media metadata
+CREATE TABLE IF NOT EXISTS `file_metadata` (
+  `file_id` int(11) NOT NULL COMMENT '文件记录ID',
+  `status` varchar(16) NOT NULL DEFAULT 'pending' COMMENT '状态(pending/ready/failed)',
+  `kind` varchar(16) NOT NULL DEFAULT '' COMMENT '媒体类别(video/audio/image)',
+  `duration` double NOT NULL DEFAULT 0 COMMENT '时长(秒)',
+  `width` int(11) NOT NULL DEFAULT 0 COMMENT '宽度(像素)',
+  `height` int(11) NOT NULL DEFAULT 0 COMMENT '高度(像素)',
+  `codec` varchar(32) NOT NULL DEFAULT '' COMMENT '编码格式',
+  `bitrate` bigint(20) NOT NULL DEFAULT 0 COMMENT '码率(bit/s)',
+  `sample_rate` int(11) NOT NULL DEFAULT 0 COMMENT '音频采样率(Hz)',
+  `channels` int(11) NOT NULL DEFAULT 0 COMMENT '音频声道数',
+  `exif` json DEFAULT NULL COMMENT '图片EXIF(默认不含GPS)',
+  `error` varchar(1024) NOT NULL DEFAULT '' COMMENT '失败原因',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`file_id`),
+  CONSTRAINT `fk_file_metadata_file_id` FOREIGN KEY (`file_id`) REFERENCES `minio_files` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='文件媒体元数据表';
+
+-- Create archive_manifests table for archive inspection results
+CREATE TABLE IF NOT EXISTS `archive_manifests` (
+  `file_id` int(11) NOT NULL COMMENT '压缩包文件记录ID',
+  `status` varchar(16) NOT NULL DEFAULT 'pending' COMMENT '状态(pending/ready/rejected/unsupported/failed)',
+  `format` varchar(16) NOT NULL DEFAULT '' COMMENT '压缩格式(zip/rar/7z)',
+  `entry_count` int(11) NOT NULL DEFAULT 0 COMMENT '条目数',
+  `total_size` bigint(20) NOT NULL DEFAULT 0 COMMENT '解压后总大小(字节)',
+  `compressed_size` bigint(20) NOT NULL DEFAULT 0 COMMENT '压缩后总大小(字节)',
+  `reject_code` varchar(64) NOT NULL DEFAULT '' COMMENT '拒绝原因代码',
+  `error` varchar(1024) NOT NULL DEFAULT '' COMMENT '拒绝或失败原因',
+  `entries` json DEFAULT NULL COMMENT '条目清单',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`file_id`),
+  CONSTRAINT `fk_archive_manifests_file_id` FOREIGN KEY (`file_id`) REFERENCES `minio_files` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='压缩包检查结果表';
+
+-- Create jobs table for the background job queue
+CREATE TABLE IF NOT EXISTS `jobs` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT COMMENT '任务ID',
+  `type` varchar(64) NOT NULL COMMENT '任务类型',
+  `payload` json NOT NULL COMMENT '任务参数',
+  `status` varchar(16) NOT NULL DEFAULT 'queued' COMMENT '状态(queued/running/succeeded/dead)',
+  `attempts` int(11) NOT NULL DEFAULT 0 COMMENT '已尝试次数',
+  `max_attempts` int(11) NOT NULL DEFAULT 5 COMMENT '最大尝试次数，超过后进入死信',
+  `run_at` datetime(3) NOT NULL COMMENT '最早可执行时间，重试时按退避策略推后',
+  `lease_owner` varchar(128) DEFAULT NULL COMMENT '持有租约的worker',
+  `leased_until` datetime(3) DEFAULT NULL COMMENT '租约到期时间，过期后可被其它worker重新领取',
+  `last_error` varchar(1024) NOT NULL DEFAULT '' COMMENT '最近一次失败原因',
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_status_run_at` (`status`, `run_at`),
+  KEY `idx_status_leased_until` (`status`, `leased_until`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='后台任务队列表';
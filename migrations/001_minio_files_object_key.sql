@@ -0,0 +1,92 @@
+-- 升级 minio_files 表：file_url 拆分为 bucket/object_key，并补充内容哈希、共享与扫描状态列
+--
+-- init.sql 只在数据目录为空时执行，且 CREATE TABLE IF NOT EXISTS 不会修改已存在的表，
+-- 因此用旧版 init.sql 初始化的数据库需要执行本脚本（make migrate）。
+-- 脚本可重复执行：每一步都先检查 information_schema，全新初始化的数据库上不会有任何改动。
+--
+-- 旧版 file_url 的格式为 <scheme>://<endpoint>/<bucket>/<object_key>，据此回填 bucket 与 object_key。
+-- 旧记录没有内容哈希，sha256 保持 NULL（唯一索引允许多个 NULL），扫描状态沿用默认值 clean。
+
+DROP PROCEDURE IF EXISTS `migrate_minio_files_001`;
+
+DELIMITER //
+CREATE PROCEDURE `migrate_minio_files_001`()
+BEGIN
+  DECLARE has_file_url INT DEFAULT 0;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'bucket') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `bucket` varchar(63) NOT NULL DEFAULT '' COMMENT 'MinIO存储桶' AFTER `file_name`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'object_key') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `object_key` varchar(1024) NOT NULL DEFAULT '' COMMENT 'MinIO对象键，访问URL按需预签名生成' AFTER `bucket`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'content_type') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `content_type` varchar(255) NOT NULL DEFAULT 'application/octet-stream' COMMENT '文件MIME类型' AFTER `object_key`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'file_size') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `file_size` bigint(20) NOT NULL DEFAULT 0 COMMENT '文件大小(字节)' AFTER `content_type`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'sha256') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `sha256` char(64) DEFAULT NULL COMMENT '文件内容SHA-256（十六进制），同一用户内唯一' AFTER `file_size`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'is_shared') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `is_shared` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否共享给其他用户' AFTER `sha256`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'scan_status') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `scan_status` varchar(16) NOT NULL DEFAULT 'clean' COMMENT '恶意软件扫描状态(pending/clean/infected/skipped)，pending与infected为隔离状态' AFTER `is_shared`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.COLUMNS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'scan_result') THEN
+    ALTER TABLE `minio_files`
+      ADD COLUMN `scan_result` varchar(255) NOT NULL DEFAULT '' COMMENT '命中的病毒特征或跳过扫描的原因' AFTER `scan_status`;
+  END IF;
+
+  IF NOT EXISTS (SELECT 1 FROM information_schema.STATISTICS
+                 WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND INDEX_NAME = 'idx_uid_sha256') THEN
+    ALTER TABLE `minio_files` ADD UNIQUE KEY `idx_uid_sha256` (`uid`, `sha256`);
+  END IF;
+
+  SELECT COUNT(*) INTO has_file_url FROM information_schema.COLUMNS
+   WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'minio_files' AND COLUMN_NAME = 'file_url';
+
+  IF has_file_url > 0 THEN
+    -- 去掉 scheme 后剩余 <endpoint>/<bucket>/<object_key>
+    UPDATE `minio_files`
+       SET `bucket` = SUBSTRING_INDEX(SUBSTRING_INDEX(SUBSTRING(`file_url`, LOCATE('://', `file_url`) + 3), '/', 2), '/', -1),
+           `object_key` = SUBSTRING(SUBSTRING(`file_url`, LOCATE('://', `file_url`) + 3),
+                                    CHAR_LENGTH(SUBSTRING_INDEX(SUBSTRING(`file_url`, LOCATE('://', `file_url`) + 3), '/', 2)) + 2)
+     WHERE `object_key` = '' AND LOCATE('://', `file_url`) > 0;
+
+    ALTER TABLE `minio_files` DROP COLUMN `file_url`;
+  END IF;
+
+  -- 回填完成后去掉临时默认值，与 init.sql 中的定义保持一致
+  ALTER TABLE `minio_files`
+    MODIFY COLUMN `bucket` varchar(63) NOT NULL COMMENT 'MinIO存储桶',
+    MODIFY COLUMN `object_key` varchar(1024) NOT NULL COMMENT 'MinIO对象键，访问URL按需预签名生成';
+END //
+DELIMITER ;
+
+CALL `migrate_minio_files_001`();
+DROP PROCEDURE IF EXISTS `migrate_minio_files_001`;
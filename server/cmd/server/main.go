@@ -1,14 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/ASNMortred/AI-Hackathon/server/internal/auth"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/handlers"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/mcp"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/scanner"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
 	"github.com/gin-gonic/gin"
 )
 
@@ -34,22 +47,185 @@ func main() {
 	router.Use(middleware.RecoveryMiddleware())
 	router.Use(middleware.LoggerMiddleware())
 
-	uploadHandler := handlers.NewUploadHandler(cfg)
-	playHandler := handlers.NewPlayHandler()
-	chatHandler := handlers.NewChatHandler()
+	tokens := auth.NewTokenManager(cfg.Auth)
+	sessionService := services.NewSessionService(tokens, cfg.Auth.RefreshTokenTTL)
+
+	minioSvc, err := storage.NewMinioService(cfg.Minio)
+	if err != nil {
+		logger.Logger.Fatal("Failed to init minio: " + err.Error())
+	}
+
+	// 后台定期清理长时间未完成的分片上传
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	multipartService := services.NewMultipartUploadService(minioSvc, cfg.Upload.Multipart)
+	go multipartService.RunJanitor(janitorCtx)
+
+	// 上传后处理（转码等）通过后台任务队列执行，不阻塞上传请求
+	jobPool := jobs.NewPool(jobs.NewMySQLStore(database.DB), cfg.Jobs)
+
+	quotaService := services.NewQuotaService(cfg.Upload.Quota)
+	fileService := services.NewFileService(minioSvc, quotaService, cfg.Upload)
+
+	// 转码、缩略图等后续处理只对已放行的文件执行：未启用扫描时入库即触发
+	onReleased := fileService.OnStored
+
+	// 新文件入库后异步扫描，扫描通过前处于隔离状态，放行后再触发后续处理
+	if cfg.Scan.Enabled {
+		clamd, err := scanner.NewClamdScanner(cfg.Scan.ClamdAddress, cfg.Scan.Timeout)
+		if err != nil {
+			logger.Logger.Fatal("Failed to init scanner: " + err.Error())
+		}
+		scanService := services.NewScanService(minioSvc, clamd, jobPool, cfg.Scan)
+		jobPool.Register(services.ScanJobType, scanService.HandleJob)
+		fileService.QuarantineUntilScanned()
+		fileService.OnStored(scanService.Submit)
+		onReleased = scanService.OnReleased
+		// 上传钩子入队失败的文件由后台定期重新入队，不会永久停留在 pending
+		go scanService.RunJanitor(janitorCtx)
+	}
+
+	// 视频入库后异步转码为 HLS
+	transcodeService := services.NewTranscodeService(minioSvc, media.NewFFmpegTranscoder(cfg.Transcode, nil), jobPool, cfg.Transcode)
+	jobPool.Register(services.TranscodeJobType, transcodeService.HandleJob)
+	if cfg.Transcode.Enabled {
+		onReleased(transcodeService.Submit)
+	}
+
+	// 图片与视频入库后异步生成缩略图
+	thumbnailService := services.NewThumbnailService(minioSvc, media.NewFFmpegFrameExtractor(cfg.Transcode.FFmpegPath, nil), jobPool, cfg.Thumbnail)
+	jobPool.Register(services.ThumbnailJobType, thumbnailService.HandleJob)
+	if cfg.Thumbnail.Enabled {
+		onReleased(thumbnailService.Submit)
+	}
+
+	// 音视频与图片入库后异步提取元数据
+	metadataService := services.NewMetadataService(minioSvc, media.NewFFprobeProber(cfg.Metadata.FFprobePath, nil), jobPool, cfg.Metadata)
+	jobPool.Register(services.MetadataJobType, metadataService.HandleJob)
+	if cfg.Metadata.Enabled {
+		onReleased(metadataService.Submit)
+	}
+
+	// zip 压缩包入库后异步检查条目清单
+	archiveService := services.NewArchiveService(minioSvc, jobPool, cfg.Archive)
+	jobPool.Register(services.ArchiveJobType, archiveService.HandleJob)
+	if cfg.Archive.Enabled {
+		onReleased(archiveService.Submit)
+	}
+	jobPool.Start()
+
+	// 聊天按模型白名单路由到 OpenAI 兼容的模型服务，主提供方不可用时切换到后备提供方
+	chatRouter := llm.NewRouterFromConfig(cfg.QiNiu, cfg.LLM)
+	if cfg.QiNiu.APIKey == "" {
+		logger.Logger.Warn("QINIU_AI_KEY not set, chat requests will be rejected")
+	}
+	// 模型可调用的工具，未启用时注册表为空，对话不携带工具声明
+	toolRegistry := tools.NewRegistry(cfg.LLM.Tools.Timeout)
+	if cfg.LLM.Tools.Enabled {
+		if err := tools.RegisterBuiltins(toolRegistry); err != nil {
+			logger.Logger.Fatal("Failed to register tools: " + err.Error())
+		}
+		// 外部 MCP 服务器的工具与资源同样提供给模型，连接失败的服务器被跳过
+		mcpManager := mcp.Connect(context.Background(), cfg.MCP.Servers)
+		defer mcpManager.Close()
+		mcpManager.RegisterTools(context.Background(), toolRegistry)
+	} else if len(cfg.MCP.Servers) > 0 {
+		// MCP 工具只通过工具调用提供给模型，未开启时不连接，避免配置被静默忽略
+		logger.Logger.Warn("mcp.servers is configured but llm.tools.enabled is false, MCP servers will not be connected")
+	}
+	toolRunner := tools.NewRunner(chatRouter, toolRegistry, cfg.LLM.Tools.MaxIterations)
+	attachmentService := services.NewAttachmentService(minioSvc, thumbnailService, metadataService, archiveService)
+
+	uploadHandler := handlers.NewUploadHandler(cfg, minioSvc, fileService, quotaService, multipartService)
+	playHandler := handlers.NewPlayHandler(minioSvc, transcodeService)
+	chatHandler := handlers.NewChatHandler(chatRouter, toolRunner, attachmentService)
+	userHandler := handlers.NewUserHandler(sessionService)
+	conversationHandler := handlers.NewConversationHandler()
+	fileHandler := handlers.NewFileHandler(minioSvc, fileService, thumbnailService, metadataService, archiveService)
+	usageHandler := handlers.NewUsageHandler(quotaService)
+
+	// 平台自身的 MCP 服务器，工具以当前登录用户的身份访问其文件与会话
+	platformServer, err := services.NewPlatformServer(services.NewPlatformToolService(metadataService, archiveService))
+	if err != nil {
+		logger.Logger.Fatal("Failed to init MCP server: " + err.Error())
+	}
+	mcpHandler := handlers.NewMCPHandler(platformServer)
 
 	v1 := router.Group("/api/v1")
 	{
-		v1.POST("/upload", uploadHandler.Upload)
-		v1.GET("/play/:videoID", playHandler.Play)
-		v1.POST("/chat", chatHandler.Chat)
-		v1.POST("/register", handlers.NewUserHandler().Register)
-		v1.POST("/login", handlers.NewUserHandler().Login)
+		v1.POST("/register", userHandler.Register)
+		v1.POST("/login", userHandler.Login)
+		v1.POST("/token/refresh", userHandler.Refresh)
+	}
+
+	// 需要登录的接口统一挂在 protected 分组下
+	protected := v1.Group("")
+	protected.Use(middleware.AuthMiddleware(sessionService))
+	{
+		protected.POST("/logout", userHandler.Logout)
+		protected.POST("/upload", uploadHandler.Upload)
+		protected.POST("/upload/presign", uploadHandler.PresignUpload)
+		protected.POST("/upload/complete", uploadHandler.CompleteUpload)
+		protected.POST("/uploads/multipart", uploadHandler.InitiateMultipart)
+		protected.GET("/uploads/multipart/:uploadID", uploadHandler.MultipartStatus)
+		protected.PUT("/uploads/multipart/:uploadID/parts/:partNumber", uploadHandler.UploadPart)
+		protected.POST("/uploads/multipart/:uploadID/complete", uploadHandler.CompleteMultipart)
+		protected.DELETE("/uploads/multipart/:uploadID", uploadHandler.AbortMultipart)
+		protected.GET("/me/usage", usageHandler.Usage)
+		protected.GET("/files", fileHandler.List)
+		protected.GET("/files/check", fileHandler.Check)
+		protected.GET("/files/gallery", fileHandler.Gallery)
+		protected.GET("/files/:id", fileHandler.Get)
+		protected.PATCH("/files/:id", fileHandler.Rename)
+		protected.DELETE("/files/:id", fileHandler.Delete)
+		protected.GET("/files/:id/download", fileHandler.Download)
+		protected.GET("/files/:id/metadata", fileHandler.Metadata)
+		protected.GET("/files/:id/archive", fileHandler.ArchiveManifest)
+		protected.GET("/files/:id/archive/entry", fileHandler.ArchiveEntry)
+		protected.GET("/files/:id/thumbnails", fileHandler.Thumbnails)
+		protected.GET("/files/:id/thumbnails/:size", fileHandler.Thumbnail)
+		protected.GET("/play/:videoID", playHandler.Play)
+		protected.HEAD("/play/:videoID", playHandler.Play)
+		protected.GET("/play/:videoID/hls/*path", playHandler.HLS)
+		protected.POST("/chat", chatHandler.Chat)
+		protected.POST("/chat/stream", chatHandler.ChatStream)
+		protected.GET("/chat/models", chatHandler.Models)
+
+		protected.GET("/conversations", conversationHandler.List)
+		protected.GET("/conversations/:id", conversationHandler.Get)
+		protected.PATCH("/conversations/:id", conversationHandler.Rename)
+		protected.DELETE("/conversations/:id", conversationHandler.Delete)
+		protected.GET("/conversations/:id/export", conversationHandler.Export)
+
+		if cfg.MCP.Expose {
+			protected.POST("/mcp", mcpHandler.Post)
+			protected.GET("/mcp", mcpHandler.MethodNotAllowed)
+			protected.DELETE("/mcp", mcpHandler.MethodNotAllowed)
+		}
 	}
 
 	addr := ":" + cfg.Server.Port
-	logger.Logger.Info("Starting server on " + addr)
-	if err := router.Run(addr); err != nil {
-		logger.Logger.Fatal("Failed to start server: " + err.Error())
+	srv := &http.Server{Addr: addr, Handler: router}
+	go func() {
+		logger.Logger.Info("Starting server on " + addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Logger.Fatal("Failed to start server: " + err.Error())
+		}
+	}()
+
+	// 收到退出信号后先停止接收请求，再排空后台任务
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logger.Logger.Info("Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Jobs.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Logger.Error("Server forced to shutdown: " + err.Error())
+	}
+	if err := jobPool.Shutdown(ctx); err != nil {
+		logger.Logger.Warn("Job workers did not drain in time: " + err.Error())
 	}
+	logger.Logger.Info("Server exited")
 }
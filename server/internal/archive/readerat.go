@@ -0,0 +1,63 @@
+package archive
+
+import (
+	"io"
+	"sync"
+)
+
+// BlockReaderAt 按固定大小的块读取并缓存最近一块，减少底层 ReaderAt 的调用次数。
+// 对象存储的 ReadAt 每次调用都是一次范围请求，解压时的小块顺序读取需经此合并。
+type BlockReaderAt struct {
+	r         io.ReaderAt
+	size      int64
+	blockSize int64
+
+	mu    sync.Mutex
+	start int64
+	block []byte
+}
+
+// NewBlockReaderAt 包装 r，size 为数据总长度
+func NewBlockReaderAt(r io.ReaderAt, size int64, blockSize int) *BlockReaderAt {
+	return &BlockReaderAt{r: r, size: size, blockSize: int64(blockSize), start: -1}
+}
+
+func (b *BlockReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= b.size {
+			return n, io.EOF
+		}
+		if b.start < 0 || pos < b.start || pos >= b.start+int64(len(b.block)) {
+			if err := b.fill(pos - pos%b.blockSize); err != nil {
+				return n, err
+			}
+		}
+		n += copy(p[n:], b.block[pos-b.start:])
+	}
+	return n, nil
+}
+
+func (b *BlockReaderAt) fill(start int64) error {
+	size := b.blockSize
+	if start+size > b.size {
+		size = b.size - start
+	}
+	if int64(cap(b.block)) < size {
+		b.block = make([]byte, size)
+	}
+	b.block = b.block[:size]
+	if _, err := b.r.ReadAt(b.block, start); err != nil && err != io.EOF {
+		b.start = -1
+		return err
+	}
+	b.start = start
+	return nil
+}
@@ -0,0 +1,175 @@
+// Package archive 检查上传的压缩包：列出条目、识别压缩炸弹与路径穿越，并支持读取单个条目
+package archive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 压缩包被拒绝的原因代码
+const (
+	CodeInvalidArchive = "INVALID_ARCHIVE"
+	CodeTooManyEntries = "TOO_MANY_ENTRIES"
+	CodeArchiveTooBig  = "UNCOMPRESSED_SIZE_EXCEEDED"
+	CodeZipBomb        = "COMPRESSION_RATIO_EXCEEDED"
+	CodeUnsafePath     = "UNSAFE_ENTRY_PATH"
+)
+
+// RejectError 压缩包不安全或无法解析，Code 为上面的原因代码之一
+type RejectError struct {
+	Code   string
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return e.Reason
+}
+
+func reject(code, format string, args ...interface{}) error {
+	return &RejectError{Code: code, Reason: fmt.Sprintf(format, args...)}
+}
+
+// ErrEntryNotFound 压缩包中不存在该条目，或条目为目录
+var ErrEntryNotFound = errors.New("archive entry not found")
+
+// ErrEntryEncrypted 条目已加密，无法读取
+var ErrEntryEncrypted = errors.New("archive entry is encrypted")
+
+// ratioMinSize 条目解压后不小于该大小时才检查压缩比，避免小文件高压缩比误判
+const ratioMinSize = 1 << 20
+
+// Limits 压缩包检查阈值，为 0 的项不检查
+type Limits struct {
+	MaxEntries int
+	// MaxUncompressedSize 全部条目解压后的总大小上限（字节）
+	MaxUncompressedSize int64
+	// MaxRatio 单个条目与整个压缩包的解压/压缩比上限
+	MaxRatio float64
+}
+
+// Entry 压缩包中的一个条目
+type Entry struct {
+	Name           string    `json:"name"`
+	Size           int64     `json:"size"`
+	CompressedSize int64     `json:"compressed_size"`
+	Modified       time.Time `json:"modified"`
+	IsDir          bool      `json:"is_dir"`
+	Encrypted      bool      `json:"encrypted,omitempty"`
+}
+
+// Manifest 压缩包条目清单
+type Manifest struct {
+	Entries        []Entry `json:"entries"`
+	EntryCount     int     `json:"entry_count"`
+	TotalSize      int64   `json:"total_size"`
+	CompressedSize int64   `json:"compressed_size"`
+}
+
+// InspectZip 读取 zip 中央目录生成条目清单，只依赖目录中声明的大小，不解压数据。
+// 压缩包不安全时返回 *RejectError。
+func InspectZip(r io.ReaderAt, size int64, limits Limits) (*Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, reject(CodeInvalidArchive, "invalid zip archive: %v", err)
+	}
+	if limits.MaxEntries > 0 && len(zr.File) > limits.MaxEntries {
+		return nil, reject(CodeTooManyEntries, "archive has %d entries, limit is %d", len(zr.File), limits.MaxEntries)
+	}
+
+	m := &Manifest{Entries: make([]Entry, 0, len(zr.File)), EntryCount: len(zr.File)}
+	for _, f := range zr.File {
+		if !SafeName(f.Name) {
+			return nil, reject(CodeUnsafePath, "unsafe entry path %q", f.Name)
+		}
+		e := entryOf(f)
+		m.TotalSize += e.Size
+		m.CompressedSize += e.CompressedSize
+		// 中央目录中的大小为无符号 64 位，累加溢出即视为炸弹
+		if e.Size < 0 || m.TotalSize < 0 {
+			return nil, reject(CodeArchiveTooBig, "entry %q declares an invalid size", f.Name)
+		}
+		if limits.MaxUncompressedSize > 0 && m.TotalSize > limits.MaxUncompressedSize {
+			return nil, reject(CodeArchiveTooBig, "uncompressed size exceeds %d bytes", limits.MaxUncompressedSize)
+		}
+		if exceedsRatio(e.Size, e.CompressedSize, limits.MaxRatio) {
+			return nil, reject(CodeZipBomb, "entry %q compression ratio exceeds %.0f", f.Name, limits.MaxRatio)
+		}
+		m.Entries = append(m.Entries, e)
+	}
+	if exceedsRatio(m.TotalSize, size, limits.MaxRatio) {
+		return nil, reject(CodeZipBomb, "archive compression ratio exceeds %.0f", limits.MaxRatio)
+	}
+	return m, nil
+}
+
+// OpenZipEntry 打开 zip 中的单个文件条目，读取时校验 CRC 与声明的大小
+func OpenZipEntry(r io.ReaderAt, size int64, name string) (io.ReadCloser, *Entry, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, reject(CodeInvalidArchive, "invalid zip archive: %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		e := entryOf(f)
+		if e.IsDir || !SafeName(f.Name) {
+			return nil, nil, ErrEntryNotFound
+		}
+		if e.Encrypted {
+			return nil, nil, ErrEntryEncrypted
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open archive entry: %w", err)
+		}
+		return rc, &e, nil
+	}
+	return nil, nil, ErrEntryNotFound
+}
+
+// SafeName 判断条目路径在解压时不会逃出目标目录：拒绝绝对路径、盘符、反斜杠、.. 段与控制字符
+func SafeName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return false
+	}
+	if len(name) >= 2 && name[1] == ':' {
+		return false
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	for _, seg := range strings.Split(strings.TrimSuffix(name, "/"), "/") {
+		if seg == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func entryOf(f *zip.File) Entry {
+	return Entry{
+		Name:           f.Name,
+		Size:           int64(f.UncompressedSize64),
+		CompressedSize: int64(f.CompressedSize64),
+		Modified:       f.Modified,
+		IsDir:          strings.HasSuffix(f.Name, "/"),
+		Encrypted:      f.Flags&0x1 != 0,
+	}
+}
+
+func exceedsRatio(uncompressed, compressed int64, maxRatio float64) bool {
+	if maxRatio <= 0 || uncompressed < ratioMinSize {
+		return false
+	}
+	if compressed <= 0 {
+		return true
+	}
+	return float64(uncompressed)/float64(compressed) > maxRatio
+}
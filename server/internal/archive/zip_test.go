@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLimits = Limits{MaxEntries: 10, MaxUncompressedSize: 10 << 20, MaxRatio: 100}
+
+func buildZip(t *testing.T, files map[string][]byte, order ...string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range order {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		require.NoError(t, err)
+		_, err = w.Write(files[name])
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestInspectZipListsEntries(t *testing.T) {
+	r := buildZip(t, map[string][]byte{
+		"docs/":          nil,
+		"docs/readme.md": []byte("# hello"),
+		"main.go":        []byte("package main"),
+	}, "docs/", "docs/readme.md", "main.go")
+
+	m, err := InspectZip(r, r.Size(), testLimits)
+	require.NoError(t, err)
+	assert.Equal(t, 3, m.EntryCount)
+	assert.Equal(t, int64(len("# hello")+len("package main")), m.TotalSize)
+	require.Len(t, m.Entries, 3)
+	assert.True(t, m.Entries[0].IsDir)
+	assert.Equal(t, "docs/readme.md", m.Entries[1].Name)
+	assert.Equal(t, int64(7), m.Entries[1].Size)
+}
+
+func TestInspectZipRejects(t *testing.T) {
+	zeros := make([]byte, 4<<20)
+	many := map[string][]byte{}
+	var names []string
+	for i := 0; i < 11; i++ {
+		name := strings.Repeat("a", i+1)
+		many[name] = []byte("x")
+		names = append(names, name)
+	}
+
+	tests := []struct {
+		name   string
+		zip    *bytes.Reader
+		limits Limits
+		code   string
+	}{
+		{"path traversal", buildZip(t, map[string][]byte{"../../etc/passwd": []byte("x")}, "../../etc/passwd"), testLimits, CodeUnsafePath},
+		{"absolute path", buildZip(t, map[string][]byte{"/etc/passwd": []byte("x")}, "/etc/passwd"), testLimits, CodeUnsafePath},
+		{"compression ratio", buildZip(t, map[string][]byte{"zeros.bin": zeros}, "zeros.bin"), testLimits, CodeZipBomb},
+		{"total size", buildZip(t, map[string][]byte{"zeros.bin": zeros}, "zeros.bin"), Limits{MaxUncompressedSize: 1 << 20}, CodeArchiveTooBig},
+		{"entry count", buildZip(t, many, names...), testLimits, CodeTooManyEntries},
+		{"not a zip", bytes.NewReader([]byte("PK\x03\x04garbage")), testLimits, CodeInvalidArchive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := InspectZip(tt.zip, tt.zip.Size(), tt.limits)
+			var rejectErr *RejectError
+			require.ErrorAs(t, err, &rejectErr)
+			assert.Equal(t, tt.code, rejectErr.Code)
+		})
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	for name, safe := range map[string]bool{
+		"a/b/c.txt":     true,
+		"dir/":          true,
+		"..hidden":      true,
+		"a/../../b":     false,
+		"..":            false,
+		"/abs":          false,
+		"C:/windows":    false,
+		"a\\..\\b":      false,
+		"bad\x00name":   false,
+		"":              false,
+		"ok/..dots/x.y": true,
+	} {
+		assert.Equal(t, safe, SafeName(name), name)
+	}
+}
+
+func TestOpenZipEntry(t *testing.T) {
+	r := buildZip(t, map[string][]byte{"dir/": nil, "dir/a.txt": []byte("hello archive")}, "dir/", "dir/a.txt")
+
+	rc, entry, err := OpenZipEntry(r, r.Size(), "dir/a.txt")
+	require.NoError(t, err)
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "hello archive", string(body))
+	assert.Equal(t, int64(13), entry.Size)
+
+	_, _, err = OpenZipEntry(r, r.Size(), "dir/")
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+	_, _, err = OpenZipEntry(r, r.Size(), "missing.txt")
+	assert.ErrorIs(t, err, ErrEntryNotFound)
+}
+
+type countingReaderAt struct {
+	r     io.ReaderAt
+	calls int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.calls++
+	return c.r.ReadAt(p, off)
+}
+
+func TestBlockReaderAtCoalescesReads(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+	counter := &countingReaderAt{r: bytes.NewReader(data)}
+	br := NewBlockReaderAt(counter, int64(len(data)), 256)
+
+	var out []byte
+	buf := make([]byte, 10)
+	for off := int64(0); ; off += 10 {
+		n, err := br.ReadAt(buf, off)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Equal(t, data, out)
+	assert.Equal(t, 4, counter.calls)
+
+	// 跨块读取
+	p := make([]byte, 20)
+	n, err := br.ReadAt(p, 250)
+	require.NoError(t, err)
+	assert.Equal(t, data[250:270], p[:n])
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidToken 令牌格式错误、签名不匹配或声明不合法
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken 令牌已过期
+	ErrExpiredToken = errors.New("token expired")
+)
+
+// Claims 访问令牌声明，sub 为用户 uid，sid 为登录会话ID
+type Claims struct {
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UID 返回令牌所属用户的 uid
+func (c *Claims) UID() (int, error) {
+	uid, err := strconv.Atoi(c.Subject)
+	if err != nil || uid <= 0 {
+		return 0, ErrInvalidToken
+	}
+	return uid, nil
+}
+
+// TokenManager 使用 HMAC-SHA256 签发和校验访问令牌
+type TokenManager struct {
+	secret    []byte
+	issuer    string
+	accessTTL time.Duration
+	now       func() time.Time
+}
+
+// NewTokenManager 根据配置创建令牌管理器
+func NewTokenManager(cfg config.AuthConfig) *TokenManager {
+	return &TokenManager{
+		secret:    []byte(cfg.JWTSecret),
+		issuer:    cfg.Issuer,
+		accessTTL: cfg.AccessTokenTTL,
+		now:       time.Now,
+	}
+}
+
+// IssueAccessToken 为指定用户和会话签发访问令牌
+func (m *TokenManager) IssueAccessToken(uid int, sessionID string) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.accessTTL)
+	claims := Claims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   strconv.Itoa(uid),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			ID:        uuid.New().String(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ParseAccessToken 校验签名、签发方和有效期，返回令牌声明
+func (m *TokenManager) ParseAccessToken(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithTimeFunc(m.now),
+		jwt.WithIssuedAt(),
+	}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, opts...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	// 缺少 exp 或 jti 的令牌不是由本服务签发的
+	if claims.ExpiresAt == nil || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	if _, err := claims.UID(); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// NewRefreshToken 生成随机刷新令牌，返回明文和用于存储的哈希
+func NewRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken 计算刷新令牌的 SHA-256 十六进制哈希
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager() *TokenManager {
+	return NewTokenManager(config.AuthConfig{
+		JWTSecret:      "0123456789abcdef0123456789abcdef",
+		Issuer:         "ai-hackathon",
+		AccessTokenTTL: 15 * time.Minute,
+	})
+}
+
+func TestIssueAndParseAccessToken(t *testing.T) {
+	m := newTestManager()
+
+	token, expiresAt, err := m.IssueAccessToken(42, "sid-1")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), expiresAt, time.Second)
+
+	claims, err := m.ParseAccessToken(token)
+	require.NoError(t, err)
+	uid, err := claims.UID()
+	require.NoError(t, err)
+	assert.Equal(t, 42, uid)
+	assert.NotEmpty(t, claims.ID)
+	assert.Equal(t, "sid-1", claims.SessionID)
+}
+
+func TestParseAccessTokenRejectsTampered(t *testing.T) {
+	m := newTestManager()
+	token, _, err := m.IssueAccessToken(1, "")
+	require.NoError(t, err)
+
+	// 篡改 payload 中的 sub
+	parts := strings.Split(token, ".")
+	forged, _, err := newTestManager().IssueAccessToken(2, "")
+	require.NoError(t, err)
+	parts[1] = strings.Split(forged, ".")[1]
+	_, err = m.ParseAccessToken(strings.Join(parts, "."))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// 其他密钥签发的令牌
+	other := NewTokenManager(config.AuthConfig{
+		JWTSecret:      "ffffffffffffffffffffffffffffffff",
+		Issuer:         "ai-hackathon",
+		AccessTokenTTL: time.Minute,
+	})
+	token, _, err = other.IssueAccessToken(1, "")
+	require.NoError(t, err)
+	_, err = m.ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// 旧格式令牌
+	_, err = m.ParseAccessToken("token_alice")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestParseAccessTokenRejectsExpired(t *testing.T) {
+	m := newTestManager()
+	m.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	token, _, err := m.IssueAccessToken(1, "")
+	require.NoError(t, err)
+
+	m.now = time.Now
+	_, err = m.ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestParseAccessTokenRejectsUnknownClaims(t *testing.T) {
+	m := newTestManager()
+
+	// 缺少 jti 和 exp
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:  "ai-hackathon",
+		Subject: "1",
+	}).SignedString(m.secret)
+	require.NoError(t, err)
+	_, err = m.ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// alg=none
+	token, err = jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Issuer:    "ai-hackathon",
+		Subject:   "1",
+		ID:        "x",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = m.ParseAccessToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNewRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashRefreshToken(token))
+
+	other, _, err := NewRefreshToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
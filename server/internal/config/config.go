@@ -3,18 +3,29 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	QiNiu    QiNiuConfig    `mapstructure:"qiniu"`
-	Minio    MinioConfig    `mapstructure:"minio"`
-	Upload   UploadConfig   `mapstructure:"upload"`
-	Database DatabaseConfig `mapstructure:"database"`
+	Server    ServerConfig    `mapstructure:"server"`
+	QiNiu     QiNiuConfig     `mapstructure:"qiniu"`
+	LLM       LLMConfig       `mapstructure:"llm"`
+	Minio     MinioConfig     `mapstructure:"minio"`
+	Upload    UploadConfig    `mapstructure:"upload"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Transcode TranscodeConfig `mapstructure:"transcode"`
+	Jobs      JobsConfig      `mapstructure:"jobs"`
+	Thumbnail ThumbnailConfig `mapstructure:"thumbnail"`
+	Metadata  MetadataConfig  `mapstructure:"metadata"`
+	Archive   ArchiveConfig   `mapstructure:"archive"`
+	Scan      ScanConfig      `mapstructure:"scan"`
+	MCP       MCPConfig       `mapstructure:"mcp"`
 }
 
 type ServerConfig struct {
@@ -25,6 +36,73 @@ type QiNiuConfig struct {
 	AccessKey string `mapstructure:"access_key"`
 	SecretKey string `mapstructure:"secret_key"`
 	Bucket    string `mapstructure:"bucket"`
+	// BaseURL 七牛 AI 推理服务的 OpenAI 兼容接口地址
+	BaseURL string `mapstructure:"base_url"`
+	// APIKey 推理服务密钥，未配置时聊天接口返回 503
+	APIKey    string `mapstructure:"api_key"`
+	Model     string `mapstructure:"model"`
+	MaxTokens int    `mapstructure:"max_tokens"`
+	// Timeout 非流式请求的整体超时，也是流式请求等待响应头的超时
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// QiNiuProviderName 七牛推理服务在模型路由中的提供方名称
+const QiNiuProviderName = "qiniu"
+
+// Provider 以七牛推理服务配置生成模型提供方配置
+func (q QiNiuConfig) Provider() LLMProviderConfig {
+	return LLMProviderConfig{
+		Name:      QiNiuProviderName,
+		BaseURL:   q.BaseURL,
+		APIKey:    q.APIKey,
+		Model:     q.Model,
+		MaxTokens: q.MaxTokens,
+		Timeout:   q.Timeout,
+	}
+}
+
+// LLMConfig 聊天模型路由配置。七牛推理服务（qiniu 配置段）始终以 qiniu 为名注册为提供方，
+// Providers 中可再配置其他 OpenAI 兼容的提供方
+type LLMConfig struct {
+	// DefaultModel 请求未指定模型时使用，为空时取 Models 中的第一个
+	DefaultModel string              `mapstructure:"default_model"`
+	Providers    []LLMProviderConfig `mapstructure:"providers"`
+	// Models 客户端可选的模型白名单，为空时只开放 qiniu.model
+	Models []LLMModelConfig `mapstructure:"models"`
+	Tools  LLMToolsConfig   `mapstructure:"tools"`
+}
+
+// LLMToolsConfig 模型函数调用配置
+type LLMToolsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxIterations 单次对话最多执行的工具调用轮数，达到后要求模型直接作答
+	MaxIterations int `mapstructure:"max_iterations"`
+	// Timeout 单次工具调用的默认超时
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// LLMProviderConfig OpenAI 兼容的模型提供方
+type LLMProviderConfig struct {
+	Name    string `mapstructure:"name"`
+	BaseURL string `mapstructure:"base_url"`
+	APIKey  string `mapstructure:"api_key"`
+	// Model 请求未指定模型时使用的默认模型
+	Model     string        `mapstructure:"model"`
+	MaxTokens int           `mapstructure:"max_tokens"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// LLMModelConfig 可选模型及其路由，按 Routes 顺序尝试，超时、5xx 或限流时切换到下一条
+type LLMModelConfig struct {
+	Name   string           `mapstructure:"name"`
+	Routes []LLMRouteConfig `mapstructure:"routes"`
+}
+
+// LLMRouteConfig 模型在某个提供方上的路由
+type LLMRouteConfig struct {
+	Provider string `mapstructure:"provider"`
+	// Model 提供方侧的模型ID，为空时与模型名称相同
+	Model string `mapstructure:"model"`
 }
 
 type MinioConfig struct {
@@ -33,12 +111,50 @@ type MinioConfig struct {
 	SecretKey string `mapstructure:"secret_key"`
 	Bucket    string `mapstructure:"bucket"`
 	UseSSL    bool   `mapstructure:"use_ssl"`
+	Region    string `mapstructure:"region"`
+	// PublicEndpoint 浏览器访问 MinIO 的地址，用于签发预签名URL；为空时使用 Endpoint
+	PublicEndpoint string        `mapstructure:"public_endpoint"`
+	PublicUseSSL   bool          `mapstructure:"public_use_ssl"`
+	PresignExpiry  time.Duration `mapstructure:"presign_expiry"`
 }
 
 type UploadConfig struct {
 	MaxSize      int64    `mapstructure:"max_size"`
 	AllowedTypes []string `mapstructure:"allowed_types"`
-	UploadDir    string   `mapstructure:"upload_dir"`
+	// AllowedMIMETypes 按文件头魔数识别出的类型必须在此列表中
+	AllowedMIMETypes []string        `mapstructure:"allowed_mime_types"`
+	UploadDir        string          `mapstructure:"upload_dir"`
+	Multipart        MultipartConfig `mapstructure:"multipart"`
+	// DedupScope 内容去重范围：user 仅在用户内去重；global 跨用户共享同一对象并按引用计数回收
+	DedupScope string      `mapstructure:"dedup_scope"`
+	Quota      QuotaConfig `mapstructure:"quota"`
+}
+
+// QuotaConfig 用户存储配额（字节），0 表示不限额
+type QuotaConfig struct {
+	Default int64 `mapstructure:"default"`
+	// Users 按 uid 单独指定的配额，覆盖 Default
+	Users map[int]int64 `mapstructure:"users"`
+}
+
+// BytesFor 返回指定用户的配额
+func (q QuotaConfig) BytesFor(uid int) int64 {
+	if quota, ok := q.Users[uid]; ok {
+		return quota
+	}
+	return q.Default
+}
+
+const (
+	DedupScopeUser   = "user"
+	DedupScopeGlobal = "global"
+)
+
+// MultipartConfig 分片上传配置
+type MultipartConfig struct {
+	PartSize   int64         `mapstructure:"part_size"`
+	StaleAfter time.Duration `mapstructure:"stale_after"`
+	GCInterval time.Duration `mapstructure:"gc_interval"`
 }
 
 type DatabaseConfig struct {
@@ -49,6 +165,121 @@ type DatabaseConfig struct {
 	Name     string `mapstructure:"name"`
 }
 
+// AuthConfig 访问令牌签发配置
+type AuthConfig struct {
+	JWTSecret       string        `mapstructure:"jwt_secret"`
+	Issuer          string        `mapstructure:"issuer"`
+	AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+}
+
+// JobsConfig 后台任务队列配置
+type JobsConfig struct {
+	Workers      int           `mapstructure:"workers"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// VisibilityTimeout 租约时长，worker 异常退出后任务在该时长后可被重新领取
+	VisibilityTimeout time.Duration `mapstructure:"visibility_timeout"`
+	MaxAttempts       int           `mapstructure:"max_attempts"`
+	BackoffBase       time.Duration `mapstructure:"backoff_base"`
+	BackoffMax        time.Duration `mapstructure:"backoff_max"`
+	// ShutdownTimeout 进程退出时等待执行中任务完成的最长时间
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+}
+
+// TranscodeConfig 视频转 HLS 配置
+type TranscodeConfig struct {
+	Enabled        bool              `mapstructure:"enabled"`
+	FFmpegPath     string            `mapstructure:"ffmpeg_path"`
+	SegmentSeconds int               `mapstructure:"segment_seconds"`
+	Timeout        time.Duration     `mapstructure:"timeout"`
+	Renditions     []RenditionConfig `mapstructure:"renditions"`
+}
+
+// RenditionConfig HLS 单路码率配置
+type RenditionConfig struct {
+	Name      string `mapstructure:"name"`
+	Height    int    `mapstructure:"height"`
+	VideoKbps int    `mapstructure:"video_kbps"`
+	AudioKbps int    `mapstructure:"audio_kbps"`
+}
+
+// ThumbnailConfig 图片与视频缩略图配置，视频截帧复用 transcode.ffmpeg_path
+type ThumbnailConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Quality 缩略图 JPEG 质量（1-100）
+	Quality int                   `mapstructure:"quality"`
+	Timeout time.Duration         `mapstructure:"timeout"`
+	Sizes   []ThumbnailSizeConfig `mapstructure:"sizes"`
+}
+
+// ThumbnailSizeConfig 缩略图规格，按长边等比缩放
+type ThumbnailSizeConfig struct {
+	Name    string `mapstructure:"name"`
+	MaxEdge int    `mapstructure:"max_edge"`
+}
+
+// MetadataConfig 媒体元数据提取配置
+type MetadataConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	FFprobePath string        `mapstructure:"ffprobe_path"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	// KeepGPS 保留图片 EXIF 中的 GPS 位置信息，默认剥离
+	KeepGPS bool `mapstructure:"keep_gps"`
+}
+
+// ArchiveConfig 压缩包检查配置，超过任一阈值的压缩包被拒绝
+type ArchiveConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	MaxEntries int  `mapstructure:"max_entries"`
+	// MaxUncompressedSize 全部条目解压后的总大小上限（字节）
+	MaxUncompressedSize int64 `mapstructure:"max_uncompressed_size"`
+	// MaxRatio 解压/压缩比上限，用于识别压缩炸弹
+	MaxRatio float64       `mapstructure:"max_ratio"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// ScanConfig 上传内容恶意软件扫描配置
+type ScanConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ClamdAddress clamd 地址，如 tcp://clamav:3310 或 unix:///run/clamav/clamd.sock
+	ClamdAddress string `mapstructure:"clamd_address"`
+	// FailOpen 扫描器不可用时放行文件；默认 false，文件保持隔离并重试扫描
+	FailOpen bool          `mapstructure:"fail_open"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+	// RequeueInterval 周期检查入队失败的待扫描文件并重新入队，登记超过该时长仍没有扫描任务的文件视为入队失败
+	RequeueInterval time.Duration `mapstructure:"requeue_interval"`
+}
+
+// MCP 服务器传输方式
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportHTTP  = "http"
+)
+
+// MCPConfig Model Context Protocol 配置
+type MCPConfig struct {
+	// Servers 外部 MCP 工具服务器，启用函数调用时其工具与资源提供给模型
+	Servers []MCPServerConfig `mapstructure:"servers"`
+	// Expose 在 /api/v1/mcp 以 Streamable HTTP 提供平台自身的 MCP 服务器
+	Expose bool `mapstructure:"expose"`
+}
+
+// MCPServerConfig 外部 MCP 服务器，stdio 方式启动子进程通信，http 方式使用 Streamable HTTP
+type MCPServerConfig struct {
+	// Name 服务器名称，作为工具名前缀
+	Name      string `mapstructure:"name"`
+	Transport string `mapstructure:"transport"`
+	// Command、Args、Env 为 stdio 方式启动的命令、参数与额外环境变量（KEY=VALUE）
+	Command string   `mapstructure:"command"`
+	Args    []string `mapstructure:"args"`
+	Env     []string `mapstructure:"env"`
+	// URL、Headers 为 http 方式的端点地址与附加请求头（如鉴权）
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"`
+	// Timeout 单次请求（含工具调用）的超时
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
 func LoadConfig() (*Config, error) {
 	var configPath string
 	pflag.StringVar(&configPath, "config", "configs/config.yaml", "path to config file")
@@ -76,6 +307,14 @@ func LoadConfig() (*Config, error) {
 	return &config, nil
 }
 
+// defaultAllowedMIMETypes 与默认扩展名白名单对应的 MIME 类型
+var defaultAllowedMIMETypes = []string{
+	"video/mp4", "video/quicktime", "video/x-matroska", "video/x-msvideo",
+	"image/jpeg", "image/png", "image/gif",
+	"audio/mpeg", "audio/wav",
+	"application/zip", "application/vnd.rar", "application/x-7z-compressed",
+}
+
 func expandEnvInConfig(cfg *Config) {
 	cfg.Server.Port = os.ExpandEnv(cfg.Server.Port)
 	cfg.Database.Host = os.ExpandEnv(cfg.Database.Host)
@@ -84,10 +323,182 @@ func expandEnvInConfig(cfg *Config) {
 	cfg.Database.Password = os.ExpandEnv(cfg.Database.Password)
 	cfg.Database.Name = os.ExpandEnv(cfg.Database.Name)
 
+	cfg.QiNiu.APIKey = os.ExpandEnv(cfg.QiNiu.APIKey)
+	cfg.QiNiu.BaseURL = os.ExpandEnv(cfg.QiNiu.BaseURL)
+	if cfg.QiNiu.BaseURL == "" {
+		cfg.QiNiu.BaseURL = "https://openai.qiniu.com/v1"
+	}
+	if cfg.QiNiu.Model == "" {
+		cfg.QiNiu.Model = "qwen-vl-max-2025-01-25"
+	}
+	if cfg.QiNiu.MaxTokens <= 0 {
+		cfg.QiNiu.MaxTokens = 1000
+	}
+	if cfg.QiNiu.Timeout <= 0 {
+		cfg.QiNiu.Timeout = 60 * time.Second
+	}
+	for i := range cfg.LLM.Providers {
+		p := &cfg.LLM.Providers[i]
+		p.BaseURL = os.ExpandEnv(p.BaseURL)
+		p.APIKey = os.ExpandEnv(p.APIKey)
+		if p.MaxTokens <= 0 {
+			p.MaxTokens = cfg.QiNiu.MaxTokens
+		}
+		if p.Timeout <= 0 {
+			p.Timeout = cfg.QiNiu.Timeout
+		}
+	}
+	if len(cfg.LLM.Models) == 0 {
+		cfg.LLM.Models = []LLMModelConfig{
+			{Name: cfg.QiNiu.Model, Routes: []LLMRouteConfig{{Provider: QiNiuProviderName}}},
+		}
+	}
+	if cfg.LLM.DefaultModel == "" {
+		cfg.LLM.DefaultModel = cfg.LLM.Models[0].Name
+	}
+	if cfg.LLM.Tools.MaxIterations <= 0 {
+		cfg.LLM.Tools.MaxIterations = 5
+	}
+	if cfg.LLM.Tools.Timeout <= 0 {
+		cfg.LLM.Tools.Timeout = 10 * time.Second
+	}
+	for i := range cfg.MCP.Servers {
+		s := &cfg.MCP.Servers[i]
+		s.Command = os.ExpandEnv(s.Command)
+		s.URL = os.ExpandEnv(s.URL)
+		for j := range s.Args {
+			s.Args[j] = os.ExpandEnv(s.Args[j])
+		}
+		for j := range s.Env {
+			s.Env[j] = os.ExpandEnv(s.Env[j])
+		}
+		for k, v := range s.Headers {
+			s.Headers[k] = os.ExpandEnv(v)
+		}
+		if s.Transport == "" {
+			s.Transport = MCPTransportStdio
+		}
+		if s.Timeout <= 0 {
+			s.Timeout = 30 * time.Second
+		}
+	}
+
 	cfg.Minio.Endpoint = os.ExpandEnv(cfg.Minio.Endpoint)
 	cfg.Minio.AccessKey = os.ExpandEnv(cfg.Minio.AccessKey)
 	cfg.Minio.SecretKey = os.ExpandEnv(cfg.Minio.SecretKey)
 	cfg.Minio.Bucket = os.ExpandEnv(cfg.Minio.Bucket)
+	cfg.Minio.PublicEndpoint = os.ExpandEnv(cfg.Minio.PublicEndpoint)
+	if cfg.Minio.Region == "" {
+		cfg.Minio.Region = "us-east-1"
+	}
+	if cfg.Minio.PresignExpiry <= 0 {
+		cfg.Minio.PresignExpiry = 15 * time.Minute
+	}
+
+	// S3 协议要求除最后一个分片外每片至少 5MiB
+	if cfg.Upload.Multipart.PartSize < 5<<20 {
+		cfg.Upload.Multipart.PartSize = 8 << 20
+	}
+	if cfg.Upload.Multipart.StaleAfter <= 0 {
+		cfg.Upload.Multipart.StaleAfter = 24 * time.Hour
+	}
+	if cfg.Upload.Multipart.GCInterval <= 0 {
+		cfg.Upload.Multipart.GCInterval = time.Hour
+	}
+	if len(cfg.Upload.AllowedMIMETypes) == 0 {
+		cfg.Upload.AllowedMIMETypes = defaultAllowedMIMETypes
+	}
+	if cfg.Upload.DedupScope == "" {
+		cfg.Upload.DedupScope = DedupScopeUser
+	}
+
+	if cfg.Transcode.FFmpegPath == "" {
+		cfg.Transcode.FFmpegPath = "ffmpeg"
+	}
+	if cfg.Transcode.SegmentSeconds <= 0 {
+		cfg.Transcode.SegmentSeconds = 6
+	}
+	if cfg.Transcode.Timeout <= 0 {
+		cfg.Transcode.Timeout = time.Hour
+	}
+	if len(cfg.Transcode.Renditions) == 0 {
+		cfg.Transcode.Renditions = []RenditionConfig{
+			{Name: "720p", Height: 720, VideoKbps: 2800, AudioKbps: 128},
+			{Name: "480p", Height: 480, VideoKbps: 1400, AudioKbps: 96},
+		}
+	}
+
+	if cfg.Thumbnail.Quality <= 0 || cfg.Thumbnail.Quality > 100 {
+		cfg.Thumbnail.Quality = 80
+	}
+	if cfg.Thumbnail.Timeout <= 0 {
+		cfg.Thumbnail.Timeout = 5 * time.Minute
+	}
+	if len(cfg.Thumbnail.Sizes) == 0 {
+		cfg.Thumbnail.Sizes = []ThumbnailSizeConfig{
+			{Name: "small", MaxEdge: 160},
+			{Name: "medium", MaxEdge: 320},
+			{Name: "large", MaxEdge: 640},
+		}
+	}
+
+	if cfg.Metadata.FFprobePath == "" {
+		cfg.Metadata.FFprobePath = "ffprobe"
+	}
+	if cfg.Metadata.Timeout <= 0 {
+		cfg.Metadata.Timeout = 2 * time.Minute
+	}
+
+	if cfg.Archive.MaxEntries <= 0 {
+		cfg.Archive.MaxEntries = 10000
+	}
+	if cfg.Archive.MaxUncompressedSize <= 0 {
+		cfg.Archive.MaxUncompressedSize = 4 << 30
+	}
+	if cfg.Archive.MaxRatio <= 0 {
+		cfg.Archive.MaxRatio = 100
+	}
+	if cfg.Archive.Timeout <= 0 {
+		cfg.Archive.Timeout = 5 * time.Minute
+	}
+
+	cfg.Scan.ClamdAddress = os.ExpandEnv(cfg.Scan.ClamdAddress)
+	if cfg.Scan.Timeout <= 0 {
+		cfg.Scan.Timeout = 5 * time.Minute
+	}
+	if cfg.Scan.RequeueInterval <= 0 {
+		cfg.Scan.RequeueInterval = 5 * time.Minute
+	}
+
+	if cfg.Jobs.Workers <= 0 {
+		cfg.Jobs.Workers = 2
+	}
+	if cfg.Jobs.PollInterval <= 0 {
+		cfg.Jobs.PollInterval = time.Second
+	}
+	if cfg.Jobs.VisibilityTimeout <= 0 {
+		cfg.Jobs.VisibilityTimeout = 5 * time.Minute
+	}
+	if cfg.Jobs.MaxAttempts <= 0 {
+		cfg.Jobs.MaxAttempts = 5
+	}
+	if cfg.Jobs.BackoffBase <= 0 {
+		cfg.Jobs.BackoffBase = 10 * time.Second
+	}
+	if cfg.Jobs.BackoffMax <= 0 {
+		cfg.Jobs.BackoffMax = 30 * time.Minute
+	}
+	if cfg.Jobs.ShutdownTimeout <= 0 {
+		cfg.Jobs.ShutdownTimeout = 30 * time.Second
+	}
+
+	cfg.Auth.JWTSecret = os.ExpandEnv(cfg.Auth.JWTSecret)
+	if cfg.Auth.AccessTokenTTL <= 0 {
+		cfg.Auth.AccessTokenTTL = 15 * time.Minute
+	}
+	if cfg.Auth.RefreshTokenTTL <= 0 {
+		cfg.Auth.RefreshTokenTTL = 7 * 24 * time.Hour
+	}
 }
 
 func validateConfig(cfg *Config) error {
@@ -117,8 +528,103 @@ func validateConfig(cfg *Config) error {
 	if cfg.Minio.Bucket == "" {
 		missing = append(missing, "MINIO_BUCKET")
 	}
+	if cfg.Auth.JWTSecret == "" {
+		missing = append(missing, "JWT_SECRET")
+	}
+	if cfg.Scan.Enabled && cfg.Scan.ClamdAddress == "" {
+		missing = append(missing, "CLAMD_ADDRESS")
+	}
 	if len(missing) > 0 {
 		return fmt.Errorf("missing required env vars: %v", missing)
 	}
+	// HS256 密钥过短会被暴力破解
+	if len(cfg.Auth.JWTSecret) < 32 {
+		return fmt.Errorf("JWT_SECRET must be at least 32 bytes")
+	}
+	if cfg.Upload.Quota.Default < 0 {
+		return fmt.Errorf("upload.quota.default must not be negative")
+	}
+	if cfg.Upload.DedupScope != DedupScopeUser && cfg.Upload.DedupScope != DedupScopeGlobal {
+		return fmt.Errorf("upload.dedup_scope must be %q or %q", DedupScopeUser, DedupScopeGlobal)
+	}
+	if err := validateLLMConfig(cfg.LLM); err != nil {
+		return err
+	}
+	if err := validateMCPConfig(cfg.MCP); err != nil {
+		return err
+	}
+	// 规格名称用于对象键与访问路径
+	seen := map[string]bool{}
+	for _, size := range cfg.Thumbnail.Sizes {
+		if !thumbnailSizeName.MatchString(size.Name) || seen[size.Name] {
+			return fmt.Errorf("thumbnail.sizes: invalid or duplicate name %q", size.Name)
+		}
+		if size.MaxEdge <= 0 {
+			return fmt.Errorf("thumbnail.sizes: max_edge of %q must be positive", size.Name)
+		}
+		seen[size.Name] = true
+	}
+	return nil
+}
+
+var thumbnailSizeName = regexp.MustCompile(`^[a-z0-9_-]{1,16}$`)
+
+// validateLLMConfig 校验提供方名称唯一、模型路由引用的提供方存在，且默认模型在白名单中
+func validateLLMConfig(cfg LLMConfig) error {
+	providers := map[string]bool{QiNiuProviderName: true}
+	for _, p := range cfg.Providers {
+		if p.Name == "" || providers[p.Name] {
+			return fmt.Errorf("llm.providers: empty or duplicate name %q", p.Name)
+		}
+		if p.BaseURL == "" {
+			return fmt.Errorf("llm.providers: base_url of %q is required", p.Name)
+		}
+		providers[p.Name] = true
+	}
+
+	models := map[string]bool{}
+	for _, m := range cfg.Models {
+		if m.Name == "" || models[m.Name] {
+			return fmt.Errorf("llm.models: empty or duplicate name %q", m.Name)
+		}
+		if len(m.Routes) == 0 {
+			return fmt.Errorf("llm.models: %q has no routes", m.Name)
+		}
+		for _, r := range m.Routes {
+			if !providers[r.Provider] {
+				return fmt.Errorf("llm.models: %q routes to unknown provider %q", m.Name, r.Provider)
+			}
+		}
+		models[m.Name] = true
+	}
+	if !models[cfg.DefaultModel] {
+		return fmt.Errorf("llm.default_model %q is not in llm.models", cfg.DefaultModel)
+	}
+	return nil
+}
+
+// mcpServerName 服务器名称作为工具名前缀，需满足模型函数名的字符限制
+var mcpServerName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+func validateMCPConfig(cfg MCPConfig) error {
+	seen := map[string]bool{}
+	for _, s := range cfg.Servers {
+		if !mcpServerName.MatchString(s.Name) || seen[s.Name] {
+			return fmt.Errorf("mcp.servers: invalid or duplicate name %q", s.Name)
+		}
+		seen[s.Name] = true
+		switch s.Transport {
+		case MCPTransportStdio:
+			if s.Command == "" {
+				return fmt.Errorf("mcp.servers: command of %q is required", s.Name)
+			}
+		case MCPTransportHTTP:
+			if s.URL == "" {
+				return fmt.Errorf("mcp.servers: url of %q is required", s.Name)
+			}
+		default:
+			return fmt.Errorf("mcp.servers: transport of %q must be %q or %q", s.Name, MCPTransportStdio, MCPTransportHTTP)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,83 @@
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+type ArchiveManifestDAO struct{}
+
+func NewArchiveManifestDAO() *ArchiveManifestDAO { return &ArchiveManifestDAO{} }
+
+// Upsert 创建或重置检查记录，清空已有结果
+func (d *ArchiveManifestDAO) Upsert(fileID int64, format, status string) error {
+	query := `INSERT INTO archive_manifests (file_id, format, status) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE format = VALUES(format), status = VALUES(status), entry_count = 0, total_size = 0,
+			compressed_size = 0, reject_code = '', error = '', entries = NULL`
+	if _, err := database.DB.Exec(query, fileID, format, status); err != nil {
+		return fmt.Errorf("failed to upsert archive manifest: %w", err)
+	}
+	return nil
+}
+
+// Save 写入检查结果（ready 或 rejected）
+func (d *ArchiveManifestDAO) Save(m *models.ArchiveManifest) error {
+	var entries interface{}
+	if m.Entries != nil {
+		b, err := json.Marshal(m.Entries)
+		if err != nil {
+			return fmt.Errorf("failed to encode archive entries: %w", err)
+		}
+		entries = string(b)
+	}
+	errMsg := m.Error
+	if len(errMsg) > 1024 {
+		errMsg = errMsg[:1024]
+	}
+	query := `UPDATE archive_manifests SET status = ?, entry_count = ?, total_size = ?, compressed_size = ?,
+		reject_code = ?, error = ?, entries = ? WHERE file_id = ?`
+	_, err := database.DB.Exec(query, m.Status, m.EntryCount, m.TotalSize, m.CompressedSize, m.RejectCode, errMsg, entries, m.FileID)
+	if err != nil {
+		return fmt.Errorf("failed to save archive manifest: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed 标记检查失败
+func (d *ArchiveManifestDAO) MarkFailed(fileID int64, errMsg string) error {
+	if len(errMsg) > 1024 {
+		errMsg = errMsg[:1024]
+	}
+	query := "UPDATE archive_manifests SET status = ?, error = ? WHERE file_id = ?"
+	if _, err := database.DB.Exec(query, models.ArchiveStatusFailed, errMsg, fileID); err != nil {
+		return fmt.Errorf("failed to update archive manifest: %w", err)
+	}
+	return nil
+}
+
+// Get 获取文件的压缩包检查记录，不存在时返回 nil
+func (d *ArchiveManifestDAO) Get(fileID int64) (*models.ArchiveManifest, error) {
+	m := &models.ArchiveManifest{}
+	var entries sql.NullString
+	err := database.DB.QueryRow(
+		`SELECT file_id, status, format, entry_count, total_size, compressed_size, reject_code, error, entries, created_at, updated_at
+		FROM archive_manifests WHERE file_id = ?`, fileID,
+	).Scan(&m.FileID, &m.Status, &m.Format, &m.EntryCount, &m.TotalSize, &m.CompressedSize,
+		&m.RejectCode, &m.Error, &entries, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get archive manifest: %w", err)
+	}
+	if entries.Valid && entries.String != "" {
+		if err := json.Unmarshal([]byte(entries.String), &m.Entries); err != nil {
+			return nil, fmt.Errorf("failed to decode archive entries: %w", err)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,280 @@
+package dao
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+// ConversationDAO 聊天会话与消息数据访问对象
+type ConversationDAO struct{}
+
+// NewConversationDAO 创建新的会话DAO实例
+func NewConversationDAO() *ConversationDAO {
+	return &ConversationDAO{}
+}
+
+// GetOrCreate 按 (uid, memoryID) 获取会话，不存在时以 title 创建
+func (dao *ConversationDAO) GetOrCreate(uid int, memoryID, title string) (*models.Conversation, error) {
+	// 唯一索引保证并发请求不会创建重复会话
+	query := "INSERT IGNORE INTO conversations (uid, memory_id, title) VALUES (?, ?, ?)"
+	if _, err := database.DB.Exec(query, uid, memoryID, title); err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	conv, err := dao.GetByMemoryID(uid, memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, fmt.Errorf("conversation %q missing after insert", memoryID)
+	}
+	return conv, nil
+}
+
+// GetByMemoryID 获取指定用户的会话
+func (dao *ConversationDAO) GetByMemoryID(uid int, memoryID string) (*models.Conversation, error) {
+	query := "SELECT id, uid, memory_id, title, created_at, updated_at FROM conversations WHERE uid = ? AND memory_id = ?"
+	row := database.DB.QueryRow(query, uid, memoryID)
+
+	conv := &models.Conversation{}
+	err := row.Scan(&conv.ID, &conv.Uid, &conv.MemoryID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 会话不存在
+		}
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	return conv, nil
+}
+
+// AddExchange 在同一事务中追加一轮对话：用户消息及其附件引用与助手回复，并刷新会话活跃时间。
+// 只在模型成功回复后写入，失败的请求不会在会话中留下没有回复的用户消息
+func (dao *ConversationDAO) AddExchange(conversationID int64, userContent string, attachments []models.MessageAttachment, assistantContent string) error {
+	return database.WithTx(func(tx *sql.Tx) error {
+		userID, err := insertMessage(tx, conversationID, models.RoleUser, userContent)
+		if err != nil {
+			return err
+		}
+		for _, a := range attachments {
+			if _, err := tx.Exec("INSERT INTO message_attachments (message_id, file_id, file_name, content_type) VALUES (?, ?, ?, ?)",
+				userID, a.FileID, a.FileName, a.ContentType); err != nil {
+				return fmt.Errorf("failed to insert message attachment: %w", err)
+			}
+		}
+		if _, err := insertMessage(tx, conversationID, models.RoleAssistant, assistantContent); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE conversations SET updated_at = NOW() WHERE id = ?", conversationID); err != nil {
+			return fmt.Errorf("failed to touch conversation: %w", err)
+		}
+		return nil
+	})
+}
+
+func insertMessage(tx *sql.Tx, conversationID int64, role, content string) (int64, error) {
+	res, err := tx.Exec("INSERT INTO messages (conversation_id, role, content) VALUES (?, ?, ?)", conversationID, role, content)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert message: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get message id: %w", err)
+	}
+	return id, nil
+}
+
+// ListRecentMessages 按时间正序返回会话最近的 limit 条消息
+func (dao *ConversationDAO) ListRecentMessages(conversationID int64, limit int) ([]models.Message, error) {
+	query := `SELECT id, conversation_id, role, content, created_at FROM (
+		SELECT id, conversation_id, role, content, created_at FROM messages
+		WHERE conversation_id = ? ORDER BY id DESC LIMIT ?
+	) recent ORDER BY id ASC`
+	rows, err := database.DB.Query(query, conversationID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+func scanMessages(rows *sql.Rows) ([]models.Message, error) {
+	messages := []models.Message{}
+	for rows.Next() {
+		var m models.Message
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate messages: %w", err)
+	}
+	return messages, nil
+}
+
+// ListByUID 按最后活跃时间倒序分页列出用户会话，同时返回总数
+func (dao *ConversationDAO) ListByUID(uid, offset, limit int) ([]models.Conversation, int, error) {
+	var total int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM conversations WHERE uid = ?", uid).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+
+	query := "SELECT id, uid, memory_id, title, created_at, updated_at FROM conversations WHERE uid = ? ORDER BY updated_at DESC, id DESC LIMIT ? OFFSET ?"
+	rows, err := database.DB.Query(query, uid, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+
+	conversations := []models.Conversation{}
+	for rows.Next() {
+		var conv models.Conversation
+		if err := rows.Scan(&conv.ID, &conv.Uid, &conv.MemoryID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		conversations = append(conversations, conv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate conversations: %w", err)
+	}
+	return conversations, total, nil
+}
+
+// SearchMessages 在用户全部会话中按关键字搜索消息内容与会话标题，按时间倒序返回最多 limit 条
+func (dao *ConversationDAO) SearchMessages(uid int, query string, limit int) ([]models.MessageSearchHit, error) {
+	pattern := "%" + escapeLike(query) + "%"
+	rows, err := database.DB.Query(
+		`SELECT c.id, c.title, m.id, m.role, m.content, m.created_at
+		FROM messages m JOIN conversations c ON c.id = m.conversation_id
+		WHERE c.uid = ? AND (m.content LIKE ? OR c.title LIKE ?)
+		ORDER BY m.id DESC LIMIT ?`,
+		uid, pattern, pattern, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []models.MessageSearchHit{}
+	for rows.Next() {
+		var h models.MessageSearchHit
+		if err := rows.Scan(&h.ConversationID, &h.Title, &h.MessageID, &h.Role, &h.Content, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message search hit: %w", err)
+		}
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate message search hits: %w", err)
+	}
+	return hits, nil
+}
+
+// GetByID 获取属于指定用户的会话，不属于该用户时返回 nil
+func (dao *ConversationDAO) GetByID(uid int, id int64) (*models.Conversation, error) {
+	query := "SELECT id, uid, memory_id, title, created_at, updated_at FROM conversations WHERE id = ? AND uid = ?"
+	row := database.DB.QueryRow(query, id, uid)
+
+	conv := &models.Conversation{}
+	err := row.Scan(&conv.ID, &conv.Uid, &conv.MemoryID, &conv.Title, &conv.CreatedAt, &conv.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 会话不存在或不属于该用户
+		}
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	return conv, nil
+}
+
+// ListMessages 按时间正序返回会话全部消息及其附件引用
+func (dao *ConversationDAO) ListMessages(conversationID int64) ([]models.Message, error) {
+	query := "SELECT id, conversation_id, role, content, created_at FROM messages WHERE conversation_id = ? ORDER BY id ASC"
+	rows, err := database.DB.Query(query, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := dao.loadAttachments(conversationID, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// loadAttachments 读取会话内全部附件引用并挂到对应消息上
+func (dao *ConversationDAO) loadAttachments(conversationID int64, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	query := `SELECT a.message_id, a.file_id, a.file_name, a.content_type FROM message_attachments a
+		JOIN messages m ON m.id = a.message_id
+		WHERE m.conversation_id = ? ORDER BY a.id ASC`
+	rows, err := database.DB.Query(query, conversationID)
+	if err != nil {
+		return fmt.Errorf("failed to list message attachments: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int, len(messages))
+	for i, m := range messages {
+		index[m.ID] = i
+	}
+	for rows.Next() {
+		var a models.MessageAttachment
+		var fileID sql.NullInt64
+		if err := rows.Scan(&a.MessageID, &fileID, &a.FileName, &a.ContentType); err != nil {
+			return fmt.Errorf("failed to scan message attachment: %w", err)
+		}
+		if fileID.Valid {
+			a.FileID = &fileID.Int64
+		}
+		if i, ok := index[a.MessageID]; ok {
+			messages[i].Attachments = append(messages[i].Attachments, a)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate message attachments: %w", err)
+	}
+	return nil
+}
+
+// Rename 修改会话标题，返回是否命中属于该用户的会话
+func (dao *ConversationDAO) Rename(uid int, id int64, title string) (bool, error) {
+	res, err := database.DB.Exec("UPDATE conversations SET title = ?, updated_at = updated_at WHERE id = ? AND uid = ?", title, id, uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to rename conversation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to rename conversation: %w", err)
+	}
+	if n > 0 {
+		return true, nil
+	}
+	// 标题未变化时 RowsAffected 为 0，需要再确认会话是否存在
+	conv, err := dao.GetByID(uid, id)
+	if err != nil {
+		return false, err
+	}
+	return conv != nil, nil
+}
+
+// Delete 删除会话及其消息，返回是否命中属于该用户的会话
+func (dao *ConversationDAO) Delete(uid int, id int64) (bool, error) {
+	res, err := database.DB.Exec("DELETE FROM conversations WHERE id = ? AND uid = ?", id, uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return n > 0, nil
+}
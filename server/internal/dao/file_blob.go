@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+)
+
+// FileBlobDAO 内容寻址对象的引用计数，增减均在调用方事务中进行
+type FileBlobDAO struct{}
+
+func NewFileBlobDAO() *FileBlobDAO { return &FileBlobDAO{} }
+
+// Acquire 为对象增加一次引用，返回对象记录是否为本次新建
+func (d *FileBlobDAO) Acquire(q database.Querier, bucket, objectKey, sha256 string, size int64) (bool, error) {
+	query := `INSERT INTO file_blobs (bucket, object_key, sha256, file_size, ref_count) VALUES (?, ?, ?, ?, 1)
+		ON DUPLICATE KEY UPDATE ref_count = ref_count + 1`
+	res, err := q.Exec(query, bucket, objectKey, sha256, size)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire file blob: %w", err)
+	}
+	// ON DUPLICATE KEY UPDATE 命中已有行时影响行数为 2
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire file blob: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Release 释放一次引用并返回剩余引用数，引用归零时删除对象记录。
+// 对象未登记（内容寻址之前上传的文件）时 found 为 false。
+func (d *FileBlobDAO) Release(tx *sql.Tx, bucket, objectKey string) (remaining int, found bool, err error) {
+	var refCount int
+	err = tx.QueryRow("SELECT ref_count FROM file_blobs WHERE bucket = ? AND object_key = ? FOR UPDATE", bucket, objectKey).Scan(&refCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to lock file blob: %w", err)
+	}
+
+	if refCount <= 1 {
+		if _, err := tx.Exec("DELETE FROM file_blobs WHERE bucket = ? AND object_key = ?", bucket, objectKey); err != nil {
+			return 0, true, fmt.Errorf("failed to delete file blob: %w", err)
+		}
+		return 0, true, nil
+	}
+	if _, err := tx.Exec("UPDATE file_blobs SET ref_count = ref_count - 1 WHERE bucket = ? AND object_key = ?", bucket, objectKey); err != nil {
+		return 0, true, fmt.Errorf("failed to release file blob: %w", err)
+	}
+	return refCount - 1, true, nil
+}
@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+type FileMetadataDAO struct{}
+
+func NewFileMetadataDAO() *FileMetadataDAO { return &FileMetadataDAO{} }
+
+// Upsert 创建或重置为 pending 状态，清空已提取的字段
+func (d *FileMetadataDAO) Upsert(fileID int64) error {
+	query := `INSERT INTO file_metadata (file_id, status) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE status = VALUES(status), kind = '', duration = 0, width = 0, height = 0,
+			codec = '', bitrate = 0, sample_rate = 0, channels = 0, exif = NULL, error = ''`
+	if _, err := database.DB.Exec(query, fileID, models.MetadataStatusPending); err != nil {
+		return fmt.Errorf("failed to upsert file metadata: %w", err)
+	}
+	return nil
+}
+
+// Save 写入提取结果并标记为 ready
+func (d *FileMetadataDAO) Save(m *models.FileMetadata) error {
+	var exif interface{}
+	if len(m.EXIF) > 0 {
+		b, err := json.Marshal(m.EXIF)
+		if err != nil {
+			return fmt.Errorf("failed to encode exif: %w", err)
+		}
+		exif = string(b)
+	}
+	query := `UPDATE file_metadata SET status = ?, kind = ?, duration = ?, width = ?, height = ?, codec = ?,
+		bitrate = ?, sample_rate = ?, channels = ?, exif = ?, error = '' WHERE file_id = ?`
+	_, err := database.DB.Exec(query, models.MetadataStatusReady, m.Kind, m.Duration, m.Width, m.Height, m.Codec,
+		m.Bitrate, m.SampleRate, m.Channels, exif, m.FileID)
+	if err != nil {
+		return fmt.Errorf("failed to save file metadata: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed 标记提取失败
+func (d *FileMetadataDAO) MarkFailed(fileID int64, errMsg string) error {
+	if len(errMsg) > 1024 {
+		errMsg = errMsg[:1024]
+	}
+	query := "UPDATE file_metadata SET status = ?, error = ? WHERE file_id = ?"
+	if _, err := database.DB.Exec(query, models.MetadataStatusFailed, errMsg, fileID); err != nil {
+		return fmt.Errorf("failed to update file metadata: %w", err)
+	}
+	return nil
+}
+
+// Get 获取文件的元数据记录，不存在时返回 nil
+func (d *FileMetadataDAO) Get(fileID int64) (*models.FileMetadata, error) {
+	m := &models.FileMetadata{}
+	var exif sql.NullString
+	err := database.DB.QueryRow(
+		`SELECT file_id, status, kind, duration, width, height, codec, bitrate, sample_rate, channels, exif, error, created_at, updated_at
+		FROM file_metadata WHERE file_id = ?`, fileID,
+	).Scan(&m.FileID, &m.Status, &m.Kind, &m.Duration, &m.Width, &m.Height, &m.Codec, &m.Bitrate,
+		&m.SampleRate, &m.Channels, &exif, &m.Error, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get file metadata: %w", err)
+	}
+	if exif.Valid && exif.String != "" {
+		if err := json.Unmarshal([]byte(exif.String), &m.EXIF); err != nil {
+			return nil, fmt.Errorf("failed to decode exif: %w", err)
+		}
+	}
+	return m, nil
+}
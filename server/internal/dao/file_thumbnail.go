@@ -0,0 +1,87 @@
+package dao
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+type FileThumbnailDAO struct{}
+
+func NewFileThumbnailDAO() *FileThumbnailDAO { return &FileThumbnailDAO{} }
+
+const fileThumbnailColumns = "file_id, size, width, height, object_key, file_size, created_at"
+
+// Replace 用新生成的缩略图替换文件已有的全部缩略图记录
+func (d *FileThumbnailDAO) Replace(fileID int64, thumbs []models.FileThumbnail) error {
+	return database.WithTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM file_thumbnails WHERE file_id = ?", fileID); err != nil {
+			return fmt.Errorf("failed to delete file thumbnails: %w", err)
+		}
+		for _, t := range thumbs {
+			_, err := tx.Exec(
+				"INSERT INTO file_thumbnails (file_id, size, width, height, object_key, file_size) VALUES (?, ?, ?, ?, ?, ?)",
+				fileID, t.Size, t.Width, t.Height, t.ObjectKey, t.FileSize,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert file thumbnail: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Get 获取文件指定规格的缩略图，不存在时返回 nil
+func (d *FileThumbnailDAO) Get(fileID int64, size string) (*models.FileThumbnail, error) {
+	query := "SELECT " + fileThumbnailColumns + " FROM file_thumbnails WHERE file_id = ? AND size = ?"
+	t, err := scanFileThumbnail(database.DB.QueryRow(query, fileID, size))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get file thumbnail: %w", err)
+	}
+	return t, nil
+}
+
+// ListByFileIDs 批量获取多个文件的缩略图，按文件ID分组，组内按宽度升序
+func (d *FileThumbnailDAO) ListByFileIDs(fileIDs []int64) (map[int64][]models.FileThumbnail, error) {
+	result := make(map[int64][]models.FileThumbnail, len(fileIDs))
+	if len(fileIDs) == 0 {
+		return result, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(fileIDs)), ",")
+	args := make([]interface{}, len(fileIDs))
+	for i, id := range fileIDs {
+		args[i] = id
+	}
+	query := "SELECT " + fileThumbnailColumns + " FROM file_thumbnails WHERE file_id IN (" + placeholders + ") ORDER BY file_id, width"
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file thumbnails: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanFileThumbnail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file thumbnail: %w", err)
+		}
+		result[t.FileID] = append(result[t.FileID], *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate file thumbnails: %w", err)
+	}
+	return result, nil
+}
+
+func scanFileThumbnail(row interface{ Scan(...interface{}) error }) (*models.FileThumbnail, error) {
+	t := &models.FileThumbnail{}
+	if err := row.Scan(&t.FileID, &t.Size, &t.Width, &t.Height, &t.ObjectKey, &t.FileSize, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
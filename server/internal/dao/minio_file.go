@@ -1,20 +1,261 @@
 package dao
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/go-sql-driver/mysql"
 )
 
+// ErrDuplicateFile 同一用户已登记相同内容的文件
+var ErrDuplicateFile = errors.New("duplicate file content")
+
 type MinioFileDAO struct{}
 
 func NewMinioFileDAO() *MinioFileDAO { return &MinioFileDAO{} }
 
-func (d *MinioFileDAO) Create(uid int, fileName, fileURL string) error {
-	query := "INSERT INTO minio_files (uid, file_name, file_url) VALUES (?, ?, ?)"
-	_, err := database.DB.Exec(query, uid, fileName, fileURL)
+const minioFileColumns = "id, uid, file_name, bucket, object_key, content_type, file_size, sha256, is_shared, scan_status, scan_result, created_at"
+
+// Create 写入文件记录，同一用户重复登记相同 sha256 时返回 ErrDuplicateFile
+func (d *MinioFileDAO) Create(q database.Querier, f *models.MinioFile) error {
+	query := "INSERT INTO minio_files (uid, file_name, bucket, object_key, content_type, file_size, sha256, scan_status) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	sha := sql.NullString{String: f.SHA256, Valid: f.SHA256 != ""}
+	if f.ScanStatus == "" {
+		f.ScanStatus = models.ScanStatusClean
+	}
+	res, err := q.Exec(query, f.Uid, f.FileName, f.Bucket, f.ObjectKey, f.ContentType, f.FileSize, sha, f.ScanStatus)
 	if err != nil {
+		if isDuplicateEntry(err) {
+			return ErrDuplicateFile
+		}
 		return fmt.Errorf("failed to insert minio file record: %w", err)
 	}
+	if f.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get minio file id: %w", err)
+	}
 	return nil
 }
+
+// GetByID 按ID获取文件记录，不存在时返回 nil
+func (d *MinioFileDAO) GetByID(id int64) (*models.MinioFile, error) {
+	query := "SELECT " + minioFileColumns + " FROM minio_files WHERE id = ?"
+	f, err := scanMinioFile(database.DB.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 文件不存在
+		}
+		return nil, fmt.Errorf("failed to get minio file: %w", err)
+	}
+	return f, nil
+}
+
+// GetByUIDAndSHA256 按内容哈希查找用户已上传的文件，不存在时返回 nil
+func (d *MinioFileDAO) GetByUIDAndSHA256(uid int, sha256 string) (*models.MinioFile, error) {
+	query := "SELECT " + minioFileColumns + " FROM minio_files WHERE uid = ? AND sha256 = ?"
+	f, err := scanMinioFile(database.DB.QueryRow(query, uid, sha256))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get minio file by sha256: %w", err)
+	}
+	return f, nil
+}
+
+func scanMinioFile(row interface{ Scan(...interface{}) error }) (*models.MinioFile, error) {
+	f := &models.MinioFile{}
+	var sha sql.NullString
+	err := row.Scan(&f.ID, &f.Uid, &f.FileName, &f.Bucket, &f.ObjectKey, &f.ContentType, &f.FileSize, &sha, &f.IsShared, &f.ScanStatus, &f.ScanResult, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	f.SHA256 = sha.String
+	return f, nil
+}
+
+// ListFilesOptions 文件列表查询条件
+type ListFilesOptions struct {
+	Query  string // 文件名模糊匹配
+	SortBy string // created_at / file_name / file_size
+	Desc   bool
+	Offset int
+	Limit  int
+	// ContentTypePrefixes 非空时只列出 content_type 以其中之一开头的文件，如 "image/"
+	ContentTypePrefixes []string
+	// MediaClasses 非空时只列出属于其中之一的文件，判定规则与 MinioFile.IsImage 等相同，包含按扩展名判定的旧记录
+	MediaClasses []models.MediaClass
+}
+
+var fileSortColumns = map[string]string{
+	"created_at": "created_at",
+	"file_name":  "file_name",
+	"file_size":  "file_size",
+}
+
+// ListByUID 分页列出用户上传的文件，同时返回总数
+func (d *MinioFileDAO) ListByUID(uid int, opts ListFilesOptions) ([]models.MinioFile, int, error) {
+	where := "WHERE uid = ?"
+	args := []interface{}{uid}
+	if opts.Query != "" {
+		where += " AND file_name LIKE ?"
+		args = append(args, "%"+escapeLike(opts.Query)+"%")
+	}
+	if len(opts.ContentTypePrefixes) > 0 {
+		conds := make([]string, len(opts.ContentTypePrefixes))
+		for i, prefix := range opts.ContentTypePrefixes {
+			conds[i] = "content_type LIKE ?"
+			args = append(args, escapeLike(prefix)+"%")
+		}
+		where += " AND (" + strings.Join(conds, " OR ") + ")"
+	}
+	if len(opts.MediaClasses) > 0 {
+		var conds []string
+		for _, class := range opts.MediaClasses {
+			conds = append(conds, "content_type LIKE ?")
+			args = append(args, escapeLike(class.ContentTypePrefix)+"%")
+			for _, ext := range class.Extensions {
+				conds = append(conds, "LOWER(file_name) LIKE ?")
+				args = append(args, "%"+escapeLike(ext))
+			}
+		}
+		where += " AND (" + strings.Join(conds, " OR ") + ")"
+	}
+
+	var total int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM minio_files "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count minio files: %w", err)
+	}
+
+	// 排序列只能取白名单中的值，避免拼接注入
+	column, ok := fileSortColumns[opts.SortBy]
+	if !ok {
+		column = "created_at"
+	}
+	direction := "ASC"
+	if opts.Desc {
+		direction = "DESC"
+	}
+	query := "SELECT " + minioFileColumns + " FROM minio_files " + where +
+		" ORDER BY " + column + " " + direction + ", id " + direction + " LIMIT ? OFFSET ?"
+	rows, err := database.DB.Query(query, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list minio files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []models.MinioFile{}
+	for rows.Next() {
+		f, err := scanMinioFile(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan minio file: %w", err)
+		}
+		files = append(files, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate minio files: %w", err)
+	}
+	return files, total, nil
+}
+
+// UpdateScanStatus 记录扫描结果，只更新仍处于 pending 的记录，返回是否更新
+func (d *MinioFileDAO) UpdateScanStatus(id int64, status, result string) (bool, error) {
+	if len(result) > 255 {
+		result = result[:255]
+	}
+	res, err := database.DB.Exec(
+		"UPDATE minio_files SET scan_status = ?, scan_result = ? WHERE id = ? AND scan_status = ?",
+		status, result, id, models.ScanStatusPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update scan status: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update scan status: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListPendingWithoutJob 列出在 before 之前登记、仍处于 pending 且从未入队过 jobType 任务的文件，
+// 即上传钩子入队失败的文件；已进入死信的扫描任务不在此列，避免永久失败的文件被反复入队
+func (d *MinioFileDAO) ListPendingWithoutJob(jobType string, before time.Time, limit int) ([]models.MinioFile, error) {
+	query := "SELECT " + minioFileColumns + " FROM minio_files WHERE scan_status = ? AND created_at < ?" +
+		" AND NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.type = ? AND JSON_EXTRACT(jobs.payload, '$.file_id') = minio_files.id)" +
+		" ORDER BY id ASC LIMIT ?"
+	rows, err := database.DB.Query(query, models.ScanStatusPending, before, jobType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending minio files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []models.MinioFile{}
+	for rows.Next() {
+		f, err := scanMinioFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan minio file: %w", err)
+		}
+		files = append(files, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate minio files: %w", err)
+	}
+	return files, nil
+}
+
+// UpdateFileName 修改文件显示名称，返回是否命中属于该用户的文件
+func (d *MinioFileDAO) UpdateFileName(uid int, id int64, fileName string) (bool, error) {
+	res, err := database.DB.Exec("UPDATE minio_files SET file_name = ? WHERE id = ? AND uid = ?", fileName, id, uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to rename minio file: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to rename minio file: %w", err)
+	}
+	return n > 0, nil
+}
+
+// GetByIDForUpdate 在事务中获取并锁定属于指定用户的文件记录
+func (d *MinioFileDAO) GetByIDForUpdate(tx *sql.Tx, uid int, id int64) (*models.MinioFile, error) {
+	query := "SELECT " + minioFileColumns + " FROM minio_files WHERE id = ? AND uid = ? FOR UPDATE"
+	f, err := scanMinioFile(tx.QueryRow(query, id, uid))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 文件不存在或不属于该用户
+		}
+		return nil, fmt.Errorf("failed to get minio file: %w", err)
+	}
+	return f, nil
+}
+
+// Delete 删除文件记录
+func (d *MinioFileDAO) Delete(q database.Querier, id int64) error {
+	if _, err := q.Exec("DELETE FROM minio_files WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete minio file: %w", err)
+	}
+	return nil
+}
+
+// CountByObjectKey 统计引用同一对象的文件记录数
+func (d *MinioFileDAO) CountByObjectKey(q database.Querier, bucket, objectKey string) (int, error) {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM minio_files WHERE bucket = ? AND object_key = ?", bucket, objectKey).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count minio files by object key: %w", err)
+	}
+	return count, nil
+}
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+// MultipartUploadDAO 分片上传会话数据访问对象
+type MultipartUploadDAO struct{}
+
+// NewMultipartUploadDAO 创建新的分片上传DAO实例
+func NewMultipartUploadDAO() *MultipartUploadDAO {
+	return &MultipartUploadDAO{}
+}
+
+const multipartUploadColumns = "id, uid, storage_upload_id, object_key, file_name, file_size, content_type, part_size, status, created_at, updated_at"
+
+// Create 保存新的分片上传会话
+func (dao *MultipartUploadDAO) Create(u *models.MultipartUpload) error {
+	query := "INSERT INTO multipart_uploads (id, uid, storage_upload_id, object_key, file_name, file_size, content_type, part_size, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := database.DB.Exec(query, u.ID, u.Uid, u.StorageUpload, u.ObjectKey, u.FileName, u.FileSize, u.ContentType, u.PartSize, u.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+	return nil
+}
+
+// GetByID 获取属于指定用户的分片上传会话，不存在时返回 nil
+func (dao *MultipartUploadDAO) GetByID(uid int, id string) (*models.MultipartUpload, error) {
+	query := "SELECT " + multipartUploadColumns + " FROM multipart_uploads WHERE id = ? AND uid = ?"
+	u, err := scanMultipartUpload(database.DB.QueryRow(query, id, uid))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 会话不存在或不属于该用户
+		}
+		return nil, fmt.Errorf("failed to get multipart upload: %w", err)
+	}
+	return u, nil
+}
+
+// UpdateStatus 仅当当前状态为 from 时更新为 to，返回是否更新成功
+func (dao *MultipartUploadDAO) UpdateStatus(id, from, to string) (bool, error) {
+	res, err := database.DB.Exec("UPDATE multipart_uploads SET status = ? WHERE id = ? AND status = ?", to, id, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update multipart upload status: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update multipart upload status: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Touch 刷新会话活跃时间
+func (dao *MultipartUploadDAO) Touch(id string) error {
+	if _, err := database.DB.Exec("UPDATE multipart_uploads SET updated_at = NOW() WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to touch multipart upload: %w", err)
+	}
+	return nil
+}
+
+// ListStale 列出在 before 之前就不再活跃的未完成会话（上传中或已合并待登记）
+func (dao *MultipartUploadDAO) ListStale(before time.Time, limit int) ([]models.MultipartUpload, error) {
+	query := "SELECT " + multipartUploadColumns + " FROM multipart_uploads WHERE status IN (?, ?) AND updated_at < ? ORDER BY updated_at ASC LIMIT ?"
+	rows, err := database.DB.Query(query, models.MultipartStatusUploading, models.MultipartStatusAssembled, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale multipart uploads: %w", err)
+	}
+	defer rows.Close()
+
+	uploads := []models.MultipartUpload{}
+	for rows.Next() {
+		u, err := scanMultipartUpload(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan multipart upload: %w", err)
+		}
+		uploads = append(uploads, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate multipart uploads: %w", err)
+	}
+	return uploads, nil
+}
+
+func scanMultipartUpload(row interface{ Scan(...interface{}) error }) (*models.MultipartUpload, error) {
+	u := &models.MultipartUpload{}
+	err := row.Scan(&u.ID, &u.Uid, &u.StorageUpload, &u.ObjectKey, &u.FileName, &u.FileSize, &u.ContentType, &u.PartSize, &u.Status, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
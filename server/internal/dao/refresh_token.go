@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+// RefreshTokenDAO 刷新令牌数据访问对象
+type RefreshTokenDAO struct{}
+
+// NewRefreshTokenDAO 创建新的刷新令牌DAO实例
+func NewRefreshTokenDAO() *RefreshTokenDAO {
+	return &RefreshTokenDAO{}
+}
+
+// Create 保存刷新令牌哈希
+func (dao *RefreshTokenDAO) Create(q database.Querier, uid int, familyID, tokenHash string, expiresAt time.Time) error {
+	query := "INSERT INTO refresh_tokens (uid, family_id, token_hash, expires_at) VALUES (?, ?, ?, ?)"
+	_, err := q.Exec(query, uid, familyID, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByHashForUpdate 在事务中按哈希查询并锁定刷新令牌
+func (dao *RefreshTokenDAO) GetByHashForUpdate(tx *sql.Tx, tokenHash string) (*models.RefreshToken, error) {
+	query := "SELECT id, uid, family_id, token_hash, expires_at, used_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = ? FOR UPDATE"
+	row := tx.QueryRow(query, tokenHash)
+
+	rt := &models.RefreshToken{}
+	err := row.Scan(&rt.ID, &rt.Uid, &rt.FamilyID, &rt.TokenHash, &rt.ExpiresAt, &rt.UsedAt, &rt.RevokedAt, &rt.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 令牌不存在
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return rt, nil
+}
+
+// MarkUsed 标记刷新令牌已被轮换
+func (dao *RefreshTokenDAO) MarkUsed(q database.Querier, id int64) error {
+	query := "UPDATE refresh_tokens SET used_at = NOW() WHERE id = ?"
+	_, err := q.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	return nil
+}
+
+// RevokeFamily 吊销同一会话下的全部刷新令牌
+func (dao *RefreshTokenDAO) RevokeFamily(q database.Querier, familyID string) error {
+	query := "UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = ? AND revoked_at IS NULL"
+	_, err := q.Exec(query, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+// IsFamilyRevoked 检查会话是否已被吊销
+func (dao *RefreshTokenDAO) IsFamilyRevoked(familyID string) (bool, error) {
+	query := "SELECT COUNT(*) FROM refresh_tokens WHERE family_id = ? AND revoked_at IS NOT NULL"
+	var count int
+	err := database.DB.QueryRow(query, familyID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check refresh token family: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,62 @@
+package dao
+
+import (
+	"fmt"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+// StorageUsageDAO 用户存储用量计数，写操作需在调用方事务中进行
+type StorageUsageDAO struct{}
+
+func NewStorageUsageDAO() *StorageUsageDAO { return &StorageUsageDAO{} }
+
+// ensure 首次访问时按 minio_files 现有记录初始化用量
+func (d *StorageUsageDAO) ensure(q database.Querier, uid int) error {
+	query := `INSERT IGNORE INTO user_storage_usage (uid, used_bytes, file_count)
+		SELECT ?, COALESCE(SUM(file_size), 0), COUNT(*) FROM minio_files WHERE uid = ?`
+	if _, err := q.Exec(query, uid, uid); err != nil {
+		return fmt.Errorf("failed to init storage usage: %w", err)
+	}
+	return nil
+}
+
+// Get 获取用户用量
+func (d *StorageUsageDAO) Get(uid int) (*models.StorageUsage, error) {
+	if err := d.ensure(database.DB, uid); err != nil {
+		return nil, err
+	}
+	u := &models.StorageUsage{Uid: uid}
+	err := database.DB.QueryRow("SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = ?", uid).
+		Scan(&u.UsedBytes, &u.FileCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	return u, nil
+}
+
+// GetForUpdate 在事务中获取并锁定用户用量
+func (d *StorageUsageDAO) GetForUpdate(q database.Querier, uid int) (*models.StorageUsage, error) {
+	if err := d.ensure(q, uid); err != nil {
+		return nil, err
+	}
+	u := &models.StorageUsage{Uid: uid}
+	err := q.QueryRow("SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = ? FOR UPDATE", uid).
+		Scan(&u.UsedBytes, &u.FileCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock storage usage: %w", err)
+	}
+	return u, nil
+}
+
+// Add 累加用量，delta 为负表示释放
+func (d *StorageUsageDAO) Add(q database.Querier, uid int, bytes int64, files int) error {
+	query := `UPDATE user_storage_usage
+		SET used_bytes = GREATEST(used_bytes + ?, 0), file_count = GREATEST(file_count + ?, 0)
+		WHERE uid = ?`
+	if _, err := q.Exec(query, bytes, files, uid); err != nil {
+		return fmt.Errorf("failed to update storage usage: %w", err)
+	}
+	return nil
+}
@@ -43,6 +43,23 @@ func (dao *UserDAO) GetUserByUsername(username string) (*models.User, error) {
 	return user, nil
 }
 
+// GetUserByUID 根据用户ID获取用户
+func (dao *UserDAO) GetUserByUID(uid int) (*models.User, error) {
+	query := "SELECT uid, username, password, created_at, updated_at FROM users WHERE uid = ?"
+	row := database.DB.QueryRow(query, uid)
+
+	user := &models.User{}
+	err := row.Scan(&user.Uid, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // 用户不存在
+		}
+		return nil, fmt.Errorf("failed to get user by uid: %w", err)
+	}
+
+	return user, nil
+}
+
 // CheckUserExists 检查用户是否存在
 func (dao *UserDAO) CheckUserExists(username string) (bool, error) {
 	query := "SELECT COUNT(*) FROM users WHERE username = ?"
@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+type VideoTranscodeDAO struct{}
+
+func NewVideoTranscodeDAO() *VideoTranscodeDAO { return &VideoTranscodeDAO{} }
+
+// Upsert 创建或重置为 pending 状态
+func (d *VideoTranscodeDAO) Upsert(fileID int64) error {
+	query := `INSERT INTO video_transcodes (file_id, status) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE status = VALUES(status), playlist_key = '', error = ''`
+	if _, err := database.DB.Exec(query, fileID, models.TranscodeStatusPending); err != nil {
+		return fmt.Errorf("failed to upsert video transcode: %w", err)
+	}
+	return nil
+}
+
+// Get 获取转码记录，不存在时返回 nil
+func (d *VideoTranscodeDAO) Get(fileID int64) (*models.VideoTranscode, error) {
+	t := &models.VideoTranscode{}
+	err := database.DB.QueryRow(
+		"SELECT file_id, status, playlist_key, error, created_at, updated_at FROM video_transcodes WHERE file_id = ?", fileID,
+	).Scan(&t.FileID, &t.Status, &t.PlaylistKey, &t.Error, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get video transcode: %w", err)
+	}
+	return t, nil
+}
+
+// UpdateStatus 更新转码状态
+func (d *VideoTranscodeDAO) UpdateStatus(fileID int64, status, playlistKey, errMsg string) error {
+	if len(errMsg) > 1024 {
+		errMsg = errMsg[:1024]
+	}
+	query := "UPDATE video_transcodes SET status = ?, playlist_key = ?, error = ? WHERE file_id = ?"
+	if _, err := database.DB.Exec(query, status, playlistKey, errMsg, fileID); err != nil {
+		return fmt.Errorf("failed to update video transcode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package filetype 根据文件头部的魔数识别真实的文件类型，不信任扩展名与客户端提供的 Content-Type
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"strings"
+)
+
+// SniffLen 识别类型所需读取的最大字节数
+const SniffLen = 512
+
+// Unknown 无法识别的类型
+const Unknown = "application/octet-stream"
+
+// extensionTypes 扩展名对应的合法 MIME 类型
+var extensionTypes = map[string][]string{
+	".mp4":  {"video/mp4"},
+	".m4v":  {"video/mp4"},
+	".mov":  {"video/quicktime", "video/mp4"},
+	".mkv":  {"video/x-matroska"},
+	".webm": {"video/webm"},
+	".avi":  {"video/x-msvideo"},
+	".jpg":  {"image/jpeg"},
+	".jpeg": {"image/jpeg"},
+	".png":  {"image/png"},
+	".gif":  {"image/gif"},
+	".webp": {"image/webp"},
+	".mp3":  {"audio/mpeg"},
+	".m4a":  {"audio/mp4"},
+	".wav":  {"audio/wav"},
+	".zip":  {"application/zip"},
+	".rar":  {"application/vnd.rar"},
+	".7z":   {"application/x-7z-compressed"},
+	".pdf":  {"application/pdf"},
+}
+
+// 标准库识别结果到本包规范名称的映射
+var canonicalTypes = map[string]string{
+	"video/avi":                    "video/x-msvideo",
+	"audio/wave":                   "audio/wav",
+	"application/x-rar-compressed": "application/vnd.rar",
+}
+
+// Detect 返回 head（文件开头最多 SniffLen 字节）对应的 MIME 类型，无法识别时返回 Unknown
+func Detect(head []byte) string {
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+	if mt := detectContainer(head); mt != "" {
+		return mt
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("7z\xBC\xAF\x27\x1C")):
+		return "application/x-7z-compressed"
+	case isMP3Frame(head):
+		return "audio/mpeg"
+	}
+
+	mt := http.DetectContentType(head)
+	if i := strings.IndexByte(mt, ';'); i >= 0 {
+		mt = mt[:i]
+	}
+	if c, ok := canonicalTypes[mt]; ok {
+		mt = c
+	}
+	// 标准库对无法识别的文本内容返回 text/plain，统一视为未知
+	if strings.HasPrefix(mt, "text/") {
+		return Unknown
+	}
+	return mt
+}
+
+// MatchesExtension 判断识别出的类型与扩展名是否一致，未登记的扩展名视为不一致
+func MatchesExtension(ext, mimeType string) bool {
+	for _, t := range extensionTypes[strings.ToLower(ext)] {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContainer 识别标准库无法区分的 ISO BMFF（ftyp）与 EBML 容器
+func detectContainer(head []byte) string {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		boxSize := int(binary.BigEndian.Uint32(head[:4]))
+		if boxSize < 12 || boxSize > len(head) {
+			boxSize = len(head)
+		}
+		brands := [][]byte{head[8:12]}
+		for i := 16; i+4 <= boxSize; i += 4 {
+			brands = append(brands, head[i:i+4])
+		}
+		for _, b := range brands {
+			switch string(b) {
+			case "qt  ":
+				return "video/quicktime"
+			case "M4A ", "M4B ":
+				return "audio/mp4"
+			}
+		}
+		for _, b := range brands {
+			switch string(b) {
+			case "isom", "iso2", "iso4", "iso5", "iso6", "mp41", "mp42", "avc1", "M4V ", "dash", "MSNV", "3gp4", "3gp5":
+				return "video/mp4"
+			}
+		}
+		return ""
+	}
+	if bytes.HasPrefix(head, []byte("\x1A\x45\xDF\xA3")) {
+		// EBML 头中的 DocType 区分 Matroska 与 WebM
+		if bytes.Contains(head, []byte("webm")) {
+			return "video/webm"
+		}
+		return "video/x-matroska"
+	}
+	return ""
+}
+
+// isMP3Frame 识别不带 ID3 标签、直接以 MPEG 音频帧开头的 MP3
+func isMP3Frame(head []byte) bool {
+	if len(head) < 3 || head[0] != 0xFF || head[1]&0xE0 != 0xE0 {
+		return false
+	}
+	version := (head[1] >> 3) & 0x03
+	layer := (head[1] >> 1) & 0x03
+	bitrate := head[2] >> 4
+	sampleRate := (head[2] >> 2) & 0x03
+	return version != 1 && layer == 1 && bitrate != 0 && bitrate != 0x0F && sampleRate != 0x03
+}
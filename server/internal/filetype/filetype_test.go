@@ -0,0 +1,44 @@
+package filetype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"mp4", []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2"), "video/mp4"},
+		{"quicktime", []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00qt  "), "video/quicktime"},
+		{"m4a", []byte("\x00\x00\x00\x18ftypM4A \x00\x00\x02\x00M4A isom"), "audio/mp4"},
+		{"matroska", []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x88matroska"), "video/x-matroska"},
+		{"webm", []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm"), "video/webm"},
+		{"avi", []byte("RIFF\x00\x00\x00\x00AVI LIST"), "video/x-msvideo"},
+		{"wav", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), "audio/wav"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0DIHDR"), "image/png"},
+		{"jpeg", []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), "image/jpeg"},
+		{"mp3 id3", []byte("ID3\x03\x00\x00\x00"), "audio/mpeg"},
+		{"mp3 frame", []byte("\xFF\xFB\x90\x64\x00"), "audio/mpeg"},
+		{"zip", []byte("PK\x03\x04\x14\x00"), "application/zip"},
+		{"7z", []byte("7z\xBC\xAF\x27\x1C\x00\x04"), "application/x-7z-compressed"},
+		{"elf", []byte("\x7FELF\x02\x01\x01\x00"), Unknown},
+		{"windows executable", []byte("MZ\x90\x00\x03\x00\x00\x00"), Unknown},
+		{"text", []byte("#!/bin/sh\nrm -rf /\n"), Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect(tt.head))
+		})
+	}
+}
+
+func TestMatchesExtension(t *testing.T) {
+	assert.True(t, MatchesExtension(".MP4", "video/mp4"))
+	assert.True(t, MatchesExtension(".mov", "video/mp4"))
+	assert.False(t, MatchesExtension(".mp4", "image/png"))
+	assert.False(t, MatchesExtension(".exe", Unknown))
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/archive"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ArchiveManifest 返回压缩包的条目清单；检查尚未完成时返回 202 及当前状态。
+// 被拒绝或格式不支持的压缩包返回 200，由 status 与 reject_code 说明原因；隔离中的文件返回 423。
+func (h *FileHandler) ArchiveManifest(c *gin.Context) {
+	file, ok := h.loadReadable(c)
+	if !ok || !requireClean(c, file) {
+		return
+	}
+	m, err := h.archives.Manifest(file.ID)
+	if err != nil {
+		logger.Logger.Error("failed to get archive manifest", zap.Int64("file_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if m == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Archive manifest not available"})
+		return
+	}
+	if m.Status == models.ArchiveStatusPending || m.Status == models.ArchiveStatusFailed {
+		c.JSON(http.StatusAccepted, gin.H{"status": m.Status, "error": m.Error})
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// ArchiveEntry 以附件形式返回压缩包中的单个文件，查询参数 path 为条目完整路径
+func (h *FileHandler) ArchiveEntry(c *gin.Context) {
+	file, ok := h.loadReadable(c)
+	if !ok || !requireClean(c, file) {
+		return
+	}
+	name := c.Query("path")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path is required"})
+		return
+	}
+
+	rc, entry, err := h.archives.OpenEntry(c.Request.Context(), file, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrArchiveNotReady):
+			c.JSON(http.StatusConflict, gin.H{"error": "Archive is not available for extraction"})
+		case errors.Is(err, archive.ErrEntryNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Archive entry not found"})
+		case errors.Is(err, archive.ErrEntryEncrypted):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Archive entry is encrypted"})
+		default:
+			logger.Logger.Error("failed to open archive entry", zap.Int64("file_id", file.ID), zap.Error(err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read archive"})
+		}
+		return
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(entry.Name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// 条目内容不受信任，始终作为附件下载，避免在站点域名下被浏览器渲染
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(entry.Name)}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Length", strconv.FormatInt(entry.Size, 10))
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, rc); err != nil {
+		// 响应头已发送，只能记录日志
+		logger.Logger.Warn("failed to stream archive entry",
+			zap.Int64("file_id", file.ID),
+			zap.String("entry", entry.Name),
+			zap.Error(err))
+	}
+}
@@ -1,80 +1,72 @@
 package handlers
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
+	"errors"
 	"net/http"
-	"os"
 	"strings"
-	"time"
 
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// historyLimit 每次请求随消息发送给模型的历史消息条数
+const historyLimit = 20
+
+// chatTemperature 对话生成的采样温度
+const chatTemperature = 0.7
+
+// ChatHandler 聊天处理器，按模型白名单路由到大模型提供方并持久化会话；
+// 模型请求的工具调用由 runner 执行后回传，直到得到最终回复
 type ChatHandler struct {
-	mcpServiceURL string
-	httpClient    *http.Client
+	conversationDAO *dao.ConversationDAO
+	attachments     *services.AttachmentService
+	router          *llm.Router
+	runner          *tools.Runner
 }
 
-func NewChatHandler() *ChatHandler {
-	mcpURL := os.Getenv("MCP_SERVICE_URL")
-	if mcpURL == "" {
-		mcpURL = "http://localhost:8000"
-		logger.Logger.Warn("MCP_SERVICE_URL not set, using default", zap.String("url", mcpURL))
-	}
-
+// NewChatHandler 创建新的聊天处理器，runner 应以 router 作为提供方
+func NewChatHandler(router *llm.Router, runner *tools.Runner, attachmentSvc *services.AttachmentService) *ChatHandler {
 	return &ChatHandler{
-		mcpServiceURL: mcpURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		conversationDAO: dao.NewConversationDAO(),
+		attachments:     attachmentSvc,
+		router:          router,
+		runner:          runner,
 	}
 }
 
 type ChatRequest struct {
-	MemoryId string `json:"memoryId" binding:"required"`
+	// MemoryId 客户端生成的会话标识，对应 conversations.memory_id varchar(64)
+	MemoryId string `json:"memoryId" binding:"required,max=64"`
 	Message  string `json:"message" binding:"required"`
+	// Attachments 引用当前用户已上传文件的ID，图片交给模型查看，其他文件以文本描述提供
+	Attachments []int64 `json:"attachments" binding:"max=8,dive,gt=0"`
+	// Model 从白名单中选择模型，为空时使用默认模型
+	Model string `json:"model"`
 }
 
-type MCPChatRequest struct {
-	Message     string  `json:"message"`
-	SessionID   string  `json:"session_id,omitempty"`
-	Temperature float64 `json:"temperature"`
-}
-
-type MCPChatResponse struct {
-	Success bool                   `json:"success"`
-	Data    map[string]interface{} `json:"data,omitempty"`
-	Error   string                 `json:"error,omitempty"`
+// Models 返回可选模型白名单与默认模型
+func (h *ChatHandler) Models(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"models":        h.router.Models(),
+		"default_model": h.router.DefaultModel(),
+	})
 }
 
 func (h *ChatHandler) Chat(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authorization header is required",
-		})
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		h.ChatStream(c)
 		return
 	}
 
-	tokenParts := strings.Split(authHeader, " ")
-	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid authorization header format",
-		})
-		return
-	}
-
-	if !strings.HasPrefix(tokenParts[1], "token_") {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid token",
-		})
-		return
-	}
+	user := middleware.MustCurrentUser(c)
 
 	var req ChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -86,95 +78,279 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 	}
 
 	logger.Logger.Info("received chat request",
+		zap.Int("uid", user.Uid),
 		zap.String("message", req.Message),
 		zap.String("memoryId", req.MemoryId),
 	)
 
-	mcpReq := MCPChatRequest{
-		Message:     req.Message,
-		SessionID:   req.MemoryId,
-		Temperature: 0.7,
+	if !h.router.Allowed(req.Model) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Model not allowed",
+			"models": h.router.Models(),
+		})
+		return
 	}
 
-	jsonData, err := json.Marshal(mcpReq)
-	if err != nil {
-		logger.Logger.Error("failed to marshal MCP request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
-		})
+	files, ok := h.resolveAttachments(c, user.Uid, req.Attachments)
+	if !ok {
 		return
 	}
 
-	mcpURL := fmt.Sprintf("%s/api/chat", h.mcpServiceURL)
-	httpReq, err := http.NewRequestWithContext(c.Request.Context(), "POST", mcpURL, bytes.NewBuffer(jsonData))
+	llmReq, err := h.prepareRequest(c.Request.Context(), user.Uid, req, files)
 	if err != nil {
-		logger.Logger.Error("failed to create MCP request", zap.Error(err))
+		logger.Logger.Error("failed to prepare conversation", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Internal server error",
 		})
 		return
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	logger.Logger.Info("forwarding request to MCP service", zap.String("url", mcpURL))
-
-	resp, err := h.httpClient.Do(httpReq)
+	resp, trace, err := h.runner.Run(c.Request.Context(), llmReq)
 	if err != nil {
-		logger.Logger.Error("failed to call MCP service",
+		logger.Logger.Error("failed to generate chat response",
+			zap.String("sessionId", req.MemoryId),
+			zap.Int("toolCalls", len(trace)),
 			zap.Error(err),
-			zap.String("url", mcpURL),
 		)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Chat service temporarily unavailable",
+		status, msg := chatErrorResponse(err)
+		c.JSON(status, gin.H{
+			"error": msg,
 		})
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logger.Logger.Error("failed to read MCP response", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
+	conversationID := h.saveExchange(user.Uid, req, files, resp.Content)
+
+	logger.Logger.Info("successfully processed chat request",
+		zap.String("sessionId", req.MemoryId),
+		zap.String("provider", resp.Provider),
+		zap.String("model", resp.Model),
+		zap.String("providerModel", resp.ProviderModel),
+		zap.Int("toolCalls", len(trace)),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"response":        resp.Content,
+			"session_id":      req.MemoryId,
+			"conversation_id": conversationID,
+			"model":           resp.Model,
+			"provider":        resp.Provider,
+			"provider_model":  resp.ProviderModel,
+			"usage":           resp.Usage,
+			"tool_trace":      toolTrace(trace),
+		},
+	})
+}
+
+// ChatStream 以 Server-Sent Events 形式转发模型的增量回复。
+// 事件类型：delta（增量文本）、tool（一次工具调用的记录）、done（会话ID、用量与工具调用记录）、error（上游错误）。
+// 收到首段回复前出错时以普通 JSON 错误响应返回。
+func (h *ChatHandler) ChatStream(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+
+	var req ChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Error("failed to parse chat stream request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
 		})
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		logger.Logger.Error("MCP service returned error",
-			zap.Int("status", resp.StatusCode),
-			zap.String("body", string(body)),
-		)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate response",
+	logger.Logger.Info("received chat stream request",
+		zap.Int("uid", user.Uid),
+		zap.String("memoryId", req.MemoryId),
+	)
+
+	if !h.router.Allowed(req.Model) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Model not allowed",
+			"models": h.router.Models(),
 		})
 		return
 	}
 
-	var mcpResp MCPChatResponse
-	if err := json.Unmarshal(body, &mcpResp); err != nil {
-		logger.Logger.Error("failed to parse MCP response", zap.Error(err))
+	files, ok := h.resolveAttachments(c, user.Uid, req.Attachments)
+	if !ok {
+		return
+	}
+
+	llmReq, err := h.prepareRequest(c.Request.Context(), user.Uid, req, files)
+	if err != nil {
+		logger.Logger.Error("failed to prepare conversation", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Internal server error",
 		})
 		return
 	}
 
-	if !mcpResp.Success {
-		logger.Logger.Error("MCP service reported failure", zap.String("error", mcpResp.Error))
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": mcpResp.Error,
-		})
+	started := false
+	startStream := func() {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+		started = true
+	}
+
+	// 客户端断开时请求上下文被取消，上游调用随之中断
+	resp, trace, err := h.runner.RunStream(c.Request.Context(), llmReq, func(delta string) error {
+		if !started {
+			startStream()
+		}
+		c.SSEvent("delta", gin.H{"content": delta})
+		c.Writer.Flush()
+		return nil
+	}, func(entry tools.TraceEntry) error {
+		if !started {
+			startStream()
+		}
+		c.SSEvent("tool", entry)
+		c.Writer.Flush()
+		return nil
+	})
+	if c.Request.Context().Err() != nil {
+		logger.Logger.Info("chat stream cancelled by client", zap.String("sessionId", req.MemoryId))
+		return
+	}
+	if err != nil {
+		logger.Logger.Error("chat stream failed",
+			zap.String("sessionId", req.MemoryId),
+			zap.Error(err),
+		)
+		status, msg := chatErrorResponse(err)
+		if !started {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		c.SSEvent("error", gin.H{"error": msg})
+		c.Writer.Flush()
 		return
 	}
 
-	logger.Logger.Info("successfully processed chat request",
+	if !started {
+		startStream()
+	}
+	conversationID := h.saveExchange(user.Uid, req, files, resp.Content)
+	c.SSEvent("done", gin.H{
+		"session_id":      req.MemoryId,
+		"conversation_id": conversationID,
+		"model":           resp.Model,
+		"provider":        resp.Provider,
+		"provider_model":  resp.ProviderModel,
+		"usage":           resp.Usage,
+		"tool_trace":      toolTrace(trace),
+	})
+	c.Writer.Flush()
+
+	logger.Logger.Info("successfully streamed chat response",
 		zap.String("sessionId", req.MemoryId),
 	)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    mcpResp.Data,
-	})
+// toolTrace 没有工具调用时返回空数组而不是 null
+func toolTrace(trace []tools.TraceEntry) []tools.TraceEntry {
+	if trace == nil {
+		return []tools.TraceEntry{}
+	}
+	return trace
+}
+
+// chatErrorResponse 将模型调用错误映射为响应状态码与错误信息
+func chatErrorResponse(err error) (int, string) {
+	var apiErr *llm.APIError
+	switch {
+	case errors.Is(err, llm.ErrNotConfigured):
+		return http.StatusServiceUnavailable, "Chat service is not configured"
+	case errors.As(err, &apiErr) && apiErr.RateLimited():
+		return http.StatusTooManyRequests, "Chat service is busy, please retry later"
+	case llm.IsTimeout(err):
+		return http.StatusGatewayTimeout, "Chat service timed out"
+	case errors.As(err, &apiErr) && !apiErr.ServerError():
+		return http.StatusBadGateway, "Failed to generate response"
+	default:
+		return http.StatusServiceUnavailable, "Chat service temporarily unavailable"
+	}
+}
+
+// resolveAttachments 校验请求引用的附件均属于当前用户且已通过扫描，失败时已写入响应
+func (h *ChatHandler) resolveAttachments(c *gin.Context, uid int, ids []int64) ([]*models.MinioFile, bool) {
+	if len(ids) == 0 {
+		return nil, true
+	}
+	files, err := h.attachments.Resolve(uid, ids)
+	var attErr *services.AttachmentError
+	switch {
+	case errors.As(err, &attErr) && errors.Is(err, services.ErrFileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found", "file_id": attErr.FileID})
+		return nil, false
+	case errors.As(err, &attErr) && errors.Is(err, services.ErrFileQuarantined):
+		c.JSON(http.StatusLocked, gin.H{
+			"error":   "Attachment is quarantined",
+			"code":    CodeFileQuarantined,
+			"file_id": attErr.FileID,
+		})
+		return nil, false
+	case err != nil:
+		logger.Logger.Error("failed to resolve attachments", zap.Int("uid", uid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	return files, true
+}
+
+// prepareRequest 读取当前用户会话的历史上下文并生成模型请求；会话与本轮消息在模型成功回复后才写入
+func (h *ChatHandler) prepareRequest(ctx context.Context, uid int, req ChatRequest, files []*models.MinioFile) (*llm.Request, error) {
+	current := llm.Message{Role: llm.RoleUser, Content: req.Message}
+	if len(files) > 0 {
+		parts, err := h.attachments.ContentParts(ctx, req.Message, files)
+		if err != nil {
+			return nil, err
+		}
+		current.Parts = parts
+	}
+
+	var stored []models.Message
+	conv, err := h.conversationDAO.GetByMemoryID(uid, req.MemoryId)
+	if err != nil {
+		return nil, err
+	}
+	if conv != nil {
+		if stored, err = h.conversationDAO.ListRecentMessages(conv.ID, historyLimit); err != nil {
+			return nil, err
+		}
+	}
+	messages := make([]llm.Message, 0, len(stored)+1)
+	for _, m := range stored {
+		messages = append(messages, llm.Message{Role: m.Role, Content: m.Content})
+	}
+	messages = append(messages, current)
+	return &llm.Request{Model: req.Model, Messages: messages, Temperature: chatTemperature}, nil
+}
+
+// saveExchange 获取或创建会话并写入本轮用户消息、附件引用与回复，返回会话ID；
+// 回复已返回给用户，保存失败只记录日志，此时返回 nil
+func (h *ChatHandler) saveExchange(uid int, req ChatRequest, files []*models.MinioFile, reply string) *int64 {
+	conv, err := h.conversationDAO.GetOrCreate(uid, req.MemoryId, conversationTitle(req.Message))
+	if err == nil {
+		err = h.conversationDAO.AddExchange(conv.ID, req.Message, services.AttachmentReferences(files), reply)
+	}
+	if err != nil {
+		logger.Logger.Error("failed to save chat exchange", zap.Int("uid", uid), zap.String("sessionId", req.MemoryId), zap.Error(err))
+		return nil
+	}
+	return &conv.ID
+}
+
+// conversationTitle 取首条消息的前 50 个字符作为会话标题
+func conversationTitle(message string) string {
+	title := strings.TrimSpace(message)
+	if r := []rune(title); len(r) > 50 {
+		title = string(r[:50])
+	}
+	return title
 }
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatErrorResponse(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{llm.ErrNotConfigured, http.StatusServiceUnavailable},
+		{&llm.APIError{StatusCode: http.StatusTooManyRequests}, http.StatusTooManyRequests},
+		{fmt.Errorf("llm: request failed: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{&llm.APIError{StatusCode: http.StatusBadRequest}, http.StatusBadGateway},
+		{&llm.APIError{StatusCode: http.StatusInternalServerError}, http.StatusServiceUnavailable},
+		{errors.New("connection refused"), http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		status, msg := chatErrorResponse(tc.err)
+		assert.Equal(t, tc.status, status, tc.err.Error())
+		assert.NotEmpty(t, msg)
+	}
+}
+
+var conversationColumns = []string{"id", "uid", "memory_id", "title", "created_at", "updated_at"}
+
+// newChatTestEngine 以 llm.Fake 作为唯一模型的提供方挂载聊天接口
+func newChatTestEngine(fake *llm.Fake) *gin.Engine {
+	router := llm.NewRouter("fake-model")
+	router.Add("fake-model", llm.Route{ProviderName: "fake", Provider: fake, Model: "fake-model"})
+	h := NewChatHandler(router, tools.NewRunner(router, nil, 5), nil)
+
+	r := newTestEngine(7)
+	r.POST("/chat", h.Chat)
+	r.POST("/chat/stream", h.ChatStream)
+	return r
+}
+
+// expectHistory 预期读取会话 s1 的历史，会话不存在时不读取消息
+func expectHistory(mock sqlmock.Sqlmock, exists bool, history ...string) {
+	rows := sqlmock.NewRows(conversationColumns)
+	if exists {
+		rows.AddRow(1, 7, "s1", "你好", time.Now(), time.Now())
+	}
+	mock.ExpectQuery("SELECT (.+) FROM conversations WHERE uid = \\? AND memory_id = \\?").WithArgs(7, "s1").WillReturnRows(rows)
+	if !exists {
+		return
+	}
+	messages := sqlmock.NewRows([]string{"id", "conversation_id", "role", "content", "created_at"})
+	for i, content := range history {
+		role := models.RoleUser
+		if i%2 == 1 {
+			role = models.RoleAssistant
+		}
+		messages.AddRow(i+1, 1, role, content, time.Now())
+	}
+	mock.ExpectQuery("SELECT (.+) FROM messages").WithArgs(int64(1), historyLimit).WillReturnRows(messages)
+}
+
+// expectExchange 预期创建或读取会话 s1，并在一个事务中写入本轮用户消息与回复
+func expectExchange(mock sqlmock.Sqlmock, user, reply string) {
+	mock.ExpectExec("INSERT IGNORE INTO conversations").WithArgs(7, "s1", user).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT (.+) FROM conversations WHERE uid = \\? AND memory_id = \\?").WithArgs(7, "s1").
+		WillReturnRows(sqlmock.NewRows(conversationColumns).AddRow(1, 7, "s1", user, time.Now(), time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO messages").WithArgs(int64(1), models.RoleUser, user).WillReturnResult(sqlmock.NewResult(10, 1))
+	mock.ExpectExec("INSERT INTO messages").WithArgs(int64(1), models.RoleAssistant, reply).WillReturnResult(sqlmock.NewResult(11, 1))
+	mock.ExpectExec("UPDATE conversations SET updated_at").WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func postChat(r *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestChatStream(t *testing.T) {
+	mock := newMockDB(t)
+	fake := llm.NewFake(llm.FakeReply{Response: &llm.Response{Content: "你好！", Model: "fake-model", Provider: "fake"}})
+	r := newChatTestEngine(fake)
+
+	expectHistory(mock, false)
+	expectExchange(mock, "你好", "你好！")
+
+	w := postChat(r, "/chat/stream", `{"memoryId":"s1","message":"你好"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "event:delta\ndata:{\"content\":\"你好！\"}\n\n")
+	assert.Contains(t, body, "event:done\ndata:")
+	assert.Contains(t, body, `"conversation_id":1`)
+	assert.Contains(t, body, `"tool_trace":[]`)
+	assert.Less(t, strings.Index(body, "event:delta"), strings.Index(body, "event:done"))
+}
+
+func TestChatStreamErrors(t *testing.T) {
+	// 首段回复之前出错，以普通 JSON 错误返回
+	mock := newMockDB(t)
+	r := newChatTestEngine(llm.NewFake(llm.FakeReply{Err: &llm.APIError{StatusCode: http.StatusTooManyRequests}}))
+	expectHistory(mock, false)
+
+	w := postChat(r, "/chat/stream", `{"memoryId":"s1","message":"你好"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	// 已开始推送后出错，以 error 事件结束，本轮消息不保存
+	mock = newMockDB(t)
+	r = newChatTestEngine(llm.NewFake(llm.FakeReply{
+		Response: &llm.Response{Content: "你"},
+		Err:      &llm.APIError{StatusCode: http.StatusBadGateway},
+	}))
+	expectHistory(mock, false)
+
+	w = postChat(r, "/chat/stream", `{"memoryId":"s1","message":"你好"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "event:delta")
+	assert.Contains(t, body, "event:error\ndata:{\"error\":\"Chat service temporarily unavailable\"}")
+	assert.NotContains(t, body, "event:done")
+
+	// 请求体不合法
+	w = postChat(r, "/chat/stream", `{"message":"你好"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestChatSavesExchangeOnlyOnSuccess(t *testing.T) {
+	// 模型调用失败时不写入会话与消息，重试不会在历史中重复同一条用户消息
+	mock := newMockDB(t)
+	fake := llm.NewFake(
+		llm.FakeReply{Err: &llm.APIError{StatusCode: http.StatusServiceUnavailable}},
+		llm.FakeReply{Response: &llm.Response{Content: "第二次"}},
+	)
+	r := newChatTestEngine(fake)
+	expectHistory(mock, true, "早", "早上好")
+
+	w := postChat(r, "/chat", `{"memoryId":"s1","message":"你好"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	expectHistory(mock, true, "早", "早上好")
+	expectExchange(mock, "你好", "第二次")
+	w = postChat(r, "/chat", `{"memoryId":"s1","message":"你好"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"conversation_id":1`)
+
+	requests := fake.Requests()
+	require.Len(t, requests, 2)
+	assert.Len(t, requests[1].Messages, 3)
+	assert.Equal(t, "你好", requests[1].Messages[2].Content)
+}
+
+func TestChatRejectsLongMemoryID(t *testing.T) {
+	newMockDB(t)
+	r := newChatTestEngine(llm.NewFake())
+
+	w := postChat(r, "/chat", `{"memoryId":"`+strings.Repeat("a", 65)+`","message":"你好"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
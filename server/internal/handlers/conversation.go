@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ConversationHandler 会话管理处理器，所有操作均按当前用户 uid 过滤
+type ConversationHandler struct {
+	conversationDAO *dao.ConversationDAO
+}
+
+// RenameConversationRequest 重命名会话请求
+type RenameConversationRequest struct {
+	Title string `json:"title" binding:"required,max=255"`
+}
+
+// NewConversationHandler 创建新的会话管理处理器
+func NewConversationHandler() *ConversationHandler {
+	return &ConversationHandler{
+		conversationDAO: dao.NewConversationDAO(),
+	}
+}
+
+// List 分页列出当前用户的会话
+func (h *ConversationHandler) List(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+	p := parsePagination(c)
+
+	conversations, total, err := h.conversationDAO.ListByUID(user.Uid, p.Offset(), p.PageSize)
+	if err != nil {
+		logger.Logger.Error("failed to list conversations", zap.Int("uid", user.Uid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversations": conversations,
+		"total":         total,
+		"page":          p.Page,
+		"page_size":     p.PageSize,
+	})
+}
+
+// Get 返回会话及其全部消息
+func (h *ConversationHandler) Get(c *gin.Context) {
+	conv, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	messages, err := h.conversationDAO.ListMessages(conv.ID)
+	if err != nil {
+		logger.Logger.Error("failed to list messages", zap.Int64("conversation_id", conv.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversation": conv,
+		"messages":     messages,
+	})
+}
+
+// Rename 修改会话标题
+func (h *ConversationHandler) Rename(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+	id, ok := conversationIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req RenameConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title must not be empty"})
+		return
+	}
+
+	found, err := h.conversationDAO.Rename(user.Uid, id, title)
+	if err != nil {
+		logger.Logger.Error("failed to rename conversation", zap.Int64("conversation_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation renamed", "id": id, "title": title})
+}
+
+// Delete 删除会话及其消息
+func (h *ConversationHandler) Delete(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+	id, ok := conversationIDParam(c)
+	if !ok {
+		return
+	}
+
+	found, err := h.conversationDAO.Delete(user.Uid, id)
+	if err != nil {
+		logger.Logger.Error("failed to delete conversation", zap.Int64("conversation_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+
+	logger.Logger.Info("conversation deleted", zap.Int("uid", user.Uid), zap.Int64("conversation_id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation deleted", "id": id})
+}
+
+// Export 以 Markdown（默认）或 JSON 格式导出会话
+func (h *ConversationHandler) Export(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "markdown"))
+	if format != "markdown" && format != "md" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format"})
+		return
+	}
+
+	conv, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	messages, err := h.conversationDAO.ListMessages(conv.ID)
+	if err != nil {
+		logger.Logger.Error("failed to list messages", zap.Int64("conversation_id", conv.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation-%d.json"`, conv.ID))
+		c.JSON(http.StatusOK, gin.H{
+			"conversation": conv,
+			"messages":     messages,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation-%d.md"`, conv.ID))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(renderConversationMarkdown(conv, messages)))
+}
+
+// loadOwned 解析路径中的会话ID并确认属于当前用户，失败时已写入响应
+func (h *ConversationHandler) loadOwned(c *gin.Context) (*models.Conversation, bool) {
+	user := middleware.MustCurrentUser(c)
+	id, ok := conversationIDParam(c)
+	if !ok {
+		return nil, false
+	}
+
+	conv, err := h.conversationDAO.GetByID(user.Uid, id)
+	if err != nil {
+		logger.Logger.Error("failed to get conversation", zap.Int64("conversation_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	if conv == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return nil, false
+	}
+	return conv, true
+}
+
+func conversationIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func renderConversationMarkdown(conv *models.Conversation, messages []models.Message) string {
+	var b strings.Builder
+	title := conv.Title
+	if title == "" {
+		title = fmt.Sprintf("Conversation %d", conv.ID)
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "- Created: %s\n- Updated: %s\n\n", conv.CreatedAt.Format("2006-01-02 15:04:05"), conv.UpdatedAt.Format("2006-01-02 15:04:05"))
+
+	for _, m := range messages {
+		speaker := "User"
+		if m.Role == models.RoleAssistant {
+			speaker = "Assistant"
+		}
+		fmt.Fprintf(&b, "## %s (%s)\n\n%s\n\n", speaker, m.CreatedAt.Format("2006-01-02 15:04:05"), m.Content)
+		if len(m.Attachments) > 0 {
+			names := make([]string, 0, len(m.Attachments))
+			for _, att := range m.Attachments {
+				names = append(names, att.FileName)
+			}
+			fmt.Fprintf(&b, "Attachments: %s\n\n", strings.Join(names, ", "))
+		}
+	}
+	return b.String()
+}
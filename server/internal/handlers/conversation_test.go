@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderConversationMarkdown(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	conv := &models.Conversation{ID: 7, Title: "周报", CreatedAt: ts, UpdatedAt: ts}
+	messages := []models.Message{
+		{Role: models.RoleUser, Content: "你好", CreatedAt: ts},
+		{Role: models.RoleAssistant, Content: "你好！", CreatedAt: ts},
+		{Role: models.RoleUser, Content: "看看这张图", CreatedAt: ts, Attachments: []models.MessageAttachment{
+			{FileName: "a.png"}, {FileName: "b.zip"},
+		}},
+	}
+
+	md := renderConversationMarkdown(conv, messages)
+	assert.Contains(t, md, "# 周报\n")
+	assert.Contains(t, md, "## User (2025-01-02 03:04:05)\n\n你好\n")
+	assert.Contains(t, md, "## Assistant (2025-01-02 03:04:05)\n\n你好！\n")
+	assert.Contains(t, md, "看看这张图\n\nAttachments: a.png, b.zip\n")
+
+	conv.Title = ""
+	assert.Contains(t, renderConversationMarkdown(conv, nil), "# Conversation 7\n")
+}
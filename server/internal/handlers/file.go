@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FileHandler 已上传文件处理器
+type FileHandler struct {
+	minio        *storage.MinioService
+	files        *services.FileService
+	thumbnails   *services.ThumbnailService
+	metadata     *services.MetadataService
+	archives     *services.ArchiveService
+	minioFileDAO *dao.MinioFileDAO
+}
+
+// RenameFileRequest 修改文件显示名称请求
+type RenameFileRequest struct {
+	FileName string `json:"file_name" binding:"required,max=255"`
+}
+
+// NewFileHandler 创建新的文件处理器
+func NewFileHandler(minioSvc *storage.MinioService, fileSvc *services.FileService, thumbnailSvc *services.ThumbnailService, metadataSvc *services.MetadataService, archiveSvc *services.ArchiveService) *FileHandler {
+	return &FileHandler{
+		minio:        minioSvc,
+		files:        fileSvc,
+		thumbnails:   thumbnailSvc,
+		metadata:     metadataSvc,
+		archives:     archiveSvc,
+		minioFileDAO: dao.NewMinioFileDAO(),
+	}
+}
+
+// List 分页列出当前用户上传的文件，支持按文件名搜索与排序
+//
+// 查询参数：q 文件名关键字；sort 取 created_at / file_name / file_size；order 取 asc / desc（默认 desc）
+func (h *FileHandler) List(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+	p := parsePagination(c)
+
+	sortBy := c.DefaultQuery("sort", "created_at")
+	if sortBy != "created_at" && sortBy != "file_name" && sortBy != "file_size" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort field"})
+		return
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "desc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort order"})
+		return
+	}
+
+	files, total, err := h.minioFileDAO.ListByUID(user.Uid, dao.ListFilesOptions{
+		Query:  strings.TrimSpace(c.Query("q")),
+		SortBy: sortBy,
+		Desc:   order == "desc",
+		Offset: p.Offset(),
+		Limit:  p.PageSize,
+	})
+	if err != nil {
+		logger.Logger.Error("failed to list files", zap.Int("uid", user.Uid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"files":     files,
+		"total":     total,
+		"page":      p.Page,
+		"page_size": p.PageSize,
+	})
+}
+
+// Check 按内容 SHA-256 查询当前用户是否已上传过相同文件，客户端可据此跳过上传
+func (h *FileHandler) Check(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+	sha := strings.ToLower(c.Query("sha256"))
+	if !isSHA256Hex(sha) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sha256"})
+		return
+	}
+
+	file, err := h.files.FindBySHA256(user.Uid, sha)
+	if err != nil {
+		logger.Logger.Error("failed to check file by sha256", zap.Int("uid", user.Uid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if file == nil {
+		c.JSON(http.StatusOK, gin.H{"exists": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"exists": true, "file": file})
+}
+
+// Get 返回文件元信息
+func (h *FileHandler) Get(c *gin.Context) {
+	file, ok := h.loadReadable(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// Metadata 返回文件提取出的媒体元数据；提取尚未完成时返回 202 及当前状态，隔离中的文件返回 423
+func (h *FileHandler) Metadata(c *gin.Context) {
+	file, ok := h.loadReadable(c)
+	if !ok || !requireClean(c, file) {
+		return
+	}
+	m, err := h.metadata.Get(file.ID)
+	if err != nil {
+		logger.Logger.Error("failed to get file metadata", zap.Int64("file_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if m == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Metadata not available"})
+		return
+	}
+	if m.Status != models.MetadataStatusReady {
+		c.JSON(http.StatusAccepted, gin.H{"status": m.Status, "error": m.Error})
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// Rename 修改文件显示名称，对象键不变；扩展名不可修改，以免改变文件的类型判定
+func (h *FileHandler) Rename(c *gin.Context) {
+	file, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	var req RenameFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	fileName := path.Base(filepath.ToSlash(strings.TrimSpace(req.FileName)))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file name"})
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(fileName), filepath.Ext(file.FileName)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File extension cannot be changed"})
+		return
+	}
+
+	updated, err := h.minioFileDAO.UpdateFileName(file.Uid, file.ID, fileName)
+	if err != nil {
+		logger.Logger.Error("failed to rename file", zap.Int64("file_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	file.FileName = fileName
+	c.JSON(http.StatusOK, file)
+}
+
+// Delete 删除文件记录与对象存储中的对象
+func (h *FileHandler) Delete(c *gin.Context) {
+	file, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+
+	if err := h.files.Delete(c.Request.Context(), file.Uid, file.ID); err != nil {
+		if errors.Is(err, services.ErrFileNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		logger.Logger.Error("failed to delete file", zap.Int64("file_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
+		return
+	}
+
+	logger.Logger.Info("file deleted",
+		zap.Int64("file_id", file.ID),
+		zap.Int("uid", file.Uid),
+		zap.String("object_key", file.ObjectKey),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "File deleted", "id": file.ID})
+}
+
+// Download 返回文件的限时预签名下载URL
+func (h *FileHandler) Download(c *gin.Context) {
+	file, ok := h.loadReadable(c)
+	if !ok || !requireClean(c, file) {
+		return
+	}
+
+	url, expiresAt, err := h.minio.PresignedGetURL(c.Request.Context(), file.ObjectKey, file.FileName)
+	if err != nil {
+		logger.Logger.Error("failed to presign download url", zap.Int64("file_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create download url"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         file.ID,
+		"url":        url,
+		"expires_at": expiresAt,
+	})
+}
+
+// loadReadable 解析路径中的文件ID并确认当前用户可读，失败时已写入响应
+func (h *FileHandler) loadReadable(c *gin.Context) (*models.MinioFile, bool) {
+	return h.load(c, func(f *models.MinioFile, uid int) bool { return f.CanBeReadBy(uid) })
+}
+
+// loadOwned 解析路径中的文件ID并确认文件属于当前用户，失败时已写入响应
+func (h *FileHandler) loadOwned(c *gin.Context) (*models.MinioFile, bool) {
+	return h.load(c, func(f *models.MinioFile, uid int) bool { return f.Uid == uid })
+}
+
+func (h *FileHandler) load(c *gin.Context, allowed func(f *models.MinioFile, uid int) bool) (*models.MinioFile, bool) {
+	user := middleware.MustCurrentUser(c)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file id"})
+		return nil, false
+	}
+
+	file, err := h.minioFileDAO.GetByID(id)
+	if err != nil {
+		logger.Logger.Error("failed to get file record", zap.Int64("file_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	// 无权访问与不存在返回相同响应，避免泄露他人文件ID
+	if file == nil || !allowed(file, user.Uid) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return nil, false
+	}
+	return file, true
+}
+
+// CodeFileQuarantined 文件尚未通过恶意软件扫描或已判定感染
+const CodeFileQuarantined = "FILE_QUARANTINED"
+
+// requireClean 确认文件已通过扫描，隔离中的文件返回 423，失败时已写入响应
+func requireClean(c *gin.Context, file *models.MinioFile) bool {
+	if !file.Quarantined() {
+		return true
+	}
+	c.JSON(http.StatusLocked, gin.H{
+		"error":       "File is quarantined",
+		"code":        CodeFileQuarantined,
+		"scan_status": file.ScanStatus,
+	})
+	return false
+}
+
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fileTest struct {
+	engine *gin.Engine
+	mock   sqlmock.Sqlmock
+	store  *miniotest.Server
+}
+
+// newFileTest 创建以用户 7 身份访问的文件接口
+func newFileTest(t *testing.T) *fileTest {
+	mock := newMockDB(t)
+	store, minioSvc := miniotest.Start(t)
+	fileSvc := services.NewFileService(minioSvc, services.NewQuotaService(config.QuotaConfig{}), config.UploadConfig{DedupScope: config.DedupScopeUser})
+	thumbnailSvc := services.NewThumbnailService(minioSvc, nil, nil, config.ThumbnailConfig{})
+	h := NewFileHandler(minioSvc, fileSvc, thumbnailSvc, nil, nil)
+
+	r := newTestEngine(7)
+	r.GET("/files", h.List)
+	r.GET("/files/gallery", h.Gallery)
+	r.GET("/files/:id", h.Get)
+	r.PATCH("/files/:id", h.Rename)
+	r.DELETE("/files/:id", h.Delete)
+	r.GET("/files/:id/download", h.Download)
+	r.GET("/files/:id/metadata", h.Metadata)
+	r.GET("/files/:id/archive", h.ArchiveManifest)
+	r.GET("/files/:id/thumbnails", h.Thumbnails)
+	return &fileTest{engine: r, mock: mock, store: store}
+}
+
+func (f *fileTest) do(method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	f.engine.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// expectGet 预期按 ID 读取一次 row
+func (f *fileTest) expectGet(row models.MinioFile) {
+	f.mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?$").WithArgs(row.ID).WillReturnRows(fileRows(row))
+}
+
+func TestListFiles(t *testing.T) {
+	f := newFileTest(t)
+
+	f.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM minio_files WHERE uid = \\? AND file_name LIKE \\?").
+		WithArgs(7, "%a\\_b%").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	f.mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE uid = \\? AND file_name LIKE \\? ORDER BY file_size ASC, id ASC LIMIT \\? OFFSET \\?").
+		WithArgs(7, "%a\\_b%", 10, 20).
+		WillReturnRows(fileRows(models.MinioFile{ID: 3, Uid: 7, FileName: "a_b.png"}))
+	w, resp := f.do(http.MethodGet, "/files?q=a_b&sort=file_size&order=asc&page=3&page_size=10", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(21), resp["total"])
+	assert.Equal(t, float64(3), resp["page"])
+	assert.Len(t, resp["files"], 1)
+
+	w, _ = f.do(http.MethodGet, "/files?sort=object_key", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = f.do(http.MethodGet, "/files?order=up", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGallery(t *testing.T) {
+	f := newFileTest(t)
+
+	// 与 IsImage/IsVideo 相同：类型识别之前登记的记录按扩展名归入图库
+	args := []driver.Value{7, "image/%", "%.jpg", "%.jpeg", "%.png", "%.gif", "video/%", "%.mp4", "%.mov", "%.mkv", "%.avi"}
+	f.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM minio_files WHERE uid = \\? AND \\(content_type LIKE \\? OR LOWER\\(file_name\\) LIKE \\?").
+		WithArgs(args...).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	f.mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE uid = \\? AND (.+) ORDER BY created_at DESC").
+		WithArgs(append(args, 20, 0)...).
+		WillReturnRows(fileRows(models.MinioFile{ID: 3, Uid: 7, FileName: "clip.MOV", ContentType: "application/octet-stream"}))
+	f.mock.ExpectQuery("SELECT (.+) FROM file_thumbnails WHERE file_id IN \\(\\?\\)").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"file_id", "size", "width", "height", "object_key", "file_size", "created_at"}))
+	w, resp := f.do(http.MethodGet, "/files/gallery", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(1), resp["total"])
+	items := resp["files"].([]interface{})
+	require.Len(t, items, 1)
+	assert.Equal(t, "clip.MOV", items[0].(map[string]interface{})["file_name"])
+}
+
+func TestGetFileSharing(t *testing.T) {
+	f := newFileTest(t)
+	own := models.MinioFile{ID: 3, Uid: 7, FileName: "a.png", ObjectKey: "uid_7/sha256/abc", ContentType: "image/png"}
+	shared := models.MinioFile{ID: 4, Uid: 8, FileName: "b.png", ObjectKey: "uid_8/sha256/def", ContentType: "image/png", IsShared: true}
+	private := models.MinioFile{ID: 5, Uid: 8, FileName: "c.png", ObjectKey: "uid_8/sha256/fed", ContentType: "image/png"}
+
+	f.expectGet(own)
+	w, resp := f.do(http.MethodGet, "/files/3", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a.png", resp["file_name"])
+
+	// 他人共享的文件可读
+	f.expectGet(shared)
+	w, _ = f.do(http.MethodGet, "/files/4", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	f.expectGet(shared)
+	w, resp = f.do(http.MethodGet, "/files/4/download", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, resp["url"], "/"+miniotest.Bucket+"/uid_8/sha256/def")
+
+	// 他人未共享的文件与不存在的文件响应相同
+	f.expectGet(private)
+	w, _ = f.do(http.MethodGet, "/files/5", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	f.mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?$").WithArgs(int64(6)).WillReturnRows(sqlmock.NewRows(minioFileColumns))
+	w, _ = f.do(http.MethodGet, "/files/6", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w, _ = f.do(http.MethodGet, "/files/abc", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 隔离中的文件不签发下载链接
+	own.ScanStatus = models.ScanStatusPending
+	f.expectGet(own)
+	w, resp = f.do(http.MethodGet, "/files/3/download", "")
+	assert.Equal(t, http.StatusLocked, w.Code)
+	assert.Equal(t, CodeFileQuarantined, resp["code"])
+}
+
+func TestQuarantinedFileDerivedData(t *testing.T) {
+	f := newFileTest(t)
+
+	// 待扫描与已感染的文件都不返回由内容提取的数据，不会读取缩略图、元数据或压缩包清单
+	for _, status := range []string{models.ScanStatusPending, models.ScanStatusInfected} {
+		file := models.MinioFile{ID: 3, Uid: 7, FileName: "a.zip", ObjectKey: "uid_7/sha256/abc", ContentType: "application/zip", ScanStatus: status}
+		for _, path := range []string{"/files/3/thumbnails", "/files/3/metadata", "/files/3/archive"} {
+			f.expectGet(file)
+			w, resp := f.do(http.MethodGet, path, "")
+			assert.Equal(t, http.StatusLocked, w.Code, path)
+			assert.Equal(t, CodeFileQuarantined, resp["code"], path)
+			assert.Equal(t, status, resp["scan_status"], path)
+		}
+	}
+}
+
+func TestRenameFile(t *testing.T) {
+	f := newFileTest(t)
+	own := models.MinioFile{ID: 3, Uid: 7, FileName: "a.png", ObjectKey: "uid_7/sha256/abc", ContentType: "image/png"}
+
+	f.expectGet(own)
+	f.mock.ExpectExec("UPDATE minio_files SET file_name = \\? WHERE id = \\? AND uid = \\?").
+		WithArgs("b.PNG", int64(3), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	w, resp := f.do(http.MethodPatch, "/files/3", `{"file_name":"dir/b.PNG"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "b.PNG", resp["file_name"])
+
+	f.expectGet(own)
+	w, _ = f.do(http.MethodPatch, "/files/3", `{"file_name":"b.exe"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 共享文件只读，不能被其他用户改名
+	f.expectGet(models.MinioFile{ID: 4, Uid: 8, FileName: "b.png", IsShared: true})
+	w, _ = f.do(http.MethodPatch, "/files/4", `{"file_name":"c.png"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteFile(t *testing.T) {
+	f := newFileTest(t)
+	own := models.MinioFile{ID: 3, Uid: 7, FileName: "a.png", Bucket: miniotest.Bucket, ObjectKey: "uid_7/sha256/abc", ContentType: "image/png", FileSize: 5}
+	f.store.Put(own.ObjectKey, []byte("image"), "image/png")
+
+	// 共享文件不能被其他用户删除
+	f.expectGet(models.MinioFile{ID: 4, Uid: 8, FileName: "b.png", IsShared: true})
+	w, _ := f.do(http.MethodDelete, "/files/4", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	f.expectGet(own)
+	f.mock.ExpectBegin()
+	f.mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\? AND uid = \\? FOR UPDATE").WithArgs(int64(3), 7).
+		WillReturnRows(fileRows(own))
+	f.mock.ExpectExec("INSERT IGNORE INTO user_storage_usage").WithArgs(7, 7).WillReturnResult(sqlmock.NewResult(0, 0))
+	f.mock.ExpectQuery("SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = \\? FOR UPDATE").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "file_count"}).AddRow(5, 1))
+	f.mock.ExpectExec("UPDATE user_storage_usage").WithArgs(int64(-5), -1, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	f.mock.ExpectExec("DELETE FROM minio_files WHERE id = \\?").WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	f.mock.ExpectQuery("SELECT ref_count FROM file_blobs").WithArgs(miniotest.Bucket, own.ObjectKey).
+		WillReturnRows(sqlmock.NewRows([]string{"ref_count"}).AddRow(1))
+	f.mock.ExpectExec("DELETE FROM file_blobs").WithArgs(miniotest.Bucket, own.ObjectKey).WillReturnResult(sqlmock.NewResult(0, 1))
+	f.mock.ExpectCommit()
+	w, resp := f.do(http.MethodDelete, "/files/3", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(3), resp["id"])
+	assert.Empty(t, f.store.Keys())
+}
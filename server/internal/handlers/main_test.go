@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var minioFileColumns = []string{"id", "uid", "file_name", "bucket", "object_key", "content_type", "file_size", "sha256", "is_shared", "scan_status", "scan_result", "created_at"}
+
+// fileRows 返回一条 minio_files 记录
+func fileRows(f models.MinioFile) *sqlmock.Rows {
+	if f.ScanStatus == "" {
+		f.ScanStatus = models.ScanStatusClean
+	}
+	return sqlmock.NewRows(minioFileColumns).AddRow(f.ID, f.Uid, f.FileName, f.Bucket, f.ObjectKey, f.ContentType,
+		f.FileSize, f.SHA256, f.IsShared, f.ScanStatus, f.ScanResult, time.Now())
+}
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// newTestEngine 创建以 uid 身份访问的路由，代替 AuthMiddleware
+func newTestEngine(uid int) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		middleware.SetCurrentUser(c, &models.User{Uid: uid})
+	})
+	return r
+}
+
+// newMockDB 以 sqlmock 替换全局数据库连接，测试结束时校验全部预期均已满足
+func newMockDB(t *testing.T) sqlmock.Sqlmock {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+	return mock
+}
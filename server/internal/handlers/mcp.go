@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/mcp"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// maxMCPRequestSize 单条 JSON-RPC 消息的大小上限
+const maxMCPRequestSize = 1 << 20
+
+// MCPHandler 以 Streamable HTTP 传输提供平台自身的 MCP 服务器。
+// 不分配会话，每个请求都通过 AuthMiddleware 鉴权，工具以当前用户的 uid 执行
+type MCPHandler struct {
+	server *mcp.Server
+}
+
+// NewMCPHandler 创建新的 MCP 处理器
+func NewMCPHandler(server *mcp.Server) *MCPHandler {
+	return &MCPHandler{server: server}
+}
+
+// Post 处理一条 JSON-RPC 消息：请求以 application/json 返回响应，通知与响应返回 202
+func (h *MCPHandler) Post(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+
+	if v := c.GetHeader("MCP-Protocol-Version"); v != "" && !mcp.SupportedProtocolVersion(v) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported MCP protocol version"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxMCPRequestSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(body) > maxMCPRequestSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+		return
+	}
+
+	resp := h.server.Handle(c.Request.Context(), user.Uid, body)
+	if resp == nil {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", resp)
+}
+
+// MethodNotAllowed 服务端不提供 GET 推送流，也没有可以 DELETE 结束的会话
+func (h *MCPHandler) MethodNotAllowed(c *gin.Context) {
+	c.Header("Allow", http.MethodPost)
+	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InitiateMultipartRequest 初始化分片上传请求
+type InitiateMultipartRequest struct {
+	FileName    string `json:"file_name" binding:"required"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+	ContentType string `json:"content_type"`
+}
+
+// InitiateMultipart 创建分片上传会话，返回分片大小与分片数
+func (h *UploadHandler) InitiateMultipart(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+
+	var req InitiateMultipartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	fileName := path.Base(filepath.ToSlash(req.FileName))
+	ext, ok := h.validateFile(c, fileName, req.Size)
+	if !ok {
+		return
+	}
+	if !h.checkQuota(c, user.Uid, req.Size) {
+		return
+	}
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = contentTypeByExt(ext)
+	}
+
+	upload, err := h.multipart.Initiate(c.Request.Context(), user.Uid, fileName, req.Size, contentType)
+	if err != nil {
+		logger.Logger.Error("failed to initiate multipart upload", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initiate upload"})
+		return
+	}
+
+	logger.Logger.Info("multipart upload initiated",
+		zap.String("upload_id", upload.ID),
+		zap.Int("uid", user.Uid),
+		zap.Int64("size", upload.FileSize),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"upload_id":  upload.ID,
+		"part_size":  upload.PartSize,
+		"part_count": upload.PartCount(),
+	})
+}
+
+// UploadPart 上传单个分片，请求体为分片原始字节
+func (h *UploadHandler) UploadPart(c *gin.Context) {
+	upload, ok := h.loadMultipart(c)
+	if !ok {
+		return
+	}
+	partNumber, err := strconv.Atoi(c.Param("partNumber"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid part number"})
+		return
+	}
+	size := c.Request.ContentLength
+	if size <= 0 {
+		c.JSON(http.StatusLengthRequired, gin.H{"error": "Content-Length is required"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, size)
+	part, err := h.multipart.UploadPart(c.Request.Context(), upload, partNumber, body, size)
+	if err != nil {
+		h.respondMultipartError(c, upload, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, part)
+}
+
+// MultipartStatus 查询分片上传进度
+func (h *UploadHandler) MultipartStatus(c *gin.Context) {
+	upload, ok := h.loadMultipart(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.multipart.Progress(c.Request.Context(), upload)
+	if err != nil {
+		h.respondMultipartError(c, upload, err)
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// CompleteMultipart 合并分片并登记文件。登记因临时错误失败时会话保持 assembled，客户端可再次调用重试
+func (h *UploadHandler) CompleteMultipart(c *gin.Context) {
+	upload, ok := h.loadMultipart(c)
+	if !ok {
+		return
+	}
+
+	if err := h.multipart.Complete(c.Request.Context(), upload); err != nil {
+		h.respondMultipartError(c, upload, err)
+		return
+	}
+
+	record := &models.MinioFile{
+		Uid:      upload.Uid,
+		FileName: upload.FileName,
+		FileSize: upload.FileSize,
+	}
+	err := h.registerAndRespond(c, record, upload.ObjectKey)
+	switch {
+	case err == nil:
+		if err := h.multipart.MarkCompleted(upload); err != nil {
+			logger.Logger.Warn("failed to mark multipart upload completed", zap.String("upload_id", upload.ID), zap.Error(err))
+		}
+	case services.IsRejected(err):
+		// 内容被拒绝时合并后的对象已删除，重试不会成功，直接结束会话
+		if err := h.multipart.Abort(c.Request.Context(), upload); err != nil && !errors.Is(err, services.ErrUploadNotActive) {
+			logger.Logger.Warn("failed to abort rejected multipart upload", zap.String("upload_id", upload.ID), zap.Error(err))
+		}
+	}
+}
+
+// AbortMultipart 取消分片上传
+func (h *UploadHandler) AbortMultipart(c *gin.Context) {
+	upload, ok := h.loadMultipart(c)
+	if !ok {
+		return
+	}
+
+	if err := h.multipart.Abort(c.Request.Context(), upload); err != nil {
+		h.respondMultipartError(c, upload, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Upload aborted", "upload_id": upload.ID})
+}
+
+// loadMultipart 获取当前用户的上传会话，失败时已写入响应
+func (h *UploadHandler) loadMultipart(c *gin.Context) (*models.MultipartUpload, bool) {
+	user := middleware.MustCurrentUser(c)
+	upload, err := h.multipart.Get(user.Uid, c.Param("uploadID"))
+	if err != nil {
+		logger.Logger.Error("failed to get multipart upload", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	if upload == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return nil, false
+	}
+	return upload, true
+}
+
+func (h *UploadHandler) respondMultipartError(c *gin.Context, upload *models.MultipartUpload, err error) {
+	switch {
+	case errors.Is(err, services.ErrUploadNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload is " + upload.Status})
+	case errors.Is(err, services.ErrInvalidPart):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid part number or part size"})
+	case errors.Is(err, services.ErrIncompleteUpload):
+		c.JSON(http.StatusConflict, gin.H{"error": "Not all parts have been uploaded"})
+	default:
+		logger.Logger.Error("multipart upload operation failed",
+			zap.String("upload_id", upload.ID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload operation failed"})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUploadID = "5d0c7b8e-6a4f-4c53-9a55-1f7f3c1f2a10"
+
+// expectMultipartUpload 预期读取用户 7 的分片上传会话
+func expectMultipartUpload(mock sqlmock.Sqlmock, objectKey string, size int64, status string) {
+	mock.ExpectQuery("SELECT (.+) FROM multipart_uploads WHERE id = \\? AND uid = \\?").WithArgs(testUploadID, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "storage_upload_id", "object_key", "file_name", "file_size",
+			"content_type", "part_size", "status", "created_at", "updated_at"}).
+			AddRow(testUploadID, 7, "storage-upload", objectKey, "a.png", size, "image/png", int64(5<<20), status, time.Now(), time.Now()))
+}
+
+func expectMultipartStatus(mock sqlmock.Sqlmock, from, to string) {
+	mock.ExpectExec("UPDATE multipart_uploads SET status = \\? WHERE id = \\? AND status = \\?").
+		WithArgs(to, testUploadID, from).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestCompleteMultipartRetriesRegister(t *testing.T) {
+	u := newUploadTest(t)
+	objectKey := "uid_7/" + testUploadID + "/a.png"
+	u.store.Put(objectKey, testPNG, "")
+	sum := sha256.Sum256(testPNG)
+	sha := hex.EncodeToString(sum[:])
+	path := "/uploads/multipart/" + testUploadID + "/complete"
+
+	// 分片已合并，但登记时数据库出错：会话保持 assembled，合并后的对象保留
+	expectMultipartUpload(u.mock, objectKey, int64(len(testPNG)), models.MultipartStatusAssembled)
+	u.mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE uid = \\? AND sha256 = \\?").WithArgs(7, sha).
+		WillReturnError(errors.New("connection reset"))
+	w, _ := u.post(path, "")
+	require.Equal(t, http.StatusInternalServerError, w.Code, w.Body.String())
+	assert.Equal(t, []string{objectKey}, u.store.Keys())
+
+	// 再次 complete 跳过合并，登记成功后结束会话
+	expectMultipartUpload(u.mock, objectKey, int64(len(testPNG)), models.MultipartStatusAssembled)
+	expectNewFile(u.mock, testPNG, models.ScanStatusClean, 12)
+	expectMultipartStatus(u.mock, models.MultipartStatusAssembled, models.MultipartStatusCompleted)
+	w, resp := u.post(path, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(12), resp["id"])
+	assert.Equal(t, []string{"uid_7/sha256/" + sha}, u.store.Keys())
+
+	// 已完成的会话不能再次 complete
+	expectMultipartUpload(u.mock, objectKey, int64(len(testPNG)), models.MultipartStatusCompleted)
+	w, _ = u.post(path, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCompleteMultipartRejected(t *testing.T) {
+	u := newUploadTest(t)
+	objectKey := "uid_7/" + testUploadID + "/a.png"
+	data := []byte("not a png at all")
+	u.store.Put(objectKey, data, "")
+
+	// 内容被拒绝时对象已删除，会话随之结束
+	expectMultipartUpload(u.mock, objectKey, int64(len(data)), models.MultipartStatusAssembled)
+	expectMultipartStatus(u.mock, models.MultipartStatusAssembled, models.MultipartStatusAborted)
+	w, resp := u.post("/uploads/multipart/"+testUploadID+"/complete", "")
+	require.Equal(t, http.StatusUnsupportedMediaType, w.Code, w.Body.String())
+	assert.Equal(t, "FILE_TYPE_NOT_ALLOWED", resp["code"])
+	assert.Empty(t, u.store.Keys())
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// maxPage 页码上限，避免 (page-1)*page_size 溢出为负数偏移
+	maxPage = 10000
+)
+
+// pagination 从查询参数 page、page_size 解析分页，非法值回退为默认值，超出上限时取上限
+type pagination struct {
+	Page     int
+	PageSize int
+}
+
+func parsePagination(c *gin.Context) pagination {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	if page > maxPage {
+		page = maxPage
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return pagination{Page: page, PageSize: pageSize}
+}
+
+func (p pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePagination(t *testing.T) {
+	cases := []struct {
+		query          string
+		page, pageSize int
+		offset         int
+	}{
+		{"", 1, defaultPageSize, 0},
+		{"page=3&page_size=10", 3, 10, 20},
+		{"page=0&page_size=-1", 1, defaultPageSize, 0},
+		{"page=abc&page_size=1000", 1, maxPageSize, 0},
+		// 极大的页码不会溢出为负数偏移
+		{"page=9223372036854775807&page_size=100", maxPage, 100, (maxPage - 1) * 100},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/?"+tc.query, nil)
+
+		p := parsePagination(c)
+		assert.Equal(t, tc.page, p.Page, tc.query)
+		assert.Equal(t, tc.pageSize, p.PageSize, tc.query)
+		assert.Equal(t, tc.offset, p.Offset(), tc.query)
+	}
+}
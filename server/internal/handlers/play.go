@@ -1,31 +1,208 @@
 package handlers
 
 import (
-	"fmt"
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-type PlayHandler struct{}
+type PlayHandler struct {
+	minio        *storage.MinioService
+	transcode    *services.TranscodeService
+	minioFileDAO *dao.MinioFileDAO
+}
 
-func NewPlayHandler() *PlayHandler {
-	return &PlayHandler{}
+func NewPlayHandler(minioSvc *storage.MinioService, transcodeSvc *services.TranscodeService) *PlayHandler {
+	return &PlayHandler{
+		minio:        minioSvc,
+		transcode:    transcodeSvc,
+		minioFileDAO: dao.NewMinioFileDAO(),
+	}
 }
 
+// Play 按 minio_files 记录ID流式返回视频，支持 Range 请求以便浏览器拖动进度。
+// 携带 format=hls 时重定向到转码后的 HLS 主播放列表。
 func (h *PlayHandler) Play(c *gin.Context) {
-	videoID := c.Param("videoID")
+	file, ok := h.loadVideo(c)
+	if !ok {
+		return
+	}
+	if c.Query("format") == "hls" {
+		h.redirectHLS(c, file)
+		return
+	}
 
-	fmt.Printf("正在播放视频：%s\n", videoID)
+	obj, info, err := h.minio.GetObject(c.Request.Context(), file.ObjectKey)
+	if err != nil {
+		logger.Logger.Error("failed to open video object",
+			zap.Int64("video_id", file.ID),
+			zap.String("object_key", file.ObjectKey),
+			zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read video from object storage"})
+		return
+	}
+	defer obj.Close()
 
 	logger.Logger.Info("playing video",
-		zap.String("video_id", videoID),
+		zap.Int64("video_id", file.ID),
+		zap.Int("uid", middleware.MustCurrentUser(c).Uid),
+		zap.String("range", c.GetHeader("Range")),
 	)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":  "Playing video",
-		"video_id": videoID,
-	})
+	c.Header("Content-Type", videoContentType(file))
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Cache-Control", "private, max-age=3600")
+	if info.ETag != "" {
+		c.Header("ETag", `"`+info.ETag+`"`)
+	}
+	// ServeContent 负责解析 Range、返回 206/416 并设置 Content-Range
+	http.ServeContent(c.Writer, c.Request, file.FileName, info.LastModified, obj)
+}
+
+// HLS 返回转码后的播放列表与分片，路径相对于 HLS 输出目录
+func (h *PlayHandler) HLS(c *gin.Context) {
+	file, ok := h.loadVideo(c)
+	if !ok {
+		return
+	}
+	if _, ok := h.readyTranscode(c, file); !ok {
+		return
+	}
+
+	rel := path.Clean("/" + c.Param("path"))[1:]
+	if rel == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	obj, info, err := h.minio.GetObject(c.Request.Context(), services.HLSPrefix(file)+rel)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	defer obj.Close()
+
+	c.Header("Cache-Control", "private, max-age=3600")
+	if strings.HasSuffix(rel, ".m3u8") {
+		body, err := io.ReadAll(obj)
+		if err != nil {
+			logger.Logger.Error("failed to read playlist", zap.Int64("video_id", file.ID), zap.Error(err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read playlist"})
+			return
+		}
+		// <video> 原生 HLS 无法携带请求头，播放列表中的相对地址需沿用 access_token
+		c.Data(http.StatusOK, services.HLSContentType(rel), appendPlaylistToken(body, c.Query("access_token")))
+		return
+	}
+	c.Header("Content-Type", services.HLSContentType(rel))
+	http.ServeContent(c.Writer, c.Request, path.Base(rel), info.LastModified, obj)
+}
+
+// redirectHLS 转码完成时重定向到主播放列表，否则返回转码状态
+func (h *PlayHandler) redirectHLS(c *gin.Context, file *models.MinioFile) {
+	if _, ok := h.readyTranscode(c, file); !ok {
+		return
+	}
+	target := c.Request.URL.Path + "/hls/" + media.MasterPlaylist
+	if token := c.Query("access_token"); token != "" {
+		target += "?access_token=" + url.QueryEscape(token)
+	}
+	c.Redirect(http.StatusFound, target)
+}
+
+// readyTranscode 确认转码已完成；未完成时返回 202 及当前状态，失败时已写入响应
+func (h *PlayHandler) readyTranscode(c *gin.Context, file *models.MinioFile) (*models.VideoTranscode, bool) {
+	t, err := h.transcode.Status(file.ID)
+	if err != nil {
+		logger.Logger.Error("failed to get transcode status", zap.Int64("video_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "HLS rendition not available"})
+		return nil, false
+	}
+	if t.Status != models.TranscodeStatusReady {
+		c.JSON(http.StatusAccepted, gin.H{"status": t.Status, "error": t.Error})
+		return nil, false
+	}
+	return t, true
+}
+
+// loadVideo 解析视频ID并确认当前用户可读、文件为视频且未被隔离，失败时已写入响应
+func (h *PlayHandler) loadVideo(c *gin.Context) (*models.MinioFile, bool) {
+	user := middleware.MustCurrentUser(c)
+	videoID, err := strconv.ParseInt(c.Param("videoID"), 10, 64)
+	if err != nil || videoID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video id"})
+		return nil, false
+	}
+
+	file, err := h.minioFileDAO.GetByID(videoID)
+	if err != nil {
+		logger.Logger.Error("failed to get video record", zap.Int64("video_id", videoID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	// 无权访问与不存在返回相同响应，避免泄露他人文件ID
+	if file == nil || !file.CanBeReadBy(user.Uid) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+		return nil, false
+	}
+	if !file.IsVideo() {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File is not a video"})
+		return nil, false
+	}
+	if !requireClean(c, file) {
+		return nil, false
+	}
+	return file, true
+}
+
+// appendPlaylistToken 为播放列表中的 URI 行追加 access_token 参数，token 为空时原样返回
+func appendPlaylistToken(playlist []byte, token string) []byte {
+	if token == "" {
+		return playlist
+	}
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(playlist))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" && !strings.HasPrefix(line, "#") {
+			sep := "?"
+			if strings.Contains(line, "?") {
+				sep = "&"
+			}
+			line += sep + "access_token=" + url.QueryEscape(token)
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+func videoContentType(f *models.MinioFile) string {
+	if strings.HasPrefix(f.ContentType, "video/") {
+		return f.ContentType
+	}
+	if mt := mime.TypeByExtension(strings.ToLower(filepath.Ext(f.FileName))); mt != "" {
+		return mt
+	}
+	return "application/octet-stream"
 }
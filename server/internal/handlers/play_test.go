@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendPlaylistToken(t *testing.T) {
+	playlist := []byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\n720p/index.m3u8\n#EXTINF:6.0,\nseg_0000.ts?v=1\n")
+
+	assert.Equal(t, playlist, appendPlaylistToken(playlist, ""))
+	assert.Equal(t,
+		"#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\n720p/index.m3u8?access_token=a%2Bb\n#EXTINF:6.0,\nseg_0000.ts?v=1&access_token=a%2Bb\n",
+		string(appendPlaylistToken(playlist, "a+b")))
+}
+
+func TestPlayRange(t *testing.T) {
+	mock := newMockDB(t)
+	store, minioSvc := miniotest.Start(t)
+	video := bytes.Repeat([]byte("0123456789"), 100)
+	store.Put("uid_7/sha256/abc", video, "video/mp4")
+
+	h := NewPlayHandler(minioSvc, nil)
+	r := newTestEngine(7)
+	r.GET("/play/:videoID", h.Play)
+	r.HEAD("/play/:videoID", h.Play)
+	row := models.MinioFile{ID: 3, Uid: 7, FileName: "a.mp4", ObjectKey: "uid_7/sha256/abc", ContentType: "video/mp4", FileSize: int64(len(video))}
+	play := func(method, rangeHeader string) *httptest.ResponseRecorder {
+		mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).WillReturnRows(fileRows(row))
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/play/3", nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := play(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "video/mp4", w.Header().Get("Content-Type"))
+	assert.Equal(t, video, w.Body.Bytes())
+
+	w = play(http.MethodGet, "bytes=10-19")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bytes 10-19/1000", w.Header().Get("Content-Range"))
+	assert.Equal(t, "0123456789", w.Body.String())
+
+	// 拖动到末尾：后缀范围
+	w = play(http.MethodGet, "bytes=-5")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bytes 995-999/1000", w.Header().Get("Content-Range"))
+	assert.Equal(t, "56789", w.Body.String())
+
+	w = play(http.MethodGet, "bytes=2000-")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+	assert.Equal(t, "bytes */1000", w.Header().Get("Content-Range"))
+
+	w = play(http.MethodHead, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1000", w.Header().Get("Content-Length"))
+	assert.Empty(t, w.Body.Bytes())
+}
+
+func TestPlayAccess(t *testing.T) {
+	mock := newMockDB(t)
+	h := NewPlayHandler(nil, nil)
+	r := newTestEngine(7)
+	r.GET("/play/:videoID", h.Play)
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("/play/abc"))
+
+	// 他人未共享的文件按不存在处理
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(int64(4)).
+		WillReturnRows(fileRows(models.MinioFile{ID: 4, Uid: 8, FileName: "b.mp4", ContentType: "video/mp4"}))
+	assert.Equal(t, http.StatusNotFound, get("/play/4"))
+
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(int64(5)).
+		WillReturnRows(fileRows(models.MinioFile{ID: 5, Uid: 7, FileName: "c.png", ContentType: "image/png"}))
+	assert.Equal(t, http.StatusUnsupportedMediaType, get("/play/5"))
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GalleryItem 图库中的一个文件及其缩略图，缩略图尚未生成时 thumbnails 为空
+type GalleryItem struct {
+	models.MinioFile
+	Thumbnails []models.FileThumbnail `json:"thumbnails"`
+}
+
+// Gallery 分页列出当前用户的图片与视频及其缩略图，按上传时间倒序
+func (h *FileHandler) Gallery(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+	p := parsePagination(c)
+
+	files, total, err := h.minioFileDAO.ListByUID(user.Uid, dao.ListFilesOptions{
+		SortBy:       "created_at",
+		Desc:         true,
+		Offset:       p.Offset(),
+		Limit:        p.PageSize,
+		MediaClasses: []models.MediaClass{models.ImageClass, models.VideoClass},
+	})
+	if err != nil {
+		logger.Logger.Error("failed to list gallery files", zap.Int("uid", user.Uid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	ids := make([]int64, len(files))
+	for i, f := range files {
+		ids[i] = f.ID
+	}
+	thumbs, err := h.thumbnails.ListByFileIDs(ids)
+	if err != nil {
+		logger.Logger.Error("failed to list thumbnails", zap.Int("uid", user.Uid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	items := make([]GalleryItem, len(files))
+	for i, f := range files {
+		items[i] = GalleryItem{MinioFile: f, Thumbnails: withThumbnailURLs(thumbs[f.ID])}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"files":     items,
+		"total":     total,
+		"page":      p.Page,
+		"page_size": p.PageSize,
+	})
+}
+
+// Thumbnails 返回文件已生成的缩略图列表，隔离中的文件返回 423
+func (h *FileHandler) Thumbnails(c *gin.Context) {
+	file, ok := h.loadReadable(c)
+	if !ok || !requireClean(c, file) {
+		return
+	}
+	thumbs, err := h.thumbnails.ListByFileIDs([]int64{file.ID})
+	if err != nil {
+		logger.Logger.Error("failed to list thumbnails", zap.Int64("file_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"file_id":    file.ID,
+		"thumbnails": withThumbnailURLs(thumbs[file.ID]),
+	})
+}
+
+// Thumbnail 返回指定规格的缩略图图片，可通过 access_token 查询参数鉴权以便 <img> 直接引用
+func (h *FileHandler) Thumbnail(c *gin.Context) {
+	file, ok := h.loadReadable(c)
+	if !ok || !requireClean(c, file) {
+		return
+	}
+	thumb, err := h.thumbnails.Get(file.ID, c.Param("size"))
+	if err != nil {
+		logger.Logger.Error("failed to get thumbnail", zap.Int64("file_id", file.ID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if thumb == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+		return
+	}
+
+	obj, info, err := h.minio.GetObject(c.Request.Context(), thumb.ObjectKey)
+	if err != nil {
+		logger.Logger.Error("failed to open thumbnail object",
+			zap.Int64("file_id", file.ID),
+			zap.String("object_key", thumb.ObjectKey),
+			zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read thumbnail from object storage"})
+		return
+	}
+	defer obj.Close()
+
+	c.Header("Content-Type", services.ThumbnailContentType)
+	c.Header("Cache-Control", "private, max-age=86400")
+	if info.ETag != "" {
+		c.Header("ETag", `"`+info.ETag+`"`)
+	}
+	http.ServeContent(c.Writer, c.Request, thumb.Size+".jpg", info.LastModified, obj)
+}
+
+// withThumbnailURLs 填充缩略图的访问地址，nil 转为空列表
+func withThumbnailURLs(thumbs []models.FileThumbnail) []models.FileThumbnail {
+	out := make([]models.FileThumbnail, len(thumbs))
+	for i, t := range thumbs {
+		t.URL = thumbnailURL(t.FileID, t.Size)
+		out[i] = t
+	}
+	return out
+}
+
+func thumbnailURL(fileID int64, size string) string {
+	return fmt.Sprintf("/api/v1/files/%d/thumbnails/%s", fileID, size)
+}
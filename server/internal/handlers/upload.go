@@ -1,46 +1,51 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"mime"
 	"net/http"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
-	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 type UploadHandler struct {
-	config *config.Config
-	minio  *storage.MinioService
+	config    *config.Config
+	minio     *storage.MinioService
+	files     *services.FileService
+	quota     *services.QuotaService
+	multipart *services.MultipartUploadService
 }
 
-func NewUploadHandler(cfg *config.Config) *UploadHandler {
-	minioSvc, err := storage.NewMinioService(cfg.Minio)
-	if err != nil {
-		logger.Logger.Fatal("failed to init minio: " + err.Error())
-	}
-	return &UploadHandler{config: cfg, minio: minioSvc}
+// PresignUploadRequest 申请直传URL请求
+type PresignUploadRequest struct {
+	FileName string `json:"file_name" binding:"required"`
+	Size     int64  `json:"size" binding:"required,gt=0"`
+}
+
+// CompleteUploadRequest 直传完成后登记文件请求
+type CompleteUploadRequest struct {
+	ObjectKey string `json:"object_key" binding:"required"`
+	FileName  string `json:"file_name" binding:"required"`
+}
+
+func NewUploadHandler(cfg *config.Config, minioSvc *storage.MinioService, fileSvc *services.FileService, quotaSvc *services.QuotaService, multipartSvc *services.MultipartUploadService) *UploadHandler {
+	return &UploadHandler{config: cfg, minio: minioSvc, files: fileSvc, quota: quotaSvc, multipart: multipartSvc}
 }
 
 func (h *UploadHandler) Upload(c *gin.Context) {
-	// 验证认证头，提取用户名
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-		return
-	}
-	tokenParts := strings.Split(authHeader, " ")
-	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" || !strings.HasPrefix(tokenParts[1], "token_") {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header"})
-		return
-	}
-	username := strings.TrimPrefix(tokenParts[1], "token_")
+	user := middleware.MustCurrentUser(c)
 
 	// 读取文件
 	file, err := c.FormFile("file")
@@ -51,34 +56,13 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 	}
 
 	// 校验大小与类型
-	if file.Size > h.config.Upload.MaxSize {
-		logger.Logger.Warn("file size exceeds limit",
-			zap.String("filename", file.Filename),
-			zap.Int64("size", file.Size),
-			zap.Int64("max_size", h.config.Upload.MaxSize),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File size exceeds limit of %d bytes", h.config.Upload.MaxSize)})
-		return
-	}
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if !h.isAllowedType(ext) {
-		logger.Logger.Warn("file type not allowed",
-			zap.String("filename", file.Filename),
-			zap.String("extension", ext),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File type not allowed"})
+	if _, ok := h.validateFile(c, file.Filename, file.Size); !ok {
 		return
 	}
-
-	// 获取用户UID
-	userDAO := dao.NewUserDAO()
-	user, err := userDAO.GetUserByUsername(username)
-	if err != nil || user == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user"})
+	if !h.checkQuota(c, user.Uid, file.Size) {
 		return
 	}
 
-	// 上传到 MinIO
 	f, err := file.Open()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded file"})
@@ -86,46 +70,227 @@ func (h *UploadHandler) Upload(c *gin.Context) {
 	}
 	defer f.Close()
 
-	objectName := fmt.Sprintf("uid_%d/%s", user.Uid, file.Filename)
-	contentType := file.Header.Get("Content-Type")
-	if contentType == "" {
-		if ext != "" {
-			if mt := mime.TypeByExtension(ext); mt != "" {
-				contentType = mt
-			}
+	// 内容类型以文件头识别结果为准，不使用客户端提供的 Content-Type
+	record := &models.MinioFile{
+		Uid:      user.Uid,
+		FileName: path.Base(filepath.ToSlash(file.Filename)),
+		FileSize: file.Size,
+	}
+
+	// 边上传边计算哈希，按内容寻址存储
+	stored, err := h.files.Store(c.Request.Context(), record, f)
+	if err != nil {
+		if h.respondStoreError(c, record, err) {
+			return
 		}
+		logger.Logger.Error("failed to store uploaded file", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to object storage"})
+		return
 	}
-	if contentType == "" {
-		contentType = "application/octet-stream"
+	h.respondStored(c, stored)
+}
+
+// PresignUpload 签发预签名 PUT URL，浏览器可直接上传到对象存储而不经过本服务
+func (h *UploadHandler) PresignUpload(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+
+	var req PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
 	}
-	url, err := h.minio.Upload(c.Request.Context(), objectName, f, file.Size, contentType)
+	fileName := path.Base(filepath.ToSlash(req.FileName))
+	if _, ok := h.validateFile(c, fileName, req.Size); !ok {
+		return
+	}
+	if !h.checkQuota(c, user.Uid, req.Size) {
+		return
+	}
+
+	// 对象键位于暂存前缀并带随机段，避免直传覆盖已有对象
+	objectKey := services.IncomingKey(user.Uid, fileName)
+	uploadURL, expiresAt, err := h.minio.PresignedPutURL(c.Request.Context(), objectKey)
 	if err != nil {
-		logger.Logger.Error("failed to upload to minio", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to object storage"})
+		logger.Logger.Error("failed to presign upload", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload url"})
 		return
 	}
 
-	// 写入数据库
-	mfDAO := dao.NewMinioFileDAO()
-	if err := mfDAO.Create(user.Uid, file.Filename, url); err != nil {
-		logger.Logger.Error("failed to insert minio file record", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record file metadata"})
+	c.JSON(http.StatusOK, gin.H{
+		"method":     http.MethodPut,
+		"upload_url": uploadURL,
+		"object_key": objectKey,
+		"expires_at": expiresAt,
+	})
+}
+
+// CompleteUpload 直传完成后校验对象并登记到 minio_files
+func (h *UploadHandler) CompleteUpload(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+
+	var req CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	// 只能登记为自己签发的暂存对象，其他对象键（内容对象、衍生文件等）登记后会被误删
+	if !services.IsIncomingKey(user.Uid, req.ObjectKey) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Object key was not issued for this user's upload"})
+		return
+	}
+
+	// 登记后直传对象会被转存到内容寻址键并删除，重复 complete 时对象已不存在
+	info, err := h.minio.StatObject(c.Request.Context(), req.ObjectKey)
+	if err != nil {
+		logger.Logger.Warn("uploaded object not found", zap.String("object_key", req.ObjectKey), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded object not found"})
+		return
+	}
+	fileName := path.Base(filepath.ToSlash(req.FileName))
+	if _, ok := h.validateFile(c, fileName, info.Size); !ok {
+		// 预签名 PUT 无法限制大小，不合规的对象直接删除
+		if err := h.minio.RemoveObject(c.Request.Context(), req.ObjectKey); err != nil {
+			logger.Logger.Warn("failed to remove rejected object", zap.String("object_key", req.ObjectKey), zap.Error(err))
+		}
 		return
 	}
 
+	record := &models.MinioFile{
+		Uid:      user.Uid,
+		FileName: fileName,
+		FileSize: info.Size,
+	}
+	h.registerAndRespond(c, record, req.ObjectKey)
+}
+
+// registerAndRespond 将已写入对象存储的对象按内容哈希入库并返回响应，入库错误原样返回供调用方善后
+func (h *UploadHandler) registerAndRespond(c *gin.Context, record *models.MinioFile, objectKey string) error {
+	stored, err := h.files.Register(c.Request.Context(), record, objectKey)
+	if err != nil {
+		if h.respondStoreError(c, record, err) {
+			return err
+		}
+		logger.Logger.Error("failed to register uploaded object", zap.String("object_key", objectKey), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record file metadata"})
+		return err
+	}
+	h.respondStored(c, stored)
+	return nil
+}
+
+// respondStored 返回入库结果，duplicate 表示内容已上传过。
+// 已放行的文件附带限时下载链接；隔离中的文件（待扫描或重复上传命中已感染内容）只返回 scan_status，不签发链接
+func (h *UploadHandler) respondStored(c *gin.Context, stored *services.StoredFile) {
+	record := stored.File
 	logger.Logger.Info("file uploaded to minio successfully",
-		zap.String("filename", file.Filename),
-		zap.Int64("size", file.Size),
-		zap.String("url", url),
-		zap.Int("uid", user.Uid),
+		zap.String("filename", record.FileName),
+		zap.Int64("size", record.FileSize),
+		zap.String("object_key", record.ObjectKey),
+		zap.Int("uid", record.Uid),
+		zap.Bool("duplicate", stored.Duplicate),
 	)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":  "File uploaded successfully",
-		"filename": file.Filename,
-		"size":     file.Size,
-		"url":      url,
+	message := "File uploaded successfully"
+	if stored.Duplicate {
+		message = "File already uploaded"
+	}
+	resp := gin.H{
+		"message":   message,
+		"id":        record.ID,
+		"filename":  record.FileName,
+		"size":      record.FileSize,
+		"sha256":    record.SHA256,
+		"duplicate": stored.Duplicate,
+	}
+	if record.Quarantined() {
+		resp["scan_status"] = record.ScanStatus
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	url, expiresAt, err := h.minio.PresignedGetURL(c.Request.Context(), record.ObjectKey, "")
+	if err != nil {
+		logger.Logger.Warn("failed to presign download url", zap.Error(err))
+		url, expiresAt = "", time.Time{}
+	}
+	resp["url"] = url
+	resp["url_expires_at"] = expiresAt
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkQuota 预检查用户配额，超出时返回 413，失败时已写入响应
+func (h *UploadHandler) checkQuota(c *gin.Context, uid int, size int64) bool {
+	err := h.quota.Check(uid, size)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		h.respondQuotaExceeded(c, uid)
+		return false
+	}
+	logger.Logger.Error("failed to check storage quota", zap.Int("uid", uid), zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	return false
+}
+
+func (h *UploadHandler) respondQuotaExceeded(c *gin.Context, uid int) {
+	resp := gin.H{"error": "Storage quota exceeded", "code": "QUOTA_EXCEEDED"}
+	if usage, err := h.quota.Usage(uid); err == nil {
+		resp["used_bytes"] = usage.UsedBytes
+		resp["quota_bytes"] = usage.QuotaBytes
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, resp)
+}
+
+// respondStoreError 处理文件入库时的业务错误（类型不合规返回 415，超出配额返回 413），返回是否已写入响应
+func (h *UploadHandler) respondStoreError(c *gin.Context, record *models.MinioFile, err error) bool {
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		logger.Logger.Warn("storage quota exceeded", zap.Int("uid", record.Uid), zap.Int64("size", record.FileSize))
+		h.respondQuotaExceeded(c, record.Uid)
+		return true
+	}
+	var ctErr *services.ContentTypeError
+	if !errors.As(err, &ctErr) {
+		return false
+	}
+	logger.Logger.Warn("file content type rejected",
+		zap.String("filename", record.FileName),
+		zap.String("detected_type", ctErr.Detected),
+		zap.String("code", ctErr.Code),
+	)
+	message := "File content type is not allowed"
+	if ctErr.Code == services.CodeFileTypeMismatch {
+		message = "File content does not match its extension"
+	}
+	c.JSON(http.StatusUnsupportedMediaType, gin.H{
+		"error":         message,
+		"code":          ctErr.Code,
+		"detected_type": ctErr.Detected,
 	})
+	return true
+}
+
+// validateFile 校验文件大小与扩展名，失败时已写入响应
+func (h *UploadHandler) validateFile(c *gin.Context, fileName string, size int64) (string, bool) {
+	if size > h.config.Upload.MaxSize {
+		logger.Logger.Warn("file size exceeds limit",
+			zap.String("filename", fileName),
+			zap.Int64("size", size),
+			zap.Int64("max_size", h.config.Upload.MaxSize),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File size exceeds limit of %d bytes", h.config.Upload.MaxSize)})
+		return "", false
+	}
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if !h.isAllowedType(ext) {
+		logger.Logger.Warn("file type not allowed",
+			zap.String("filename", fileName),
+			zap.String("extension", ext),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File type not allowed"})
+		return "", false
+	}
+	return ext, true
 }
 
 func (h *UploadHandler) isAllowedType(ext string) bool {
@@ -136,3 +301,12 @@ func (h *UploadHandler) isAllowedType(ext string) bool {
 	}
 	return false
 }
+
+func contentTypeByExt(ext string) string {
+	if ext != "" {
+		if mt := mime.TypeByExtension(ext); mt != "" {
+			return mt
+		}
+	}
+	return "application/octet-stream"
+}
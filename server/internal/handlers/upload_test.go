@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPNG 只含 PNG 文件头，足以通过类型识别
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+type uploadTest struct {
+	engine *gin.Engine
+	mock   sqlmock.Sqlmock
+	store  *miniotest.Server
+	files  *services.FileService
+}
+
+func newUploadTest(t *testing.T) *uploadTest {
+	mock := newMockDB(t)
+	store, minioSvc := miniotest.Start(t)
+	cfg := &config.Config{Upload: config.UploadConfig{
+		MaxSize:          1 << 20,
+		AllowedTypes:     []string{".png"},
+		AllowedMIMETypes: []string{"image/png"},
+		DedupScope:       config.DedupScopeUser,
+	}}
+	quotaSvc := services.NewQuotaService(config.QuotaConfig{})
+	fileSvc := services.NewFileService(minioSvc, quotaSvc, cfg.Upload)
+	multipartSvc := services.NewMultipartUploadService(minioSvc, config.MultipartConfig{PartSize: 5 << 20})
+	h := NewUploadHandler(cfg, minioSvc, fileSvc, quotaSvc, multipartSvc)
+
+	r := newTestEngine(7)
+	r.POST("/upload/presign", h.PresignUpload)
+	r.POST("/upload/complete", h.CompleteUpload)
+	r.POST("/uploads/multipart/:uploadID/complete", h.CompleteMultipart)
+	return &uploadTest{engine: r, mock: mock, store: store, files: fileSvc}
+}
+
+func (u *uploadTest) post(path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	u.engine.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// expectQuotaCheck 预期读取用户 7 的用量（不限额）
+func expectQuotaCheck(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("INSERT IGNORE INTO user_storage_usage").WithArgs(7, 7).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = \\?$").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "file_count"}).AddRow(0, 0))
+}
+
+// expectNewFile 预期内容为 data 的文件以 scanStatus 登记为记录 id
+func expectNewFile(mock sqlmock.Sqlmock, data []byte, scanStatus string, id int64) {
+	sum := sha256.Sum256(data)
+	sha := hex.EncodeToString(sum[:])
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE uid = \\? AND sha256 = \\?").WithArgs(7, sha).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns))
+	expectQuotaCheck(mock)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT IGNORE INTO user_storage_usage").WithArgs(7, 7).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = \\? FOR UPDATE").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "file_count"}).AddRow(0, 0))
+	mock.ExpectExec("UPDATE user_storage_usage").WithArgs(int64(len(data)), 1, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO file_blobs").WithArgs(miniotest.Bucket, "uid_7/sha256/"+sha, sha, int64(len(data))).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO minio_files").
+		WithArgs(7, "a.png", miniotest.Bucket, "uid_7/sha256/"+sha, "image/png", int64(len(data)), sqlmock.AnyArg(), scanStatus).
+		WillReturnResult(sqlmock.NewResult(id, 1))
+	mock.ExpectCommit()
+}
+
+func TestPresignUpload(t *testing.T) {
+	u := newUploadTest(t)
+
+	expectQuotaCheck(u.mock)
+	w, resp := u.post("/upload/presign", `{"file_name":"../a.png","size":100}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.MethodPut, resp["method"])
+	objectKey := resp["object_key"].(string)
+	assert.True(t, strings.HasPrefix(objectKey, "uid_7/incoming/"), objectKey)
+	assert.True(t, strings.HasSuffix(objectKey, "/a.png"), objectKey)
+	assert.True(t, services.IsIncomingKey(7, objectKey), objectKey)
+
+	uploadURL, err := url.Parse(resp["upload_url"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "/"+miniotest.Bucket+"/"+objectKey, uploadURL.Path)
+	assert.NotEmpty(t, uploadURL.Query().Get("X-Amz-Signature"))
+	assert.Equal(t, "3600", uploadURL.Query().Get("X-Amz-Expires"))
+
+	// 大小与扩展名在签发前校验，不查询配额
+	w, _ = u.post("/upload/presign", `{"file_name":"a.exe","size":100}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = u.post("/upload/presign", `{"file_name":"a.png","size":2097152}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = u.post("/upload/presign", `{"file_name":"a.png"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCompleteUpload(t *testing.T) {
+	u := newUploadTest(t)
+	stagingKey := services.IncomingKey(7, "a.png")
+	u.store.Put(stagingKey, testPNG, "")
+
+	expectNewFile(u.mock, testPNG, models.ScanStatusClean, 12)
+	w, resp := u.post("/upload/complete", `{"object_key":"`+stagingKey+`","file_name":"a.png"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(12), resp["id"])
+	assert.Equal(t, false, resp["duplicate"])
+	assert.NotEmpty(t, resp["url"])
+
+	// 直传对象转存到内容寻址键后被删除
+	sum := sha256.Sum256(testPNG)
+	assert.Equal(t, []string{"uid_7/sha256/" + hex.EncodeToString(sum[:])}, u.store.Keys())
+
+	// 重复 complete 时直传对象已不存在
+	w, _ = u.post("/upload/complete", `{"object_key":"`+stagingKey+`","file_name":"a.png"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCompleteUploadQuarantined(t *testing.T) {
+	u := newUploadTest(t)
+	u.files.QuarantineUntilScanned()
+	sum := sha256.Sum256(testPNG)
+	sha := hex.EncodeToString(sum[:])
+
+	// 新文件待扫描，不签发下载链接
+	stagingKey := services.IncomingKey(7, "a.png")
+	u.store.Put(stagingKey, testPNG, "")
+	expectNewFile(u.mock, testPNG, models.ScanStatusPending, 12)
+	w, resp := u.post("/upload/complete", `{"object_key":"`+stagingKey+`","file_name":"a.png"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, models.ScanStatusPending, resp["scan_status"])
+	assert.NotContains(t, resp, "url")
+	assert.NotContains(t, resp, "url_expires_at")
+
+	// 重复上传命中已判定感染的内容
+	stagingKey = services.IncomingKey(7, "a.png")
+	u.store.Put(stagingKey, testPNG, "")
+	u.mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE uid = \\? AND sha256 = \\?").WithArgs(7, sha).
+		WillReturnRows(fileRows(models.MinioFile{ID: 12, Uid: 7, FileName: "a.png", Bucket: miniotest.Bucket,
+			ObjectKey: "uid_7/sha256/" + sha, ContentType: "image/png", SHA256: sha, ScanStatus: models.ScanStatusInfected}))
+	w, resp = u.post("/upload/complete", `{"object_key":"`+stagingKey+`","file_name":"a.png"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, true, resp["duplicate"])
+	assert.Equal(t, models.ScanStatusInfected, resp["scan_status"])
+	assert.NotContains(t, resp, "url")
+	assert.NotContains(t, resp, "url_expires_at")
+}
+
+func TestCompleteUploadRejects(t *testing.T) {
+	u := newUploadTest(t)
+
+	// 只能登记为自己签发的暂存对象
+	w, _ := u.post("/upload/complete", `{"object_key":"`+services.IncomingKey(8, "a.png")+`","file_name":"a.png"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w, _ = u.post("/upload/complete", `{"object_key":"uid_7/incoming/../../uid_8/a.png","file_name":"a.png"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 扩展名不合规的直传对象被删除
+	exeKey := services.IncomingKey(7, "a.exe")
+	u.store.Put(exeKey, testPNG, "")
+	w, _ = u.post("/upload/complete", `{"object_key":"`+exeKey+`","file_name":"a.exe"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 识别出的类型不在白名单中时返回 415 并删除对象
+	badKey := services.IncomingKey(7, "b.png")
+	u.store.Put(badKey, []byte("MZ\x90\x00\x03\x00\x00\x00"), "")
+	w, resp := u.post("/upload/complete", `{"object_key":"`+badKey+`","file_name":"b.png"}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Equal(t, services.CodeFileTypeNotAllowed, resp["code"])
+
+	assert.Empty(t, u.store.Keys())
+}
+
+func TestCompleteUploadKeepsStoredObjects(t *testing.T) {
+	u := newUploadTest(t)
+	sum := sha256.Sum256(testPNG)
+	sha := hex.EncodeToString(sum[:])
+
+	// 用户自己的内容对象、衍生文件、全局内容对象与历史对象都不能当作暂存对象登记
+	keys := []string{
+		"uid_7/sha256/" + sha,
+		"uid_7/sha256/" + sha + ".derived/thumb_256.jpg",
+		"sha256/" + sha[:2] + "/" + sha,
+		"uid_7/a.png",
+		"uid_7/incoming/" + sha + "/a.png",
+		"uid_7/incoming/5d0c7b8e-6a4f-4c53-9a55-1f7f3c1f2a10/sha256/a.png",
+	}
+	for _, key := range keys {
+		u.store.Put(key, testPNG, "")
+		w, _ := u.post("/upload/complete", `{"object_key":"`+key+`","file_name":"a.png"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code, key)
+	}
+	assert.ElementsMatch(t, keys, u.store.Keys())
+}
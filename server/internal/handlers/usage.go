@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UsageHandler 用户存储用量处理器
+type UsageHandler struct {
+	quota *services.QuotaService
+}
+
+// NewUsageHandler 创建新的用量处理器
+func NewUsageHandler(quotaSvc *services.QuotaService) *UsageHandler {
+	return &UsageHandler{quota: quotaSvc}
+}
+
+// Usage 返回当前用户已用空间、文件数与配额
+func (h *UsageHandler) Usage(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+
+	usage, err := h.quota.Usage(user.Uid)
+	if err != nil {
+		logger.Logger.Error("failed to get storage usage", zap.Int("uid", user.Uid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
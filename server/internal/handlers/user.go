@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/middleware"
 	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -11,7 +14,8 @@ import (
 
 // UserHandler 用户处理器
 type UserHandler struct {
-	userService *services.UserService
+	userService    *services.UserService
+	sessionService *services.SessionService
 }
 
 // RegisterRequest 注册请求
@@ -32,17 +36,34 @@ type RegisterResponse struct {
 	Username string `json:"username"`
 }
 
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Message  string `json:"message"`
-	Username string `json:"username"`
-	Token    string `json:"token"`
+	Message          string    `json:"message"`
+	Username         string    `json:"username"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// TokenResponse 刷新令牌响应
+type TokenResponse struct {
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 // NewUserHandler 创建新的用户处理器
-func NewUserHandler() *UserHandler {
+func NewUserHandler(sessionService *services.SessionService) *UserHandler {
 	return &UserHandler{
-		userService: services.NewUserService(),
+		userService:    services.NewUserService(),
+		sessionService: sessionService,
 	}
 }
 
@@ -100,16 +121,96 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 生成简单token（实际项目中应使用JWT或其他安全的token机制）
-	token := "token_" + user.Username
+	// 开启新会话，签发访问令牌和刷新令牌
+	pair, err := h.sessionService.CreateSession(user.Uid)
+	if err != nil {
+		logger.Logger.Error("failed to create session",
+			zap.Int("user_id", user.Uid),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
 
 	logger.Logger.Info("user logged in successfully",
 		zap.String("username", req.Username),
 		zap.Int("user_id", user.Uid))
 
 	c.JSON(http.StatusOK, LoginResponse{
-		Message:  "Login successful",
-		Username: user.Username,
-		Token:    token,
+		Message:          "Login successful",
+		Username:         user.Username,
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	})
+}
+
+// Refresh 使用刷新令牌换取新的令牌对
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Logger.Error("failed to parse refresh request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	pair, err := h.sessionService.Refresh(req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			logger.Logger.Warn("refresh token reuse detected, session revoked")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Refresh token reused, session revoked",
+			})
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid refresh token",
+			})
+		default:
+			logger.Logger.Error("failed to refresh token", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Internal server error",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	})
+}
+
+// Logout 注销当前会话
+func (h *UserHandler) Logout(c *gin.Context) {
+	user := middleware.MustCurrentUser(c)
+	claims, ok := middleware.CurrentClaims(c)
+	if !ok || claims.SessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Token is not bound to a session",
+		})
+		return
+	}
+
+	if err := h.sessionService.Revoke(claims.SessionID); err != nil {
+		logger.Logger.Error("failed to revoke session",
+			zap.Int("user_id", user.Uid),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+		return
+	}
+
+	logger.Logger.Info("user logged out", zap.Int("user_id", user.Uid))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logout successful",
 	})
 }
@@ -0,0 +1,84 @@
+// Package jobs 提供基于 MySQL 的后台任务队列：入队、带可见性超时的租约、指数退避重试与死信，
+// 以及在进程退出时可优雅排空的 worker 池。
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 任务状态
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	// StatusDead 超过最大尝试次数或永久失败，进入死信，不再自动重试
+	StatusDead = "dead"
+)
+
+// Job 一条后台任务
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Decode 将任务参数解析到 v，解析失败视为永久错误
+func (j *Job) Decode(v interface{}) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return Permanent(fmt.Errorf("invalid payload for job %d: %w", j.ID, err))
+	}
+	return nil
+}
+
+// LastAttempt 判断本次执行返回 err 后任务是否不再重试（永久错误或已用尽尝试次数），
+// 处理器可据此只在最终失败时记录失败状态
+func (j *Job) LastAttempt(err error) bool {
+	return IsPermanent(err) || j.Attempts >= j.MaxAttempts
+}
+
+// Handler 处理一种类型的任务，返回错误时按退避策略重试
+type Handler func(ctx context.Context, job *Job) error
+
+// Store 任务持久化，Lease 之后的状态变更均需校验租约持有者
+type Store interface {
+	Enqueue(ctx context.Context, job *Job) error
+	// Lease 取出一条可执行任务（到期的排队任务或租约已过期的运行中任务），
+	// 尝试次数加一并将租约延长到 until；没有任务时返回 nil
+	Lease(ctx context.Context, owner string, now, until time.Time) (*Job, error)
+	// Extend 延长仍在执行的任务的租约
+	Extend(ctx context.Context, id int64, owner string, until time.Time) error
+	Complete(ctx context.Context, id int64, owner string) error
+	// Retry 释放租约，任务在 runAt 之后重新可执行
+	Retry(ctx context.Context, id int64, owner string, runAt time.Time, lastError string) error
+	// Bury 将任务移入死信
+	Bury(ctx context.Context, id int64, owner string, lastError string) error
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 包装不应重试的错误，任务会直接进入死信
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 判断错误是否为永久错误
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLeaseLost 任务租约已过期并被其它 worker 取走
+var ErrLeaseLost = errors.New("job lease lost")
+
+const jobColumns = "id, type, payload, status, attempts, max_attempts, run_at, last_error, created_at"
+
+// MySQLStore 基于 jobs 表的任务存储，使用 FOR UPDATE SKIP LOCKED 保证多 worker 间不重复领取
+type MySQLStore struct {
+	db *sql.DB
+}
+
+// NewMySQLStore 创建 MySQL 任务存储
+func NewMySQLStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{db: db}
+}
+
+func (s *MySQLStore) Enqueue(ctx context.Context, job *Job) error {
+	query := "INSERT INTO jobs (type, payload, status, max_attempts, run_at) VALUES (?, ?, ?, ?, ?)"
+	res, err := s.db.ExecContext(ctx, query, job.Type, []byte(job.Payload), StatusQueued, job.MaxAttempts, job.RunAt)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	if job.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get job id: %w", err)
+	}
+	job.Status = StatusQueued
+	return nil
+}
+
+func (s *MySQLStore) Lease(ctx context.Context, owner string, now, until time.Time) (*Job, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + jobColumns + ` FROM jobs
+		WHERE (status = ? AND run_at <= ?) OR (status = ? AND leased_until < ?)
+		ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED`
+	job := &Job{}
+	var payload []byte
+	err = tx.QueryRowContext(ctx, query, StatusQueued, now, StatusRunning, now).Scan(
+		&job.ID, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt, &job.LastError, &job.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lease job: %w", err)
+	}
+	job.Payload = payload
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE jobs SET status = ?, attempts = attempts + 1, lease_owner = ?, leased_until = ? WHERE id = ?",
+		StatusRunning, owner, until, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease job: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit job lease: %w", err)
+	}
+	job.Status = StatusRunning
+	job.Attempts++
+	return job, nil
+}
+
+func (s *MySQLStore) Extend(ctx context.Context, id int64, owner string, until time.Time) error {
+	return s.update(ctx, "UPDATE jobs SET leased_until = ? WHERE id = ? AND lease_owner = ? AND status = ?",
+		until, id, owner, StatusRunning)
+}
+
+func (s *MySQLStore) Complete(ctx context.Context, id int64, owner string) error {
+	return s.update(ctx, "UPDATE jobs SET status = ?, leased_until = NULL, last_error = '' WHERE id = ? AND lease_owner = ? AND status = ?",
+		StatusSucceeded, id, owner, StatusRunning)
+}
+
+func (s *MySQLStore) Retry(ctx context.Context, id int64, owner string, runAt time.Time, lastError string) error {
+	return s.update(ctx, "UPDATE jobs SET status = ?, run_at = ?, leased_until = NULL, last_error = ? WHERE id = ? AND lease_owner = ? AND status = ?",
+		StatusQueued, runAt, truncate(lastError, 1024), id, owner, StatusRunning)
+}
+
+func (s *MySQLStore) Bury(ctx context.Context, id int64, owner string, lastError string) error {
+	return s.update(ctx, "UPDATE jobs SET status = ?, leased_until = NULL, last_error = ? WHERE id = ? AND lease_owner = ? AND status = ?",
+		StatusDead, truncate(lastError, 1024), id, owner, StatusRunning)
+}
+
+// update 执行带租约校验的状态变更，未命中时返回 ErrLeaseLost
+func (s *MySQLStore) update(ctx context.Context, query string, args ...interface{}) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,250 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Pool 后台任务 worker 池，同时负责入队
+type Pool struct {
+	store    Store
+	cfg      config.JobsConfig
+	owner    string
+	handlers map[string]Handler
+	now      func() time.Time
+	// backoff 返回第 attempt 次失败后的重试间隔
+	backoff func(attempt int) time.Duration
+
+	mu      sync.Mutex
+	started bool
+	stop    chan struct{}
+	// cancel 取消正在执行的任务，排空超时后调用
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool 创建任务池，需在 Start 之前通过 Register 注册处理器
+func NewPool(store Store, cfg config.JobsConfig) *Pool {
+	host, _ := os.Hostname()
+	p := &Pool{
+		store:    store,
+		cfg:      cfg,
+		owner:    fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8]),
+		handlers: make(map[string]Handler),
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+	p.backoff = p.exponentialBackoff
+	return p
+}
+
+// Register 注册任务处理器
+func (p *Pool) Register(jobType string, h Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[jobType] = h
+}
+
+// Enqueue 入队一条任务，payload 序列化为 JSON
+func (p *Pool) Enqueue(ctx context.Context, jobType string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+	job := &Job{
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: p.cfg.MaxAttempts,
+		RunAt:       p.now(),
+	}
+	if err := p.store.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Start 启动 cfg.Workers 个 worker
+func (p *Pool) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	for i := 0; i < p.cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+	logger.Logger.Info("job workers started", zap.Int("workers", p.cfg.Workers), zap.String("owner", p.owner))
+}
+
+// Shutdown 停止领取新任务并等待执行中的任务完成；ctx 到期时取消执行中的任务，
+// 被取消的任务会释放租约以便重启后继续执行
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.started {
+		p.mu.Unlock()
+		return nil
+	}
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+
+		job, err := p.store.Lease(ctx, p.owner, p.now(), p.now().Add(p.cfg.VisibilityTimeout))
+		if err != nil {
+			logger.Logger.Error("failed to lease job", zap.Error(err))
+		}
+		if job == nil {
+			select {
+			case <-p.stop:
+				return
+			case <-time.After(p.cfg.PollInterval):
+			}
+			continue
+		}
+		p.run(ctx, job)
+	}
+}
+
+// run 执行任务并按结果完成、重试或移入死信
+func (p *Pool) run(ctx context.Context, job *Job) {
+	log := logger.Logger.With(zap.Int64("job_id", job.ID), zap.String("type", job.Type), zap.Int("attempt", job.Attempts))
+
+	// 租约过期被重新领取的任务也计入尝试次数，避免反复崩溃的任务无限执行
+	if job.Attempts > job.MaxAttempts {
+		p.finish(log, p.store.Bury(ctx, job.ID, p.owner, "max attempts exceeded: "+job.LastError))
+		log.Warn("job moved to dead letter")
+		return
+	}
+
+	p.mu.Lock()
+	h, ok := p.handlers[job.Type]
+	p.mu.Unlock()
+	if !ok {
+		p.finish(log, p.store.Bury(ctx, job.ID, p.owner, "no handler registered for job type"))
+		log.Error("no handler registered for job type")
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopHeartbeat := p.heartbeat(jobCtx, job)
+	err := safeCall(jobCtx, h, job)
+	stopHeartbeat()
+
+	switch {
+	case err == nil:
+		p.finish(log, p.store.Complete(context.Background(), job.ID, p.owner))
+	case ctx.Err() != nil:
+		// 排空超时被取消，立即释放租约，不等待退避
+		p.finish(log, p.store.Retry(context.Background(), job.ID, p.owner, p.now(), "interrupted by shutdown: "+err.Error()))
+		log.Warn("job interrupted by shutdown", zap.Error(err))
+	case job.LastAttempt(err):
+		p.finish(log, p.store.Bury(context.Background(), job.ID, p.owner, err.Error()))
+		log.Error("job failed permanently, moved to dead letter", zap.Error(err))
+	default:
+		delay := p.backoff(job.Attempts)
+		p.finish(log, p.store.Retry(context.Background(), job.ID, p.owner, p.now().Add(delay), err.Error()))
+		log.Warn("job failed, will retry", zap.Duration("delay", delay), zap.Error(err))
+	}
+}
+
+// heartbeat 定期延长租约，防止长任务超过可见性超时被其它 worker 重复领取
+func (p *Pool) heartbeat(ctx context.Context, job *Job) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(p.cfg.VisibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.store.Extend(ctx, job.ID, p.owner, p.now().Add(p.cfg.VisibilityTimeout)); err != nil {
+					logger.Logger.Warn("failed to extend job lease", zap.Int64("job_id", job.ID), zap.Error(err))
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (p *Pool) finish(log *zap.Logger, err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, ErrLeaseLost) {
+		log.Warn("job lease lost before completion")
+		return
+	}
+	log.Error("failed to update job state", zap.Error(err))
+}
+
+func (p *Pool) exponentialBackoff(attempt int) time.Duration {
+	d := p.cfg.BackoffBase
+	for i := 1; i < attempt && d < p.cfg.BackoffMax; i++ {
+		d *= 2
+	}
+	if d > p.cfg.BackoffMax {
+		d = p.cfg.BackoffMax
+	}
+	return d
+}
+
+// safeCall 执行处理器，panic 视为普通失败
+func safeCall(ctx context.Context, h Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panic: %v", r)
+		}
+	}()
+	return h(ctx, job)
+}
@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memoryStore 内存实现的 Store，语义与 MySQLStore 一致
+type memoryStore struct {
+	mu     sync.Mutex
+	nextID int64
+	jobs   map[int64]*memJob
+}
+
+type memJob struct {
+	Job
+	owner       string
+	leasedUntil time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{jobs: make(map[int64]*memJob)}
+}
+
+func (s *memoryStore) Enqueue(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	job.ID = s.nextID
+	job.Status = StatusQueued
+	s.jobs[job.ID] = &memJob{Job: *job}
+	return nil
+}
+
+func (s *memoryStore) Lease(ctx context.Context, owner string, now, until time.Time) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := int64(1); id <= s.nextID; id++ {
+		j := s.jobs[id]
+		if (j.Status == StatusQueued && !j.RunAt.After(now)) || (j.Status == StatusRunning && j.leasedUntil.Before(now)) {
+			j.Status = StatusRunning
+			j.Attempts++
+			j.owner = owner
+			j.leasedUntil = until
+			leased := j.Job
+			return &leased, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) mutate(id int64, owner string, fn func(j *memJob)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.jobs[id]
+	if j == nil || j.owner != owner || j.Status != StatusRunning {
+		return ErrLeaseLost
+	}
+	fn(j)
+	return nil
+}
+
+func (s *memoryStore) Extend(ctx context.Context, id int64, owner string, until time.Time) error {
+	return s.mutate(id, owner, func(j *memJob) { j.leasedUntil = until })
+}
+
+func (s *memoryStore) Complete(ctx context.Context, id int64, owner string) error {
+	return s.mutate(id, owner, func(j *memJob) { j.Status = StatusSucceeded })
+}
+
+func (s *memoryStore) Retry(ctx context.Context, id int64, owner string, runAt time.Time, lastError string) error {
+	return s.mutate(id, owner, func(j *memJob) { j.Status, j.RunAt, j.LastError = StatusQueued, runAt, lastError })
+}
+
+func (s *memoryStore) Bury(ctx context.Context, id int64, owner string, lastError string) error {
+	return s.mutate(id, owner, func(j *memJob) { j.Status, j.LastError = StatusDead, lastError })
+}
+
+func (s *memoryStore) get(id int64) Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id].Job
+}
+
+func newTestPool(t *testing.T, store Store) *Pool {
+	logger.Logger = zap.NewNop()
+	p := NewPool(store, config.JobsConfig{
+		Workers:           2,
+		PollInterval:      5 * time.Millisecond,
+		VisibilityTimeout: time.Minute,
+		MaxAttempts:       3,
+		BackoffBase:       time.Millisecond,
+		BackoffMax:        4 * time.Millisecond,
+	})
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return p
+}
+
+func waitStatus(t *testing.T, store *memoryStore, id int64, status string) Job {
+	t.Helper()
+	require.Eventually(t, func() bool { return store.get(id).Status == status }, 2*time.Second, 5*time.Millisecond)
+	return store.get(id)
+}
+
+func TestPoolRunsJob(t *testing.T) {
+	store := newMemoryStore()
+	p := newTestPool(t, store)
+
+	got := make(chan int64, 1)
+	p.Register("echo", func(ctx context.Context, job *Job) error {
+		var payload struct{ FileID int64 }
+		if err := job.Decode(&payload); err != nil {
+			return err
+		}
+		got <- payload.FileID
+		return nil
+	})
+	job, err := p.Enqueue(context.Background(), "echo", map[string]int64{"FileID": 42})
+	require.NoError(t, err)
+	p.Start()
+
+	assert.Equal(t, int64(42), <-got)
+	done := waitStatus(t, store, job.ID, StatusSucceeded)
+	assert.Equal(t, 1, done.Attempts)
+}
+
+func TestPoolRetriesWithBackoff(t *testing.T) {
+	store := newMemoryStore()
+	p := newTestPool(t, store)
+
+	var mu sync.Mutex
+	calls := 0
+	p.Register("flaky", func(ctx context.Context, job *Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	job, err := p.Enqueue(context.Background(), "flaky", nil)
+	require.NoError(t, err)
+	p.Start()
+
+	done := waitStatus(t, store, job.ID, StatusSucceeded)
+	assert.Equal(t, 3, done.Attempts)
+}
+
+func TestPoolDeadLetters(t *testing.T) {
+	store := newMemoryStore()
+	p := newTestPool(t, store)
+
+	p.Register("broken", func(ctx context.Context, job *Job) error { return errors.New("boom") })
+	p.Register("invalid", func(ctx context.Context, job *Job) error { return Permanent(errors.New("bad input")) })
+	broken, err := p.Enqueue(context.Background(), "broken", nil)
+	require.NoError(t, err)
+	invalid, err := p.Enqueue(context.Background(), "invalid", nil)
+	require.NoError(t, err)
+	unknown, err := p.Enqueue(context.Background(), "unknown", nil)
+	require.NoError(t, err)
+	p.Start()
+
+	dead := waitStatus(t, store, broken.ID, StatusDead)
+	assert.Equal(t, 3, dead.Attempts)
+	assert.Equal(t, "boom", dead.LastError)
+
+	dead = waitStatus(t, store, invalid.ID, StatusDead)
+	assert.Equal(t, 1, dead.Attempts)
+
+	waitStatus(t, store, unknown.ID, StatusDead)
+}
+
+func TestPoolReclaimsExpiredLease(t *testing.T) {
+	store := newMemoryStore()
+	p := newTestPool(t, store)
+
+	job, err := p.Enqueue(context.Background(), "noop", nil)
+	require.NoError(t, err)
+	// 模拟其它 worker 领取后崩溃，租约已过期
+	_, err = store.Lease(context.Background(), "crashed", time.Now(), time.Now().Add(-time.Second))
+	require.NoError(t, err)
+
+	p.Register("noop", func(ctx context.Context, job *Job) error { return nil })
+	p.Start()
+
+	done := waitStatus(t, store, job.ID, StatusSucceeded)
+	assert.Equal(t, 2, done.Attempts)
+}
+
+func TestShutdownDrainsRunningJobs(t *testing.T) {
+	store := newMemoryStore()
+	p := newTestPool(t, store)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	p.Register("slow", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-release
+		return nil
+	})
+	job, err := p.Enqueue(context.Background(), "slow", nil)
+	require.NoError(t, err)
+	p.Start()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- p.Shutdown(context.Background()) }()
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown returned before running job finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-shutdown)
+	assert.Equal(t, StatusSucceeded, store.get(job.ID).Status)
+}
+
+func TestShutdownTimeoutReleasesLease(t *testing.T) {
+	store := newMemoryStore()
+	p := newTestPool(t, store)
+
+	started := make(chan struct{})
+	p.Register("stuck", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	job, err := p.Enqueue(context.Background(), "stuck", nil)
+	require.NoError(t, err)
+	p.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+	// 被中断的任务回到队列，重启后可立即执行
+	assert.Equal(t, StatusQueued, store.get(job.ID).Status)
+}
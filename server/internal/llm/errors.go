@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+var (
+	// ErrNotConfigured 未配置模型服务的 API Key
+	ErrNotConfigured = errors.New("llm: provider is not configured")
+	// ErrEmptyResponse 模型服务返回的结果中没有任何候选回复
+	ErrEmptyResponse = errors.New("llm: empty response")
+	// ErrIncompleteStream 流式响应在结束标记之前断开
+	ErrIncompleteStream = errors.New("llm: stream ended before completion")
+)
+
+// APIError 模型服务返回的错误响应
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("llm: api error %d (%s): %s", e.StatusCode, e.Code, msg)
+	}
+	return fmt.Sprintf("llm: api error %d: %s", e.StatusCode, msg)
+}
+
+// RateLimited 请求被限流
+func (e *APIError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// ServerError 模型服务内部错误
+func (e *APIError) ServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// IsTimeout 判断错误是否由请求超时引起
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeReply Fake 的一次预设回复；流式调用时先以一段增量输出 Response.Content，再返回 Err
+type FakeReply struct {
+	Response *Response
+	Err      error
+}
+
+// Fake 内存中的提供方，按顺序返回预设回复并记录收到的请求，供测试使用；回复用完后重复最后一条
+type Fake struct {
+	mu       sync.Mutex
+	replies  []FakeReply
+	requests []Request
+}
+
+// NewFake 创建按顺序返回 replies 的提供方
+func NewFake(replies ...FakeReply) *Fake {
+	return &Fake{replies: replies}
+}
+
+func (f *Fake) next(req *Request) FakeReply {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, *req)
+	if len(f.replies) == 0 {
+		return FakeReply{Response: &Response{}}
+	}
+	reply := f.replies[0]
+	if len(f.replies) > 1 {
+		f.replies = f.replies[1:]
+	}
+	return reply
+}
+
+func (f *Fake) Chat(ctx context.Context, req *Request) (*Response, error) {
+	reply := f.next(req)
+	if reply.Err != nil {
+		return nil, reply.Err
+	}
+	resp := *reply.Response
+	return &resp, nil
+}
+
+func (f *Fake) ChatStream(ctx context.Context, req *Request, onDelta func(delta string) error) (*Response, error) {
+	reply := f.next(req)
+	if reply.Response != nil && reply.Response.Content != "" {
+		if err := onDelta(reply.Response.Content); err != nil {
+			return nil, err
+		}
+	}
+	if reply.Err != nil {
+		return nil, reply.Err
+	}
+	resp := *reply.Response
+	return &resp, nil
+}
+
+// Requests 返回已收到的请求
+func (f *Fake) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request(nil), f.requests...)
+}
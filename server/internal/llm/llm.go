@@ -0,0 +1,134 @@
+// Package llm 大模型调用，按 OpenAI Chat Completions 协议与模型服务通信
+package llm
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// 消息角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	// RoleTool 工具调用结果
+	RoleTool = "tool"
+)
+
+// 内容片段类型，与 OpenAI Chat Completions 的多模态消息格式一致
+const (
+	ContentPartText     = "text"
+	ContentPartImageURL = "image_url"
+)
+
+// ContentPart 多模态消息的内容片段
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL 图片片段的地址，模型服务通过该地址拉取图片
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// Message 对话中的一条消息，Parts 非空时以内容片段数组发送并忽略 Content
+type Message struct {
+	Role    string
+	Content string
+	Parts   []ContentPart
+	// ToolCalls 模型请求的工具调用，仅 assistant 消息
+	ToolCalls []ToolCall
+	// ToolCallID 对应的工具调用ID，仅 tool 消息
+	ToolCallID string
+}
+
+// MarshalJSON 按协议将 content 编码为字符串或内容片段数组，只含工具调用的 assistant 消息 content 为 null
+func (m Message) MarshalJSON() ([]byte, error) {
+	wire := struct {
+		Role       string      `json:"role"`
+		Content    interface{} `json:"content"`
+		ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+		ToolCallID string      `json:"tool_call_id,omitempty"`
+	}{Role: m.Role, Content: m.Content, ToolCalls: m.ToolCalls, ToolCallID: m.ToolCallID}
+	if len(m.Parts) > 0 {
+		wire.Content = m.Parts
+	} else if m.Content == "" && len(m.ToolCalls) > 0 {
+		wire.Content = nil
+	}
+	return json.Marshal(wire)
+}
+
+// ToolTypeFunction 目前协议中唯一的工具类型
+const ToolTypeFunction = "function"
+
+// ToolCall 模型请求的一次函数调用
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall 函数名与 JSON 编码的参数
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolDefinition 提供给模型的工具声明
+type ToolDefinition struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition 函数名、用途说明与参数的 JSON Schema
+type FunctionDefinition struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters"`
+}
+
+// Request 一次对话补全请求
+type Request struct {
+	// Model 为空时使用提供方的默认模型
+	Model       string
+	Messages    []Message
+	Temperature float64
+	// MaxTokens 为 0 时使用提供方的默认值
+	MaxTokens int
+	// Tools 允许模型调用的工具，为空时不启用函数调用
+	Tools []ToolDefinition
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Response 模型的完整回复
+type Response struct {
+	Content string
+	// Model 经 Router 路由时为请求的白名单模型名，否则为提供方返回的模型ID
+	Model string
+	// ProviderModel 提供方实际使用的模型ID，由 Router 填写
+	ProviderModel string
+	// Provider 生成回复的提供方名称
+	Provider     string
+	FinishReason string
+	// ToolCalls 模型请求的工具调用，非空时 Content 通常为空
+	ToolCalls []ToolCall
+	// Usage 提供方未返回用量时为 nil
+	Usage *Usage
+}
+
+// Provider 大模型提供方
+type Provider interface {
+	// Chat 生成完整回复
+	Chat(ctx context.Context, req *Request) (*Response, error)
+	// ChatStream 流式生成回复，每收到一段增量文本调用一次 onDelta，onDelta 返回错误时中止；
+	// 返回的 Response 包含完整回复与用量
+	ChatStream(ctx context.Context, req *Request, onDelta func(delta string) error) (*Response, error)
+}
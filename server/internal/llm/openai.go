@@ -0,0 +1,294 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+)
+
+// OpenAIClient OpenAI 兼容的 Chat Completions 客户端（七牛 AI 推理服务等）
+type OpenAIClient struct {
+	name      string
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+	timeout   time.Duration
+	client    *http.Client
+}
+
+// NewOpenAIClient 创建新的 OpenAI 兼容客户端，httpClient 为 nil 时使用按 cfg.Timeout 等待响应头的默认客户端。
+// 非流式请求整体受 cfg.Timeout 限制，流式请求的生命周期由调用方的上下文控制。
+func NewOpenAIClient(cfg config.LLMProviderConfig, httpClient *http.Client) *OpenAIClient {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: cfg.Timeout,
+			},
+		}
+	}
+	return &OpenAIClient{
+		name:      cfg.Name,
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:    cfg.APIKey,
+		model:     cfg.Model,
+		maxTokens: cfg.MaxTokens,
+		timeout:   cfg.Timeout,
+		client:    httpClient,
+	}
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatCompletionRequest struct {
+	Model         string           `json:"model"`
+	Messages      []Message        `json:"messages"`
+	Temperature   float64          `json:"temperature"`
+	MaxTokens     int              `json:"max_tokens,omitempty"`
+	Tools         []ToolDefinition `json:"tools,omitempty"`
+	Stream        bool             `json:"stream,omitempty"`
+	StreamOptions *streamOptions   `json:"stream_options,omitempty"`
+}
+
+type chatCompletionChoice struct {
+	Message struct {
+		Content   string     `json:"content"`
+		ToolCalls []ToolCall `json:"tool_calls"`
+	} `json:"message"`
+	Delta struct {
+		Content   string          `json:"content"`
+		ToolCalls []toolCallDelta `json:"tool_calls"`
+	} `json:"delta"`
+	FinishReason string `json:"finish_reason"`
+}
+
+// toolCallDelta 流式响应中的工具调用片段，同一调用的片段通过 Index 关联，参数分多段到达
+type toolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatCompletionResponse struct {
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+	Usage   *Usage                 `json:"usage"`
+	Error   *apiErrorBody          `json:"error"`
+}
+
+type apiErrorBody struct {
+	Message string          `json:"message"`
+	Type    string          `json:"type"`
+	Code    json.RawMessage `json:"code"`
+}
+
+func (b *apiErrorBody) toError(status int) *APIError {
+	// code 在不同实现中可能是字符串、数字或 null
+	code := strings.Trim(string(b.Code), `"`)
+	if code == "null" {
+		code = ""
+	}
+	return &APIError{StatusCode: status, Type: b.Type, Code: code, Message: b.Message}
+}
+
+// Chat 生成完整回复
+func (c *OpenAIClient) Chat(ctx context.Context, req *Request) (*Response, error) {
+	if c.apiKey == "" {
+		return nil, ErrNotConfigured
+	}
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	resp, err := c.do(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("llm: failed to decode response: %w", err)
+	}
+	if body.Error != nil {
+		return nil, body.Error.toError(resp.StatusCode)
+	}
+	if len(body.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+	return &Response{
+		Content:      body.Choices[0].Message.Content,
+		Model:        body.Model,
+		Provider:     c.name,
+		FinishReason: body.Choices[0].FinishReason,
+		ToolCalls:    body.Choices[0].Message.ToolCalls,
+		Usage:        body.Usage,
+	}, nil
+}
+
+// ChatStream 流式生成回复，按 SSE 逐条解析增量文本
+func (c *OpenAIClient) ChatStream(ctx context.Context, req *Request, onDelta func(delta string) error) (*Response, error) {
+	if c.apiKey == "" {
+		return nil, ErrNotConfigured
+	}
+
+	resp, err := c.do(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &Response{Provider: c.name}
+	var content strings.Builder
+	var calls []ToolCall
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk chatCompletionResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("llm: failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return nil, chunk.Error.toError(resp.StatusCode)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.Usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				result.FinishReason = choice.FinishReason
+			}
+			calls = mergeToolCallDeltas(calls, choice.Delta.ToolCalls)
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("llm: failed to read stream: %w", err)
+	}
+	// 部分实现不发送 [DONE]，以收到结束原因为准
+	if !done && result.FinishReason == "" {
+		return nil, ErrIncompleteStream
+	}
+	result.Content = content.String()
+	result.ToolCalls = calls
+	return result, nil
+}
+
+// mergeToolCallDeltas 按 Index 拼接工具调用片段
+func mergeToolCallDeltas(calls []ToolCall, deltas []toolCallDelta) []ToolCall {
+	for _, d := range deltas {
+		if d.Index < 0 {
+			continue
+		}
+		for len(calls) <= d.Index {
+			calls = append(calls, ToolCall{Type: ToolTypeFunction})
+		}
+		call := &calls[d.Index]
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Type != "" {
+			call.Type = d.Type
+		}
+		call.Function.Name += d.Function.Name
+		call.Function.Arguments += d.Function.Arguments
+	}
+	return calls
+}
+
+// do 发送请求，非 2xx 响应解析为 *APIError
+func (c *OpenAIClient) do(ctx context.Context, req *Request, stream bool) (*http.Response, error) {
+	payload := chatCompletionRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Tools:       req.Tools,
+	}
+	if payload.Model == "" {
+		payload.Model = c.model
+	}
+	if payload.MaxTokens == 0 {
+		payload.MaxTokens = c.maxTokens
+	}
+	if stream {
+		payload.Stream = true
+		payload.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("llm: failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("llm: failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("llm: request failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, readAPIError(resp)
+	}
+	return resp, nil
+}
+
+// readAPIError 解析错误响应体，非 JSON 时以响应体前若干字节作为错误信息
+func readAPIError(resp *http.Response) *APIError {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Error *apiErrorBody `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &body); err == nil && body.Error != nil {
+		return body.Error.toError(resp.StatusCode)
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(raw))}
+}
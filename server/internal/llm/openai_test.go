@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *OpenAIClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewOpenAIClient(config.LLMProviderConfig{
+		Name:      "stub",
+		BaseURL:   srv.URL + "/v1/",
+		APIKey:    "test-key",
+		Model:     "default-model",
+		MaxTokens: 100,
+		Timeout:   time.Second,
+	}, nil)
+}
+
+func TestChat(t *testing.T) {
+	var got map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		fmt.Fprint(w, `{"model":"default-model","choices":[{"message":{"role":"assistant","content":"你好！"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`)
+	})
+
+	resp, err := c.Chat(context.Background(), &Request{
+		Messages: []Message{
+			{Role: RoleUser, Content: "你好"},
+			{Role: RoleUser, Content: "看图", Parts: []ContentPart{
+				{Type: ContentPartText, Text: "看图"},
+				{Type: ContentPartImageURL, ImageURL: &ImageURL{URL: "http://minio/a.png"}},
+			}},
+		},
+		Temperature: 0.7,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "你好！", resp.Content)
+	assert.Equal(t, "default-model", resp.Model)
+	assert.Equal(t, "stub", resp.Provider)
+	assert.Equal(t, "stop", resp.FinishReason)
+	assert.Equal(t, &Usage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}, resp.Usage)
+
+	// 未指定模型与 max_tokens 时使用配置的默认值；带内容片段的消息以数组发送
+	assert.Equal(t, "default-model", got["model"])
+	assert.Equal(t, float64(100), got["max_tokens"])
+	assert.Nil(t, got["stream"])
+	messages := got["messages"].([]interface{})
+	assert.Equal(t, "你好", messages[0].(map[string]interface{})["content"])
+	parts := messages[1].(map[string]interface{})["content"].([]interface{})
+	require.Len(t, parts, 2)
+	assert.Equal(t, map[string]interface{}{"url": "http://minio/a.png"}, parts[1].(map[string]interface{})["image_url"])
+}
+
+func TestChatAPIError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"slow down","type":"rate_limit","code":"rate_limit_exceeded"}}`)
+	})
+
+	_, err := c.Chat(context.Background(), &Request{Messages: []Message{{Role: RoleUser, Content: "hi"}}})
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.True(t, apiErr.RateLimited())
+	assert.False(t, apiErr.ServerError())
+	assert.Equal(t, "rate_limit_exceeded", apiErr.Code)
+	assert.Equal(t, "slow down", apiErr.Message)
+
+	// 非 JSON 错误响应以响应体作为错误信息
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream down", http.StatusBadGateway)
+	})
+	_, err = c.Chat(context.Background(), &Request{})
+	require.True(t, errors.As(err, &apiErr))
+	assert.True(t, apiErr.ServerError())
+	assert.Equal(t, "upstream down", apiErr.Message)
+}
+
+func TestChatTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	t.Cleanup(func() { close(release) })
+	c.timeout = 50 * time.Millisecond
+
+	_, err := c.Chat(context.Background(), &Request{})
+	require.Error(t, err)
+	assert.True(t, IsTimeout(err))
+}
+
+func TestChatNotConfigured(t *testing.T) {
+	c := NewOpenAIClient(config.LLMProviderConfig{BaseURL: "http://127.0.0.1:0"}, nil)
+	_, err := c.Chat(context.Background(), &Request{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+	_, err = c.ChatStream(context.Background(), &Request{}, func(string) error { return nil })
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestChatStream(t *testing.T) {
+	var got map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"model\":\"m1\",\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"你\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"好\"},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var deltas []string
+	resp, err := c.ChatStream(context.Background(), &Request{Model: "m1"}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"你", "好"}, deltas)
+	assert.Equal(t, "你好", resp.Content)
+	assert.Equal(t, "m1", resp.Model)
+	assert.Equal(t, 5, resp.Usage.TotalTokens)
+	assert.Equal(t, true, got["stream"])
+	assert.Equal(t, map[string]interface{}{"include_usage": true}, got["stream_options"])
+}
+
+func TestChatStreamErrors(t *testing.T) {
+	// 没有结束标记就断开
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"半\"}}]}\n\n")
+	})
+	_, err := c.ChatStream(context.Background(), &Request{}, func(string) error { return nil })
+	assert.ErrorIs(t, err, ErrIncompleteStream)
+
+	// 流中的错误事件
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"error\":{\"message\":\"overloaded\",\"code\":503}}\n\n")
+	})
+	_, err = c.ChatStream(context.Background(), &Request{}, func(string) error { return nil })
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "503", apiErr.Code)
+
+	// onDelta 返回错误时中止
+	stop := errors.New("client gone")
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"a\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	_, err = c.ChatStream(context.Background(), &Request{}, func(string) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestChatToolCalls(t *testing.T) {
+	var got map[string]interface{}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_current_time","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`)
+	})
+
+	resp, err := c.Chat(context.Background(), &Request{
+		Messages: []Message{
+			{Role: RoleUser, Content: "几点了"},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_0", Type: ToolTypeFunction, Function: FunctionCall{Name: "f", Arguments: "{}"}}}},
+			{Role: RoleTool, ToolCallID: "call_0", Content: "ok"},
+		},
+		Tools: []ToolDefinition{{Type: ToolTypeFunction, Function: FunctionDefinition{Name: "get_current_time", Parameters: map[string]interface{}{"type": "object"}}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "get_current_time", resp.ToolCalls[0].Function.Name)
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+
+	// 只含工具调用的 assistant 消息 content 为 null
+	messages := got["messages"].([]interface{})
+	assistant := messages[1].(map[string]interface{})
+	assert.Nil(t, assistant["content"])
+	assert.Len(t, assistant["tool_calls"], 1)
+	assert.Equal(t, "call_0", messages[2].(map[string]interface{})["tool_call_id"])
+	assert.Len(t, got["tools"], 1)
+}
+
+func TestChatStreamToolCalls(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"get_current_time\",\"arguments\":\"\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"timezone\\\":\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"UTC\\\"}\"}},{\"index\":1,\"id\":\"call_2\",\"function\":{\"name\":\"echo\",\"arguments\":\"{}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	resp, err := c.ChatStream(context.Background(), &Request{}, func(string) error {
+		t.Fatal("tool calls must not be emitted as deltas")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ToolCall{
+		{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_current_time", Arguments: `{"timezone":"UTC"}`}},
+		{ID: "call_2", Type: ToolTypeFunction, Function: FunctionCall{Name: "echo", Arguments: "{}"}},
+	}, resp.ToolCalls)
+}
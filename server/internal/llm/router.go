@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"go.uber.org/zap"
+)
+
+// ErrModelNotAllowed 请求的模型不在白名单中
+var ErrModelNotAllowed = errors.New("llm: model is not allowed")
+
+// Route 模型在某个提供方上的路由
+type Route struct {
+	ProviderName string
+	Provider     Provider
+	// Model 提供方侧的模型ID
+	Model string
+}
+
+// Router 按模型白名单路由请求，当前提供方超时、5xx 或限流时依次切换到下一条路由
+type Router struct {
+	defaultModel string
+	models       []string
+	routes       map[string][]Route
+}
+
+// NewRouter 创建空的模型路由，defaultModel 为请求未指定模型时使用的模型
+func NewRouter(defaultModel string) *Router {
+	return &Router{defaultModel: defaultModel, routes: map[string][]Route{}}
+}
+
+// NewRouterFromConfig 按配置创建模型路由，七牛推理服务以 qiniu 为名注册为提供方
+func NewRouterFromConfig(qiniu config.QiNiuConfig, cfg config.LLMConfig) *Router {
+	providers := map[string]Provider{
+		config.QiNiuProviderName: NewOpenAIClient(qiniu.Provider(), nil),
+	}
+	for _, p := range cfg.Providers {
+		providers[p.Name] = NewOpenAIClient(p, nil)
+	}
+
+	r := NewRouter(cfg.DefaultModel)
+	for _, m := range cfg.Models {
+		routes := make([]Route, 0, len(m.Routes))
+		for _, rc := range m.Routes {
+			model := rc.Model
+			if model == "" {
+				model = m.Name
+			}
+			routes = append(routes, Route{ProviderName: rc.Provider, Provider: providers[rc.Provider], Model: model})
+		}
+		r.Add(m.Name, routes...)
+	}
+	return r
+}
+
+// Add 将模型加入白名单，按 routes 顺序尝试
+func (r *Router) Add(model string, routes ...Route) {
+	if _, ok := r.routes[model]; !ok {
+		r.models = append(r.models, model)
+	}
+	r.routes[model] = routes
+}
+
+// DefaultModel 请求未指定模型时使用的模型
+func (r *Router) DefaultModel() string {
+	return r.defaultModel
+}
+
+// Models 按配置顺序返回可选模型
+func (r *Router) Models() []string {
+	return append([]string(nil), r.models...)
+}
+
+// Allowed 判断模型是否在白名单中，空字符串表示使用默认模型
+func (r *Router) Allowed(model string) bool {
+	if model == "" {
+		return true
+	}
+	_, ok := r.routes[model]
+	return ok
+}
+
+// Chat 按路由依次尝试生成完整回复，返回的 Model 为实际使用的提供方模型ID
+func (r *Router) Chat(ctx context.Context, req *Request) (*Response, error) {
+	model, routes, err := r.resolve(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	for i, route := range routes {
+		resp, err := route.Provider.Chat(ctx, routeRequest(req, route))
+		if err == nil {
+			return routeResponse(resp, model, route), nil
+		}
+		if i == len(routes)-1 || !shouldFailover(ctx, err) {
+			return nil, err
+		}
+		logFailover(route, routes[i+1], err)
+	}
+	return nil, ErrModelNotAllowed
+}
+
+// ChatStream 按路由依次尝试流式生成回复；已经输出增量文本后出错不再切换，避免回复重复
+func (r *Router) ChatStream(ctx context.Context, req *Request, onDelta func(delta string) error) (*Response, error) {
+	model, routes, err := r.resolve(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	for i, route := range routes {
+		emitted := false
+		resp, err := route.Provider.ChatStream(ctx, routeRequest(req, route), func(delta string) error {
+			emitted = true
+			return onDelta(delta)
+		})
+		if err == nil {
+			return routeResponse(resp, model, route), nil
+		}
+		if emitted || i == len(routes)-1 || !shouldFailover(ctx, err) {
+			return nil, err
+		}
+		logFailover(route, routes[i+1], err)
+	}
+	return nil, ErrModelNotAllowed
+}
+
+// resolve 返回请求使用的白名单模型名（未指定时为默认模型）及其路由
+func (r *Router) resolve(model string) (string, []Route, error) {
+	if model == "" {
+		model = r.defaultModel
+	}
+	routes := r.routes[model]
+	if len(routes) == 0 {
+		return "", nil, ErrModelNotAllowed
+	}
+	return model, routes, nil
+}
+
+func routeRequest(req *Request, route Route) *Request {
+	routed := *req
+	routed.Model = route.Model
+	return &routed
+}
+
+// routeResponse 对外保留白名单模型名，提供方返回的模型ID记录在 ProviderModel
+func routeResponse(resp *Response, model string, route Route) *Response {
+	resp.ProviderModel = resp.Model
+	if resp.ProviderModel == "" {
+		resp.ProviderModel = route.Model
+	}
+	resp.Model = model
+	resp.Provider = route.ProviderName
+	return resp
+}
+
+// shouldFailover 超时、5xx、限流或提供方未配置时切换到下一条路由；调用方已取消时不再尝试
+func shouldFailover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, ErrNotConfigured) || IsTimeout(err) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.RateLimited() || apiErr.ServerError())
+}
+
+func logFailover(from, to Route, err error) {
+	logger.Logger.Warn("llm provider failed, falling back",
+		zap.String("provider", from.ProviderName),
+		zap.String("model", from.Model),
+		zap.String("fallback_provider", to.ProviderName),
+		zap.String("fallback_model", to.Model),
+		zap.Error(err),
+	)
+}
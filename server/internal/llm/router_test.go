@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func reply(content string) FakeReply {
+	return FakeReply{Response: &Response{Content: content}}
+}
+
+func TestRouterFailover(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		failover bool
+	}{
+		{"rate limited", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &APIError{StatusCode: http.StatusBadGateway}, true},
+		{"timeout", fmt.Errorf("llm: request failed: %w", context.DeadlineExceeded), true},
+		{"not configured", ErrNotConfigured, true},
+		{"bad request", &APIError{StatusCode: http.StatusBadRequest}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			primary := NewFake(FakeReply{Err: tc.err})
+			backup := NewFake(reply("from backup"))
+			r := NewRouter("qwen")
+			r.Add("qwen", Route{ProviderName: "qiniu", Provider: primary, Model: "qwen-vl-max"}, Route{ProviderName: "backup", Provider: backup, Model: "qwen-backup"})
+
+			resp, err := r.Chat(context.Background(), &Request{})
+			if !tc.failover {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Empty(t, backup.Requests())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "from backup", resp.Content)
+			// 对外返回请求的白名单模型名，实际使用的模型ID单独记录
+			assert.Equal(t, "qwen", resp.Model)
+			assert.Equal(t, "qwen-backup", resp.ProviderModel)
+			assert.Equal(t, "backup", resp.Provider)
+			assert.Equal(t, "qwen-vl-max", primary.Requests()[0].Model)
+			assert.Equal(t, "qwen-backup", backup.Requests()[0].Model)
+		})
+	}
+}
+
+func TestRouterAllowList(t *testing.T) {
+	a, b := NewFake(reply("a")), NewFake(reply("b"))
+	r := NewRouter("model-a")
+	r.Add("model-a", Route{ProviderName: "p", Provider: a, Model: "model-a"})
+	r.Add("model-b", Route{ProviderName: "p", Provider: b, Model: "model-b"})
+
+	assert.Equal(t, []string{"model-a", "model-b"}, r.Models())
+	assert.True(t, r.Allowed(""))
+	assert.True(t, r.Allowed("model-b"))
+	assert.False(t, r.Allowed("gpt-4"))
+
+	resp, err := r.Chat(context.Background(), &Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "a", resp.Content)
+	resp, err = r.Chat(context.Background(), &Request{Model: "model-b"})
+	require.NoError(t, err)
+	assert.Equal(t, "b", resp.Content)
+
+	_, err = r.Chat(context.Background(), &Request{Model: "gpt-4"})
+	assert.ErrorIs(t, err, ErrModelNotAllowed)
+}
+
+func TestRouterStreamFailover(t *testing.T) {
+	overloaded := &APIError{StatusCode: http.StatusServiceUnavailable}
+
+	// 尚未输出内容时切换到后备提供方
+	primary := NewFake(FakeReply{Err: overloaded})
+	backup := NewFake(reply("ok"))
+	r := NewRouter("m")
+	r.Add("m", Route{ProviderName: "a", Provider: primary, Model: "m"}, Route{ProviderName: "b", Provider: backup, Model: "m"})
+	var deltas []string
+	resp, err := r.ChatStream(context.Background(), &Request{}, func(d string) error {
+		deltas = append(deltas, d)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "b", resp.Provider)
+	assert.Equal(t, []string{"ok"}, deltas)
+
+	// 已输出部分内容后出错不再切换
+	primary = NewFake(FakeReply{Response: &Response{Content: "partial"}, Err: overloaded})
+	backup = NewFake(reply("ok"))
+	r = NewRouter("m")
+	r.Add("m", Route{ProviderName: "a", Provider: primary, Model: "m"}, Route{ProviderName: "b", Provider: backup, Model: "m"})
+	_, err = r.ChatStream(context.Background(), &Request{}, func(string) error { return nil })
+	assert.True(t, errors.Is(err, overloaded))
+	assert.Empty(t, backup.Requests())
+}
+
+func TestRouterStopsWhenCallerCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	primary := NewFake(FakeReply{Err: fmt.Errorf("llm: request failed: %w", context.Canceled)})
+	backup := NewFake(reply("ok"))
+	r := NewRouter("m")
+	r.Add("m", Route{ProviderName: "a", Provider: primary, Model: "m"}, Route{ProviderName: "b", Provider: backup, Model: "m"})
+
+	_, err := r.Chat(ctx, &Request{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, backup.Requests())
+}
+
+func TestNewRouterFromConfig(t *testing.T) {
+	r := NewRouterFromConfig(config.QiNiuConfig{Model: "qwen"}, config.LLMConfig{
+		DefaultModel: "qwen",
+		Providers:    []config.LLMProviderConfig{{Name: "backup", BaseURL: "http://backup/v1"}},
+		Models: []config.LLMModelConfig{
+			{Name: "qwen", Routes: []config.LLMRouteConfig{{Provider: "qiniu"}, {Provider: "backup", Model: "qwen-mirror"}}},
+		},
+	})
+	assert.Equal(t, "qwen", r.DefaultModel())
+	routes := r.routes["qwen"]
+	require.Len(t, routes, 2)
+	assert.Equal(t, "qiniu", routes[0].ProviderName)
+	assert.Equal(t, "qwen", routes[0].Model)
+	assert.Equal(t, "backup", routes[1].ProviderName)
+	assert.Equal(t, "qwen-mirror", routes[1].Model)
+
+	// 两个提供方都未配置密钥时返回最后一个错误
+	_, err := r.Chat(context.Background(), &Request{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+)
+
+// clientInfo initialize 中上报的客户端信息
+var clientInfo = Implementation{Name: "ai-hackathon-server", Version: "1.0.0"}
+
+type transport interface {
+	// roundTrip 发送请求并等待对应 ID 的响应
+	roundTrip(ctx context.Context, req *message) (*message, error)
+	// notify 发送不需要响应的通知
+	notify(ctx context.Context, n *message) error
+	close() error
+}
+
+// Client 与单个 MCP 服务器的连接
+type Client struct {
+	name    string
+	t       transport
+	timeout time.Duration
+	nextID  int64
+	info    InitializeResult
+}
+
+// Dial 按配置连接 MCP 服务器并完成 initialize 握手，httpClient 为 nil 时使用默认客户端（仅 http 方式）
+func Dial(ctx context.Context, cfg config.MCPServerConfig, httpClient *http.Client) (*Client, error) {
+	var t transport
+	switch cfg.Transport {
+	case config.MCPTransportHTTP:
+		t = newHTTPTransport(cfg, httpClient)
+	case config.MCPTransportStdio, "":
+		st, err := newStdioTransport(cfg)
+		if err != nil {
+			return nil, err
+		}
+		t = st
+	default:
+		return nil, fmt.Errorf("mcp: unsupported transport %q", cfg.Transport)
+	}
+
+	c := &Client{name: cfg.Name, t: t, timeout: cfg.Timeout}
+	if err := c.initialize(ctx); err != nil {
+		t.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	params := initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      clientInfo,
+	}
+	if err := c.call(ctx, "initialize", params, &c.info); err != nil {
+		return fmt.Errorf("mcp: initialize %s: %w", c.name, err)
+	}
+	if v, ok := c.t.(interface{ setProtocolVersion(string) }); ok {
+		v.setProtocolVersion(c.info.ProtocolVersion)
+	}
+	return c.notify(ctx, "notifications/initialized")
+}
+
+// Name 配置中的服务器名称
+func (c *Client) Name() string {
+	return c.name
+}
+
+// ServerInfo 服务器在 initialize 中返回的信息
+func (c *Client) ServerInfo() InitializeResult {
+	return c.info
+}
+
+// ListTools 列出服务器提供的全部工具，自动翻页
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var page listToolsResult
+		if err := c.call(ctx, "tools/list", cursorParams{Cursor: cursor}, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool 调用工具；工具执行失败由结果的 IsError 表示，返回的 error 仅表示协议或传输错误
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources 列出服务器提供的全部资源，自动翻页
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	cursor := ""
+	for {
+		var page listResourcesResult
+		if err := c.call(ctx, "resources/list", cursorParams{Cursor: cursor}, &page); err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Resources...)
+		if page.NextCursor == "" {
+			return resources, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// ReadResource 读取资源内容
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	var result readResourceResult
+	if err := c.call(ctx, "resources/read", readResourceParams{URI: uri}, &result); err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// Close 关闭连接，stdio 方式会结束子进程
+func (c *Client) Close() error {
+	return c.t.close()
+}
+
+// call 发送请求并将结果解码到 result，每个请求受配置的超时限制
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("mcp: failed to encode params: %w", err)
+	}
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	id := atomic.AddInt64(&c.nextID, 1)
+	resp, err := c.t.roundTrip(ctx, &message{
+		JSONRPC: jsonRPCVersion,
+		ID:      json.RawMessage(strconv.FormatInt(id, 10)),
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("mcp: failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (c *Client) notify(ctx context.Context, method string) error {
+	return c.t.notify(ctx, &message{JSONRPC: jsonRPCVersion, Method: method})
+}
@@ -0,0 +1,225 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/mcp/mcptest"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fixtureEnv 设置后测试二进制作为 stdio MCP 服务器运行
+const fixtureEnv = "MCPTEST_STDIO"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fixtureEnv) == "1" {
+		if err := mcptest.NewServer().ServeStdio(os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func stdioConfig() config.MCPServerConfig {
+	return config.MCPServerConfig{
+		Name:      "fixture",
+		Transport: config.MCPTransportStdio,
+		Command:   os.Args[0],
+		Env:       []string{fixtureEnv + "=1"},
+		Timeout:   5 * time.Second,
+	}
+}
+
+func dialHTTP(t *testing.T, sse bool) (*Client, *mcptest.Server) {
+	fixture := mcptest.NewServer()
+	fixture.SSE = sse
+	srv := httptest.NewServer(fixture)
+	t.Cleanup(srv.Close)
+	c, err := Dial(context.Background(), config.MCPServerConfig{
+		Name:      "fixture",
+		Transport: config.MCPTransportHTTP,
+		URL:       srv.URL + "/mcp",
+		Timeout:   5 * time.Second,
+	}, srv.Client())
+	require.NoError(t, err)
+	return c, fixture
+}
+
+// exerciseClient 各传输方式共用的协议检查
+func exerciseClient(t *testing.T, c *Client) {
+	ctx := context.Background()
+	assert.Equal(t, "mcptest", c.ServerInfo().ServerInfo.Name)
+
+	list, err := c.ListTools(ctx)
+	require.NoError(t, err)
+	names := make([]string, 0, len(list))
+	for _, tool := range list {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{"echo", "add", "fail"}, names)
+
+	result, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": "你好"})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "你好", result.Text())
+
+	result, err = c.CallTool(ctx, "fail", nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	resources, err := c.ListResources(ctx)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	contents, err := c.ReadResource(ctx, resources[0].URI)
+	require.NoError(t, err)
+	assert.Equal(t, mcptest.ReadmeText, contents[0].Text)
+
+	_, err = c.ReadResource(ctx, "fixture://missing")
+	var rpcErr *RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32002, rpcErr.Code)
+}
+
+func TestStdioClient(t *testing.T) {
+	c, err := Dial(context.Background(), stdioConfig(), nil)
+	require.NoError(t, err)
+	exerciseClient(t, c)
+	require.NoError(t, c.Close())
+
+	// 进程退出后的请求返回 ErrClosed
+	_, err = c.ListTools(context.Background())
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestStdioClientStartFailure(t *testing.T) {
+	cfg := stdioConfig()
+	cfg.Command = "/nonexistent/mcp-server"
+	_, err := Dial(context.Background(), cfg, nil)
+	assert.Error(t, err)
+}
+
+func TestHTTPClient(t *testing.T) {
+	for _, sse := range []bool{false, true} {
+		c, fixture := dialHTTP(t, sse)
+		exerciseClient(t, c)
+		assert.Equal(t, 1, fixture.ActiveSessions())
+		require.NoError(t, c.Close())
+		assert.Equal(t, 0, fixture.ActiveSessions(), "close ends the session")
+	}
+}
+
+func TestToolName(t *testing.T) {
+	assert.Equal(t, "files__search", ToolName("files", "search"))
+	assert.Equal(t, "files__get_user_profile", ToolName("files", "get.user/profile"))
+
+	// 截断后以哈希区分前缀相同的长名称
+	a := ToolName("srv", strings.Repeat("x", 100)+"a")
+	b := ToolName("srv", strings.Repeat("x", 100)+"b")
+	assert.Len(t, a, 64)
+	assert.Len(t, b, 64)
+	assert.NotEqual(t, a, b)
+	assert.True(t, strings.HasPrefix(a, "srv__xxx"), a)
+	assert.Equal(t, a, ToolName("srv", strings.Repeat("x", 100)+"a"))
+}
+
+func TestRegisterTools(t *testing.T) {
+	c, _ := dialHTTP(t, false)
+	t.Cleanup(func() { c.Close() })
+
+	registry := tools.NewRegistry(time.Second)
+	require.NoError(t, RegisterTools(context.Background(), registry, c))
+
+	defs := registry.Definitions()
+	names := make([]string, 0, len(defs))
+	for _, d := range defs {
+		names = append(names, d.Function.Name)
+	}
+	assert.Equal(t, []string{"fixture__echo", "fixture__add", "fixture__fail", "fixture__list_resources", "fixture__read_resource"}, names)
+
+	ctx := context.Background()
+	out, err := registry.Call(ctx, "fixture__add", `{"a":1,"b":2.5}`)
+	require.NoError(t, err)
+	assert.Equal(t, "3.5", out)
+
+	// 参数在本地按服务器声明的 Schema 校验
+	_, err = registry.Call(ctx, "fixture__add", `{"a":1}`)
+	assert.ErrorIs(t, err, tools.ErrInvalidArguments)
+
+	_, err = registry.Call(ctx, "fixture__fail", `{}`)
+	assert.EqualError(t, err, "boom")
+
+	out, err = registry.Call(ctx, "fixture__list_resources", ``)
+	require.NoError(t, err)
+	assert.Contains(t, out, mcptest.ReadmeURI)
+	out, err = registry.Call(ctx, "fixture__read_resource", `{"uri":"`+mcptest.ReadmeURI+`"}`)
+	require.NoError(t, err)
+	assert.Equal(t, mcptest.ReadmeText, out)
+}
+
+func TestRegisterToolsSkipsConflicts(t *testing.T) {
+	c, _ := dialHTTP(t, false)
+	t.Cleanup(func() { c.Close() })
+
+	// 与已注册的工具重名的工具被跳过，其余工具照常注册
+	registry := tools.NewRegistry(time.Second)
+	require.NoError(t, registry.Register(tools.Tool{
+		Name: "fixture__add",
+		Call: func(ctx context.Context, args map[string]interface{}) (string, error) { return "local", nil },
+	}))
+	require.NoError(t, RegisterTools(context.Background(), registry, c))
+	assert.Equal(t, 5, registry.Len())
+
+	out, err := registry.Call(context.Background(), "fixture__add", `{}`)
+	require.NoError(t, err)
+	assert.Equal(t, "local", out)
+	out, err = registry.Call(context.Background(), "fixture__echo", `{"text":"hi"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", out)
+}
+
+func TestManagerWithRunner(t *testing.T) {
+	manager := Connect(context.Background(), []config.MCPServerConfig{
+		stdioConfig(),
+		{Name: "broken", Transport: config.MCPTransportStdio, Command: "/nonexistent/mcp-server"},
+	})
+	t.Cleanup(manager.Close)
+	require.Len(t, manager.Clients(), 1, "unavailable servers are skipped")
+
+	registry := tools.NewRegistry(time.Second)
+	manager.RegisterTools(context.Background(), registry)
+
+	// 模型看到服务器声明的原始 Schema，请求的工具调用经 MCP 执行
+	fake := llm.NewFake(
+		llm.FakeReply{Response: &llm.Response{ToolCalls: []llm.ToolCall{{
+			ID:       "call_1",
+			Type:     llm.ToolTypeFunction,
+			Function: llm.FunctionCall{Name: "fixture__echo", Arguments: `{"text":"pong"}`},
+		}}}},
+		llm.FakeReply{Response: &llm.Response{Content: "done"}},
+	)
+	resp, trace, err := tools.NewRunner(fake, registry, 3).Run(context.Background(), &llm.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "done", resp.Content)
+	require.Len(t, trace, 1)
+	assert.Equal(t, "pong", trace[0].Result)
+
+	first := fake.Requests()[0].Tools
+	require.NotEmpty(t, first)
+	params, err := json.Marshal(first[0].Function.Parameters)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`, string(params))
+}
@@ -0,0 +1,254 @@
+// Package mcptest 测试用的最小 MCP 服务器，支持 stdio 与 Streamable HTTP 传输，
+// 提供 echo、add、fail 三个工具与一个文本资源
+package mcptest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ReadmeURI 服务器提供的唯一资源
+const ReadmeURI = "fixture://readme"
+
+// ReadmeText 资源内容
+const ReadmeText = "hello from fixture"
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// tools 以两页返回，用于验证客户端翻页
+var tools = []map[string]interface{}{
+	{
+		"name":        "echo",
+		"description": "原样返回 text",
+		"inputSchema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}},
+			"required":   []string{"text"},
+		},
+	},
+	{
+		"name":        "add",
+		"description": "返回 a + b",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"a": map[string]interface{}{"type": "number"},
+				"b": map[string]interface{}{"type": "number"},
+			},
+			"required": []string{"a", "b"},
+		},
+	},
+	{
+		"name":        "fail",
+		"description": "总是失败",
+		"inputSchema": map[string]interface{}{"type": "object"},
+	},
+}
+
+// Server 最小 MCP 服务器
+type Server struct {
+	// SSE 为 true 时 HTTP 请求的响应以事件流返回，并在响应前插入一条通知
+	SSE bool
+
+	mu       sync.Mutex
+	sessions int
+	active   map[string]bool
+}
+
+// NewServer 创建测试服务器
+func NewServer() *Server {
+	return &Server{active: map[string]bool{}}
+}
+
+// ServeStdio 从 r 逐行读取请求并向 w 写入响应，直到 r 关闭
+func (s *Server) ServeStdio(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		resp := s.handle(scanner.Bytes())
+		if resp == nil {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ServeHTTP Streamable HTTP 传输：initialize 分配会话ID，之后的请求须携带会话ID与协议版本请求头
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get("Mcp-Session-Id")
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.active, sessionID)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var req message
+	if err := json.Unmarshal(raw, &req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if req.Method == "initialize" {
+		s.mu.Lock()
+		s.sessions++
+		sessionID = "session-" + strconv.Itoa(s.sessions)
+		s.active[sessionID] = true
+		s.mu.Unlock()
+		w.Header().Set("Mcp-Session-Id", sessionID)
+	} else {
+		s.mu.Lock()
+		ok := s.active[sessionID]
+		s.mu.Unlock()
+		if !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("MCP-Protocol-Version") == "" {
+			http.Error(w, "missing protocol version", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := s.handle(raw)
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if !s.SSE {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	note, _ := json.Marshal(message{JSONRPC: "2.0", Method: "notifications/message", Params: json.RawMessage(`{"level":"info","data":"working"}`)})
+	body, _ := json.Marshal(resp)
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", note)
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", body)
+}
+
+// ActiveSessions 未结束的 HTTP 会话数
+func (s *Server) ActiveSessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.active)
+}
+
+// handle 处理一条消息，通知返回 nil
+func (s *Server) handle(raw []byte) *message {
+	var req message
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return &message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: -32700, Message: "parse error"}}
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := &message{JSONRPC: "2.0", ID: req.ID}
+	result, rpcErr := s.dispatch(req.Method, req.Params)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+func (s *Server) dispatch(method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": "2025-06-18",
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}, "resources": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "mcptest", "version": "0.0.1"},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		var p struct {
+			Cursor string `json:"cursor"`
+		}
+		json.Unmarshal(params, &p)
+		if p.Cursor == "" {
+			return map[string]interface{}{"tools": tools[:2], "nextCursor": "page-2"}, nil
+		}
+		return map[string]interface{}{"tools": tools[2:]}, nil
+	case "tools/call":
+		var p struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: -32602, Message: "invalid params"}
+		}
+		return callTool(p.Name, p.Arguments)
+	case "resources/list":
+		return map[string]interface{}{"resources": []map[string]string{
+			{"uri": ReadmeURI, "name": "readme", "mimeType": "text/plain"},
+		}}, nil
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		json.Unmarshal(params, &p)
+		if p.URI != ReadmeURI {
+			return nil, &rpcError{Code: -32002, Message: "resource not found"}
+		}
+		return map[string]interface{}{"contents": []map[string]string{
+			{"uri": ReadmeURI, "mimeType": "text/plain", "text": ReadmeText},
+		}}, nil
+	default:
+		return nil, &rpcError{Code: -32601, Message: "method not found"}
+	}
+}
+
+func callTool(name string, args map[string]interface{}) (interface{}, *rpcError) {
+	switch name {
+	case "echo":
+		text, _ := args["text"].(string)
+		return map[string]interface{}{"content": []content{{Type: "text", Text: text}}}, nil
+	case "add":
+		a, _ := args["a"].(float64)
+		b, _ := args["b"].(float64)
+		return map[string]interface{}{"content": []content{{Type: "text", Text: strconv.FormatFloat(a+b, 'f', -1, 64)}}}, nil
+	case "fail":
+		return map[string]interface{}{"content": []content{{Type: "text", Text: "boom"}}, "isError": true}, nil
+	default:
+		return nil, &rpcError{Code: -32602, Message: "unknown tool: " + name}
+	}
+}
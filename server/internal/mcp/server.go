@@ -0,0 +1,222 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
+	"go.uber.org/zap"
+)
+
+// supportedProtocolVersions 服务端接受的协议版本，按新旧排序
+var supportedProtocolVersions = []string{ProtocolVersion, "2025-03-26"}
+
+// SupportedProtocolVersion 判断服务端是否支持该协议版本
+func SupportedProtocolVersion(version string) bool {
+	for _, v := range supportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolError 工具执行失败，Message 以 isError 结果返回给调用方；
+// 工具返回的其他错误视为内部错误，只记录日志，调用方收到通用错误信息
+type ToolError struct {
+	Message string
+}
+
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// ToolErrorf 创建返回给调用方的工具错误
+func ToolErrorf(format string, args ...interface{}) error {
+	return &ToolError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ServerTool 服务端提供的工具。Call 收到经过鉴权的调用方 uid，须自行确认 uid 有权访问所请求的数据；
+// 返回值编码为 JSON 后作为文本内容与结构化内容返回
+type ServerTool struct {
+	Name        string
+	Description string
+	// InputSchema 参数 Schema，调用前按其校验，为 nil 时接受任意对象
+	InputSchema *tools.Schema
+	Call        func(ctx context.Context, uid int, args map[string]interface{}) (interface{}, error)
+}
+
+// Server MCP 服务端。不维护会话，每个请求的调用方由传输层的鉴权结果（uid）确定
+type Server struct {
+	info         Implementation
+	instructions string
+	tools        []ServerTool
+	index        map[string]int
+}
+
+// NewServer 创建 MCP 服务端，instructions 在 initialize 中返回，提示调用方如何使用这些工具
+func NewServer(info Implementation, instructions string) *Server {
+	return &Server{info: info, instructions: instructions, index: map[string]int{}}
+}
+
+// AddTool 注册工具，名称重复时返回错误
+func (s *Server) AddTool(t ServerTool) error {
+	if t.Name == "" || t.Call == nil {
+		return fmt.Errorf("mcp: tool %q needs a name and Call", t.Name)
+	}
+	if _, ok := s.index[t.Name]; ok {
+		return fmt.Errorf("mcp: tool %q is already registered", t.Name)
+	}
+	s.index[t.Name] = len(s.tools)
+	s.tools = append(s.tools, t)
+	return nil
+}
+
+// Handle 以 uid 的身份处理一条 JSON-RPC 消息，返回编码后的响应；通知与响应消息返回 nil
+func (s *Server) Handle(ctx context.Context, uid int, raw []byte) []byte {
+	resp := s.handle(ctx, uid, raw)
+	if resp == nil {
+		return nil
+	}
+	out, err := json.Marshal(resp)
+	if err != nil {
+		logger.Logger.Error("failed to encode mcp response", zap.Error(err))
+		out, _ = json.Marshal(errorResponse(resp.ID, CodeInternalError, "internal error"))
+	}
+	return out
+}
+
+func (s *Server) handle(ctx context.Context, uid int, raw []byte) *message {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		return errorResponse(nil, CodeInvalidRequest, "batch requests are not supported")
+	}
+	var req message
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return errorResponse(nil, CodeParseError, "parse error")
+	}
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" {
+		if req.isResponse() {
+			// 服务端不向客户端发送请求，忽略客户端的响应
+			return nil
+		}
+		return errorResponse(req.ID, CodeInvalidRequest, "invalid request")
+	}
+	if !req.isRequest() {
+		// notifications/initialized 等通知无需处理
+		return nil
+	}
+
+	result, rpcErr := s.dispatch(ctx, uid, req.Method, req.Params)
+	if rpcErr != nil {
+		return &message{JSONRPC: jsonRPCVersion, ID: req.ID, Error: rpcErr}
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		logger.Logger.Error("failed to encode mcp result", zap.String("method", req.Method), zap.Error(err))
+		return errorResponse(req.ID, CodeInternalError, "internal error")
+	}
+	return &message{JSONRPC: jsonRPCVersion, ID: req.ID, Result: encoded}
+}
+
+func (s *Server) dispatch(ctx context.Context, uid int, method string, params json.RawMessage) (interface{}, *RPCError) {
+	switch method {
+	case "initialize":
+		var p initializeParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		// 客户端请求的版本受支持时沿用，否则返回服务端的最新版本由客户端决定是否继续
+		version := ProtocolVersion
+		if SupportedProtocolVersion(p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return InitializeResult{
+			ProtocolVersion: version,
+			Capabilities:    ServerCapabilities{Tools: &ToolsCapability{}},
+			ServerInfo:      s.info,
+			Instructions:    s.instructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		list := make([]Tool, 0, len(s.tools))
+		for _, t := range s.tools {
+			schema, _ := json.Marshal(t.InputSchema)
+			if t.InputSchema == nil {
+				schema = json.RawMessage(`{"type":"object"}`)
+			}
+			list = append(list, Tool{Name: t.Name, Description: t.Description, InputSchema: schema})
+		}
+		return listToolsResult{Tools: list}, nil
+	case "tools/call":
+		var p callToolParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		i, ok := s.index[p.Name]
+		if !ok {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + p.Name}
+		}
+		return s.callTool(ctx, uid, s.tools[i], p.Arguments), nil
+	default:
+		return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// callTool 执行工具；参数错误与工具错误以 isError 结果返回，便于调用方（通常是模型）自行修正
+func (s *Server) callTool(ctx context.Context, uid int, t ServerTool, args map[string]interface{}) *CallToolResult {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	if err := t.InputSchema.Validate(args); err != nil {
+		return toolErrorResult("invalid arguments: " + err.Error())
+	}
+
+	value, err := t.Call(ctx, uid, args)
+	if err != nil {
+		var toolErr *ToolError
+		if errors.As(err, &toolErr) {
+			return toolErrorResult(toolErr.Message)
+		}
+		logger.Logger.Error("mcp tool failed", zap.String("tool", t.Name), zap.Int("uid", uid), zap.Error(err))
+		return toolErrorResult("internal error")
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		logger.Logger.Error("failed to encode mcp tool result", zap.String("tool", t.Name), zap.Error(err))
+		return toolErrorResult("internal error")
+	}
+	result := &CallToolResult{Content: []Content{{Type: "text", Text: string(encoded)}}}
+	// 结构化内容必须是 JSON 对象
+	if len(encoded) > 0 && encoded[0] == '{' {
+		result.StructuredContent = encoded
+	}
+	return result
+}
+
+func toolErrorResult(msg string) *CallToolResult {
+	return &CallToolResult{Content: []Content{{Type: "text", Text: msg}}, IsError: true}
+}
+
+func decodeParams(params json.RawMessage, v interface{}) *RPCError {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &RPCError{Code: CodeInvalidParams, Message: "invalid params"}
+	}
+	return nil
+}
+
+func errorResponse(id json.RawMessage, code int, msg string) *message {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &message{JSONRPC: jsonRPCVersion, ID: id, Error: &RPCError{Code: code, Message: msg}}
+}
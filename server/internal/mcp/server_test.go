@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer(Implementation{Name: "test", Version: "1"}, "use whoami")
+	require.NoError(t, s.AddTool(ServerTool{
+		Name:        "whoami",
+		InputSchema: &tools.Schema{Type: "object"},
+		Call: func(ctx context.Context, uid int, args map[string]interface{}) (interface{}, error) {
+			return map[string]int{"uid": uid}, nil
+		},
+	}))
+	require.NoError(t, s.AddTool(ServerTool{
+		Name: "lookup",
+		InputSchema: &tools.Schema{
+			Type:       "object",
+			Properties: map[string]*tools.Schema{"id": {Type: "integer"}},
+			Required:   []string{"id"},
+		},
+		Call: func(ctx context.Context, uid int, args map[string]interface{}) (interface{}, error) {
+			if args["id"].(float64) == 1 {
+				return nil, ToolErrorf("item %d not found", 1)
+			}
+			return nil, errors.New("db is down")
+		},
+	}))
+	assert.Error(t, s.AddTool(ServerTool{Name: "whoami", Call: func(context.Context, int, map[string]interface{}) (interface{}, error) { return nil, nil }}))
+	return s
+}
+
+// serveAs 模拟鉴权中间件：以 X-Uid 请求头作为调用方
+func serveAs(s *Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := strconv.Atoi(r.Header.Get("X-Uid"))
+		var body json.RawMessage
+		json.NewDecoder(r.Body).Decode(&body)
+		resp := s.Handle(r.Context(), uid, body)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resp)
+	})
+}
+
+func TestServerWithClient(t *testing.T) {
+	srv := httptest.NewServer(serveAs(newTestServer(t)))
+	t.Cleanup(srv.Close)
+
+	c, err := Dial(context.Background(), config.MCPServerConfig{
+		Name:      "platform",
+		Transport: config.MCPTransportHTTP,
+		URL:       srv.URL,
+		Headers:   map[string]string{"X-Uid": "42"},
+		Timeout:   5 * time.Second,
+	}, srv.Client())
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, ProtocolVersion, c.ServerInfo().ProtocolVersion)
+	assert.Equal(t, "use whoami", c.ServerInfo().Instructions)
+
+	list, err := c.ListTools(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.JSONEq(t, `{"type":"object"}`, string(list[0].InputSchema))
+
+	// 工具以调用方的 uid 执行，结果同时作为文本与结构化内容返回
+	result, err := c.CallTool(context.Background(), "whoami", nil)
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.JSONEq(t, `{"uid":42}`, result.Text())
+	assert.JSONEq(t, `{"uid":42}`, string(result.StructuredContent))
+
+	// 参数校验失败、工具错误与内部错误都以 isError 结果返回，内部错误不泄露细节
+	result, err = c.CallTool(context.Background(), "lookup", map[string]interface{}{"id": "x"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Text(), "invalid arguments")
+
+	result, err = c.CallTool(context.Background(), "lookup", map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "item 1 not found", result.Text())
+
+	result, err = c.CallTool(context.Background(), "lookup", map[string]interface{}{"id": 2})
+	require.NoError(t, err)
+	assert.Equal(t, "internal error", result.Text())
+
+	_, err = c.CallTool(context.Background(), "missing", nil)
+	var rpcErr *RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, CodeInvalidParams, rpcErr.Code)
+}
+
+func TestServerHandle(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		body string
+		want string
+	}{
+		{`not json`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`},
+		{`[{"jsonrpc":"2.0","id":1,"method":"ping"}]`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch requests are not supported"}}`},
+		{`{"jsonrpc":"1.0","id":1,"method":"ping"}`, `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"invalid request"}}`},
+		{`{"jsonrpc":"2.0","id":"a","method":"ping"}`, `{"jsonrpc":"2.0","id":"a","result":{}}`},
+		{`{"jsonrpc":"2.0","id":2,"method":"prompts/list"}`, `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found: prompts/list"}}`},
+		// 不支持的版本回退到服务端的最新版本
+		{`{"jsonrpc":"2.0","id":3,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`, `{"jsonrpc":"2.0","id":3,"result":{"protocolVersion":"2025-06-18","capabilities":{"tools":{}},"serverInfo":{"name":"test","version":"1"},"instructions":"use whoami"}}`},
+	}
+	for _, tc := range cases {
+		assert.JSONEq(t, tc.want, string(s.Handle(ctx, 1, []byte(tc.body))), tc.body)
+	}
+
+	// 通知与客户端响应不产生响应
+	assert.Nil(t, s.Handle(ctx, 1, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+	assert.Nil(t, s.Handle(ctx, 1, []byte(`{"jsonrpc":"2.0","id":9,"result":{}}`)))
+}
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"go.uber.org/zap"
+)
+
+// ErrClosed 连接已关闭或服务器进程已退出
+var ErrClosed = errors.New("mcp: connection closed")
+
+// stdioCloseGrace 关闭 stdin 后等待子进程自行退出的时间，超时后强制结束
+const stdioCloseGrace = 2 * time.Second
+
+// stdioTransport 启动子进程，通过 stdin/stdout 以换行分隔的 JSON 消息通信，stderr 写入日志
+type stdioTransport struct {
+	name  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *message
+
+	// done 在读循环退出（子进程退出或 stdout 关闭）时关闭
+	done       chan struct{}
+	readErr    error
+	stderrDone chan struct{}
+}
+
+func newStdioTransport(cfg config.MCPServerConfig) (*stdioTransport, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = append(os.Environ(), cfg.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: failed to open stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: failed to open stderr: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp: failed to start %s: %w", cfg.Command, err)
+	}
+
+	t := &stdioTransport{
+		name:       cfg.Name,
+		cmd:        cmd,
+		stdin:      stdin,
+		pending:    map[string]chan *message{},
+		done:       make(chan struct{}),
+		stderrDone: make(chan struct{}),
+	}
+	go t.readLoop(stdout)
+	go t.logStderr(stderr)
+	return t, nil
+}
+
+func (t *stdioTransport) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			logger.Logger.Warn("mcp server wrote invalid message", zap.String("server", t.name), zap.Error(err))
+			continue
+		}
+		switch {
+		case msg.isResponse():
+			t.mu.Lock()
+			ch, ok := t.pending[string(msg.ID)]
+			delete(t.pending, string(msg.ID))
+			t.mu.Unlock()
+			if ok {
+				ch <- &msg
+			}
+		case msg.isRequest():
+			t.reply(&msg)
+		}
+	}
+	t.readErr = scanner.Err()
+	close(t.done)
+}
+
+// reply 响应服务器发来的请求：只支持 ping，其余返回 method not found
+func (t *stdioTransport) reply(req *message) {
+	resp := &message{JSONRPC: jsonRPCVersion, ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage(`{}`)
+	} else {
+		resp.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	if err := t.write(resp); err != nil {
+		logger.Logger.Warn("failed to reply to mcp server", zap.String("server", t.name), zap.Error(err))
+	}
+}
+
+func (t *stdioTransport) logStderr(r io.Reader) {
+	defer close(t.stderrDone)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logger.Logger.Info("mcp server stderr", zap.String("server", t.name), zap.String("line", scanner.Text()))
+	}
+}
+
+func (t *stdioTransport) write(msg *message) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("mcp: failed to encode message: %w", err)
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(raw, '\n')); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return nil
+}
+
+func (t *stdioTransport) roundTrip(ctx context.Context, req *message) (*message, error) {
+	ch := make(chan *message, 1)
+	key := string(req.ID)
+	t.mu.Lock()
+	t.pending[key] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(req); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		if t.readErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrClosed, t.readErr)
+		}
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) notify(ctx context.Context, n *message) error {
+	return t.write(n)
+}
+
+// close 关闭 stdin 通知服务器退出，超时后结束子进程
+func (t *stdioTransport) close() error {
+	t.stdin.Close()
+	select {
+	case <-t.done:
+	case <-time.After(stdioCloseGrace):
+		t.cmd.Process.Kill()
+	}
+	// Wait 会关闭管道，须在读取完成后调用；子进程被信号结束或非零退出都视为正常关闭
+	<-t.done
+	<-t.stderrDone
+	t.cmd.Wait()
+	return nil
+}
@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
+	"go.uber.org/zap"
+)
+
+// toolNameSeparator 注册到模型的工具名为 <服务器名>__<工具名>，避免不同服务器的同名工具冲突
+const toolNameSeparator = "__"
+
+// maxToolNameLength 模型函数名的长度上限
+const maxToolNameLength = 64
+
+// ToolName 返回服务器工具注册到模型时的名称，不允许的字符替换为下划线；
+// 超长时截断并以完整名称的哈希作后缀，前缀相同的长名称截断后不会重名
+func ToolName(server, tool string) string {
+	full := server + toolNameSeparator + tool
+	name := []byte(full)
+	for i, b := range name {
+		if !(b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '-') {
+			name[i] = '_'
+		}
+	}
+	if len(name) > maxToolNameLength {
+		sum := sha256.Sum256([]byte(full))
+		suffix := "_" + hex.EncodeToString(sum[:4])
+		name = append(name[:maxToolNameLength-len(suffix)], suffix...)
+	}
+	return string(name)
+}
+
+// RegisterTools 将服务器的工具注册到 registry；服务器声明了资源能力时，
+// 另注册 <服务器名>__list_resources 与 <服务器名>__read_resource 供模型浏览资源。
+// 单个工具注册失败（如与已注册的工具重名）时记录警告后跳过，只有列出工具失败时返回错误
+func RegisterTools(ctx context.Context, registry *tools.Registry, c *Client) error {
+	var list []tools.Tool
+	if c.info.Capabilities.Tools != nil {
+		serverTools, err := c.ListTools(ctx)
+		if err != nil {
+			return fmt.Errorf("mcp: list tools of %s: %w", c.name, err)
+		}
+		for _, t := range serverTools {
+			list = append(list, c.tool(t))
+		}
+	}
+	if c.info.Capabilities.Resources != nil {
+		list = append(list, c.resourceTools()...)
+	}
+	for _, t := range list {
+		if err := registry.Register(t); err != nil {
+			logger.Logger.Warn("skipped mcp tool", zap.String("server", c.name), zap.String("tool", t.Name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (c *Client) tool(t Tool) tools.Tool {
+	description := t.Description
+	if description == "" {
+		description = t.Title
+	}
+	name := t.Name
+	return tools.Tool{
+		Name:          ToolName(c.name, name),
+		Description:   description,
+		Parameters:    parseSchema(c.name, name, t.InputSchema),
+		RawParameters: t.InputSchema,
+		Timeout:       c.timeout,
+		Call: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			result, err := c.CallTool(ctx, name, args)
+			if err != nil {
+				return "", err
+			}
+			if result.IsError {
+				return "", errors.New(result.Text())
+			}
+			return result.Text(), nil
+		},
+	}
+}
+
+// parseSchema 解析工具的参数 Schema 用于校验，包含校验不支持的写法（如 type 为数组）时不做校验
+func parseSchema(server, tool string, raw json.RawMessage) *tools.Schema {
+	if len(raw) == 0 {
+		return nil
+	}
+	var schema tools.Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		logger.Logger.Warn("mcp tool schema not validated",
+			zap.String("server", server),
+			zap.String("tool", tool),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return &schema
+}
+
+func (c *Client) resourceTools() []tools.Tool {
+	return []tools.Tool{
+		{
+			Name:        ToolName(c.name, "list_resources"),
+			Description: fmt.Sprintf("列出 %s 提供的资源（URI、名称与说明）", c.name),
+			Parameters:  &tools.Schema{Type: "object"},
+			Timeout:     c.timeout,
+			Call: func(ctx context.Context, args map[string]interface{}) (string, error) {
+				resources, err := c.ListResources(ctx)
+				if err != nil {
+					return "", err
+				}
+				raw, err := json.Marshal(resources)
+				return string(raw), err
+			},
+		},
+		{
+			Name:        ToolName(c.name, "read_resource"),
+			Description: fmt.Sprintf("读取 %s 提供的资源内容", c.name),
+			Parameters: &tools.Schema{
+				Type: "object",
+				Properties: map[string]*tools.Schema{
+					"uri": {Type: "string", Description: "资源 URI"},
+				},
+				Required: []string{"uri"},
+			},
+			Timeout: c.timeout,
+			Call: func(ctx context.Context, args map[string]interface{}) (string, error) {
+				contents, err := c.ReadResource(ctx, args["uri"].(string))
+				if err != nil {
+					return "", err
+				}
+				texts := make([]string, 0, len(contents))
+				for i := range contents {
+					texts = append(texts, contents[i].text())
+				}
+				return strings.Join(texts, "\n"), nil
+			},
+		},
+	}
+}
+
+// Manager 配置的全部 MCP 服务器连接
+type Manager struct {
+	clients []*Client
+}
+
+// Connect 依次连接配置的服务器；外部服务器不可用不应阻止服务启动，连接失败的服务器记录警告后跳过
+func Connect(ctx context.Context, servers []config.MCPServerConfig) *Manager {
+	m := &Manager{}
+	for _, cfg := range servers {
+		c, err := Dial(ctx, cfg, nil)
+		if err != nil {
+			logger.Logger.Warn("failed to connect mcp server", zap.String("server", cfg.Name), zap.Error(err))
+			continue
+		}
+		logger.Logger.Info("connected mcp server",
+			zap.String("server", cfg.Name),
+			zap.String("serverName", c.info.ServerInfo.Name),
+			zap.String("protocolVersion", c.info.ProtocolVersion),
+		)
+		m.clients = append(m.clients, c)
+	}
+	return m
+}
+
+// Clients 已连接的服务器
+func (m *Manager) Clients() []*Client {
+	return append([]*Client(nil), m.clients...)
+}
+
+// RegisterTools 将全部已连接服务器的工具注册到 registry，单个服务器失败时记录警告后继续
+func (m *Manager) RegisterTools(ctx context.Context, registry *tools.Registry) {
+	for _, c := range m.clients {
+		before := registry.Len()
+		if err := RegisterTools(ctx, registry, c); err != nil {
+			logger.Logger.Warn("failed to register mcp tools", zap.String("server", c.name), zap.Error(err))
+		}
+		logger.Logger.Info("registered mcp tools", zap.String("server", c.name), zap.Int("count", registry.Len()-before))
+	}
+}
+
+// Close 关闭全部连接
+func (m *Manager) Close() {
+	for _, c := range m.clients {
+		if err := c.Close(); err != nil {
+			logger.Logger.Warn("failed to close mcp server", zap.String("server", c.name), zap.Error(err))
+		}
+	}
+}
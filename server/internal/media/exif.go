@@ -0,0 +1,379 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ImageInfo 图片尺寸与 EXIF 信息
+type ImageInfo struct {
+	Format string
+	Width  int
+	Height int
+	// EXIF 按标签名索引的常用 EXIF 字段，没有 EXIF 时为 nil
+	EXIF map[string]interface{}
+}
+
+// maxEXIFSize EXIF 数据上限，JPEG APP1 段本身不超过 64KiB
+const maxEXIFSize = 1 << 20
+
+var errInvalidEXIF = errors.New("invalid exif data")
+
+// ReadImageInfo 读取图片尺寸与 EXIF，keepGPS 为 false 时丢弃 GPS 信息。
+// EXIF 损坏不视为错误，只是不返回 EXIF。
+func ReadImageInfo(path string, keepGPS bool) (*ImageInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image header: %w", err)
+	}
+	info := &ImageInfo{Format: format, Width: cfg.Width, Height: cfg.Height}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var raw []byte
+	switch format {
+	case "jpeg":
+		raw, err = jpegEXIF(bufio.NewReader(f))
+	case "png":
+		raw, err = pngEXIF(f)
+	}
+	if err == nil && raw != nil {
+		info.EXIF, err = ParseEXIF(raw, keepGPS)
+	}
+	if err != nil && !errors.Is(err, errInvalidEXIF) && !errors.Is(err, io.ErrUnexpectedEOF) && err != io.EOF {
+		return nil, err
+	}
+	return info, nil
+}
+
+// jpegEXIF 在 JPEG 的 APP1 段中查找 EXIF 数据，遇到图像数据前仍未找到时返回 nil
+func jpegEXIF(r *bufio.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil {
+		return nil, err
+	}
+	if soi != [2]byte{0xFF, 0xD8} {
+		return nil, errInvalidEXIF
+	}
+	for {
+		var hdr [4]byte
+		if _, err := io.ReadFull(r, hdr[:2]); err != nil {
+			return nil, err
+		}
+		if hdr[0] != 0xFF {
+			return nil, errInvalidEXIF
+		}
+		marker := hdr[1]
+		// 填充字节与无长度的标记
+		if marker == 0xFF || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return nil, nil
+		}
+		if _, err := io.ReadFull(r, hdr[2:]); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(hdr[2:])) - 2
+		if length < 0 {
+			return nil, errInvalidEXIF
+		}
+		if marker == 0xE1 {
+			seg := make([]byte, length)
+			if _, err := io.ReadFull(r, seg); err != nil {
+				return nil, err
+			}
+			if bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+				return seg[6:], nil
+			}
+			continue // XMP 等其它 APP1 段
+		}
+		if _, err := r.Discard(length); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pngEXIF 读取 PNG 的 eXIf 数据块
+func pngEXIF(r io.ReadSeeker) ([]byte, error) {
+	if _, err := r.Seek(8, io.SeekStart); err != nil {
+		return nil, err
+	}
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint32(hdr[:4])
+		switch string(hdr[4:]) {
+		case "eXIf":
+			if length > maxEXIFSize {
+				return nil, errInvalidEXIF
+			}
+			data := make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, err
+			}
+			return data, nil
+		case "IEND":
+			return nil, nil
+		}
+		// 跳过数据与 CRC
+		if _, err := r.Seek(int64(length)+4, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// EXIF 标签指针与常用标签名
+const (
+	tagExifIFD = 0x8769
+	tagGPSIFD  = 0x8825
+)
+
+var exifTagNames = map[uint16]string{
+	0x010E: "ImageDescription",
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0112: "Orientation",
+	0x011A: "XResolution",
+	0x011B: "YResolution",
+	0x0128: "ResolutionUnit",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013B: "Artist",
+	0x8298: "Copyright",
+	0x829A: "ExposureTime",
+	0x829D: "FNumber",
+	0x8822: "ExposureProgram",
+	0x8827: "ISOSpeedRatings",
+	0x9003: "DateTimeOriginal",
+	0x9004: "DateTimeDigitized",
+	0x9010: "OffsetTime",
+	0x9011: "OffsetTimeOriginal",
+	0x9201: "ShutterSpeedValue",
+	0x9202: "ApertureValue",
+	0x9204: "ExposureBiasValue",
+	0x9207: "MeteringMode",
+	0x9209: "Flash",
+	0x920A: "FocalLength",
+	0xA001: "ColorSpace",
+	0xA002: "PixelXDimension",
+	0xA003: "PixelYDimension",
+	0xA402: "ExposureMode",
+	0xA403: "WhiteBalance",
+	0xA405: "FocalLengthIn35mmFilm",
+	0xA433: "LensMake",
+	0xA434: "LensModel",
+}
+
+var gpsTagNames = map[uint16]string{
+	0x0001: "GPSLatitudeRef",
+	0x0002: "GPSLatitude",
+	0x0003: "GPSLongitudeRef",
+	0x0004: "GPSLongitude",
+	0x0005: "GPSAltitudeRef",
+	0x0006: "GPSAltitude",
+	0x0007: "GPSTimeStamp",
+	0x001D: "GPSDateStamp",
+}
+
+// ParseEXIF 解析 TIFF 结构的 EXIF 数据，只保留已知标签；keepGPS 为 false 时不读取 GPS IFD。
+// GPS 经纬度转换为带符号的十进制度数。
+func ParseEXIF(data []byte, keepGPS bool) (map[string]interface{}, error) {
+	if len(data) < 8 || len(data) > maxEXIFSize {
+		return nil, errInvalidEXIF
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, errInvalidEXIF
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil, errInvalidEXIF
+	}
+
+	p := &exifParser{data: data, order: order, visited: map[uint32]bool{}}
+	out := map[string]interface{}{}
+	pointers := p.readIFD(order.Uint32(data[4:]), exifTagNames, out)
+	if off, ok := pointers[tagExifIFD]; ok {
+		p.readIFD(off, exifTagNames, out)
+	}
+	if off, ok := pointers[tagGPSIFD]; ok && keepGPS {
+		gps := map[string]interface{}{}
+		p.readIFD(off, gpsTagNames, gps)
+		mergeGPS(out, gps)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+type exifParser struct {
+	data    []byte
+	order   binary.ByteOrder
+	visited map[uint32]bool
+}
+
+// readIFD 读取一个 IFD 中的已知标签写入 out，返回子 IFD 指针；越界或重复访问的 IFD 直接忽略
+func (p *exifParser) readIFD(offset uint32, names map[uint16]string, out map[string]interface{}) map[uint16]uint32 {
+	pointers := map[uint16]uint32{}
+	if p.visited[offset] || int(offset)+2 > len(p.data) {
+		return pointers
+	}
+	p.visited[offset] = true
+
+	count := int(p.order.Uint16(p.data[offset:]))
+	entries := p.data[offset+2:]
+	for i := 0; i < count && (i+1)*12 <= len(entries); i++ {
+		e := entries[i*12 : (i+1)*12]
+		tag := p.order.Uint16(e)
+		typ := p.order.Uint16(e[2:])
+		n := p.order.Uint32(e[4:])
+		if tag == tagExifIFD || tag == tagGPSIFD {
+			pointers[tag] = p.order.Uint32(e[8:])
+			continue
+		}
+		name, ok := names[tag]
+		if !ok {
+			continue
+		}
+		if v := p.value(typ, n, e[8:12]); v != nil {
+			out[name] = v
+		}
+	}
+	return pointers
+}
+
+// exifTypeSizes 支持的 EXIF 数据类型及单个值字节数，UNDEFINED 等类型多为厂商私有数据，不解析
+var exifTypeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 9: 4, 10: 8}
+
+// value 按类型解码标签值，数量为 1 时返回标量，否则返回切片；不支持的类型返回 nil
+func (p *exifParser) value(typ uint16, n uint32, inline []byte) interface{} {
+	size, ok := exifTypeSizes[typ]
+	if !ok || n == 0 || n > 1024 {
+		return nil
+	}
+	// 不超过 4 字节的值直接存放在条目中，否则为数据偏移
+	total := size * n
+	raw := inline[:min32(total, 4)]
+	if total > 4 {
+		off := p.order.Uint32(inline)
+		if uint64(off)+uint64(total) > uint64(len(p.data)) {
+			return nil
+		}
+		raw = p.data[off : off+total]
+	}
+
+	if typ == 2 {
+		s := strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+
+	vals := make([]interface{}, 0, n)
+	for i := uint32(0); i < n; i++ {
+		b := raw[i*size:]
+		switch typ {
+		case 1:
+			vals = append(vals, int64(b[0]))
+		case 3:
+			vals = append(vals, int64(p.order.Uint16(b)))
+		case 4:
+			vals = append(vals, int64(p.order.Uint32(b)))
+		case 9:
+			vals = append(vals, int64(int32(p.order.Uint32(b))))
+		case 5:
+			num, den := p.order.Uint32(b), p.order.Uint32(b[4:])
+			if den == 0 {
+				return nil
+			}
+			vals = append(vals, roundRational(float64(num)/float64(den)))
+		case 10:
+			num, den := int32(p.order.Uint32(b)), int32(p.order.Uint32(b[4:]))
+			if den == 0 {
+				return nil
+			}
+			vals = append(vals, roundRational(float64(num)/float64(den)))
+		}
+	}
+	return scalarOrSlice(vals)
+}
+
+func min32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func scalarOrSlice(vals []interface{}) interface{} {
+	if len(vals) == 1 {
+		return vals[0]
+	}
+	return vals
+}
+
+func roundRational(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// mergeGPS 将 GPS 度分秒与方向合并为十进制经纬度
+func mergeGPS(out, gps map[string]interface{}) {
+	if lat, ok := dmsToDegrees(gps["GPSLatitude"], gps["GPSLatitudeRef"], "S"); ok {
+		out["GPSLatitude"] = lat
+	}
+	if lon, ok := dmsToDegrees(gps["GPSLongitude"], gps["GPSLongitudeRef"], "W"); ok {
+		out["GPSLongitude"] = lon
+	}
+	if alt, ok := gps["GPSAltitude"].(float64); ok {
+		// GPSAltitudeRef 为 1 表示海平面以下
+		if ref, _ := gps["GPSAltitudeRef"].(int64); ref == 1 {
+			alt = -alt
+		}
+		out["GPSAltitude"] = alt
+	}
+	if date, ok := gps["GPSDateStamp"].(string); ok {
+		out["GPSDateStamp"] = date
+	}
+}
+
+func dmsToDegrees(v, ref interface{}, negativeRef string) (float64, bool) {
+	dms, ok := v.([]interface{})
+	if !ok || len(dms) != 3 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i, x := range dms {
+		if parts[i], ok = x.(float64); !ok {
+			return 0, false
+		}
+	}
+	deg := parts[0] + parts[1]/60 + parts[2]/3600
+	if r, _ := ref.(string); r == negativeRef {
+		deg = -deg
+	}
+	return math.Round(deg*1e6) / 1e6, true
+}
@@ -0,0 +1,80 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FakeTranscoder 不调用 ffmpeg，直接写出固定内容的播放列表与分片，供测试使用
+type FakeTranscoder struct {
+	// Err 非 nil 时 TranscodeHLS 直接返回该错误
+	Err error
+
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (f *FakeTranscoder) TranscodeHLS(ctx context.Context, inputPath, outputDir string, renditions []Rendition) error {
+	f.mu.Lock()
+	f.inputs = append(f.inputs, inputPath)
+	f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+
+	for _, r := range renditions {
+		dir := filepath.Join(outputDir, r.Name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		playlist := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:6.0,\nseg_0000.ts\n#EXT-X-ENDLIST\n"
+		if err := os.WriteFile(filepath.Join(dir, "index.m3u8"), []byte(playlist), 0o644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "seg_0000.ts"), []byte(fmt.Sprintf("segment %s", r.Name)), 0o644); err != nil {
+			return err
+		}
+	}
+	return WriteMasterPlaylist(outputDir, renditions)
+}
+
+// Inputs 返回已转码的输入文件路径
+func (f *FakeTranscoder) Inputs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.inputs...)
+}
+
+// FakeFrameExtractor 不调用 ffmpeg，写出固定尺寸的纯色 PNG 作为封面，供测试使用
+type FakeFrameExtractor struct {
+	// Err 非 nil 时 ExtractFrame 直接返回该错误
+	Err error
+	// Width、Height 封面尺寸，为 0 时使用 1280x720
+	Width, Height int
+}
+
+func (f *FakeFrameExtractor) ExtractFrame(ctx context.Context, inputPath, outputPath string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	w, h := f.Width, f.Height
+	if w == 0 || h == 0 {
+		w, h = 1280, 720
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return png.Encode(out, img)
+}
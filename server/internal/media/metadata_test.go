@@ -0,0 +1,202 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ifdEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte // 超过 4 字节时写入数据区
+}
+
+// buildEXIF 生成小端序 TIFF：IFD0 -> Exif IFD、GPS IFD
+func buildEXIF(ifd0, exifIFD, gpsIFD []ifdEntry) []byte {
+	le := binary.LittleEndian
+	ifdSize := func(entries []ifdEntry) int { return 2 + 12*len(entries) + 4 }
+	off0 := 8
+	offExif := off0 + ifdSize(ifd0) + 12*2 // IFD0 额外包含两个指针条目
+	offGPS := offExif + ifdSize(exifIFD)
+	dataOff := offGPS + ifdSize(gpsIFD)
+
+	var buf, data bytes.Buffer
+	buf.WriteString("II")
+	binary.Write(&buf, le, uint16(42))
+	binary.Write(&buf, le, uint32(off0))
+
+	writeIFD := func(entries []ifdEntry) {
+		binary.Write(&buf, le, uint16(len(entries)))
+		for _, e := range entries {
+			binary.Write(&buf, le, e.tag)
+			binary.Write(&buf, le, e.typ)
+			binary.Write(&buf, le, e.count)
+			if len(e.data) > 4 {
+				binary.Write(&buf, le, uint32(dataOff+data.Len()))
+				data.Write(e.data)
+			} else {
+				var v [4]byte
+				copy(v[:], e.data)
+				buf.Write(v[:])
+			}
+		}
+		binary.Write(&buf, le, uint32(0))
+	}
+	u32 := func(v uint32) []byte { b := make([]byte, 4); le.PutUint32(b, v); return b }
+	ifd0 = append(ifd0,
+		ifdEntry{tag: tagExifIFD, typ: 4, count: 1, data: u32(uint32(offExif))},
+		ifdEntry{tag: tagGPSIFD, typ: 4, count: 1, data: u32(uint32(offGPS))},
+	)
+	writeIFD(ifd0)
+	writeIFD(exifIFD)
+	writeIFD(gpsIFD)
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+func rationals(vals ...uint32) []byte {
+	b := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(b[i*4:], v)
+	}
+	return b
+}
+
+func sampleEXIF() []byte {
+	return buildEXIF(
+		[]ifdEntry{
+			{tag: 0x010F, typ: 2, count: 6, data: []byte("Canon\x00")},
+			{tag: 0x0112, typ: 3, count: 1, data: []byte{6, 0}},
+			{tag: 0x9999, typ: 3, count: 1, data: []byte{1, 0}}, // 未知标签
+		},
+		[]ifdEntry{
+			{tag: 0x829D, typ: 5, count: 1, data: rationals(28, 10)},
+			{tag: 0x8827, typ: 3, count: 1, data: []byte{200, 0}},
+		},
+		[]ifdEntry{
+			{tag: 0x0001, typ: 2, count: 2, data: []byte("N\x00")},
+			{tag: 0x0002, typ: 5, count: 3, data: rationals(37, 1, 46, 1, 3000, 100)},
+			{tag: 0x0003, typ: 2, count: 2, data: []byte("W\x00")},
+			{tag: 0x0004, typ: 5, count: 3, data: rationals(122, 1, 25, 1, 0, 1)},
+		},
+	)
+}
+
+func TestParseEXIFStripsGPSByDefault(t *testing.T) {
+	exif, err := ParseEXIF(sampleEXIF(), false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Make":            "Canon",
+		"Orientation":     int64(6),
+		"FNumber":         2.8,
+		"ISOSpeedRatings": int64(200),
+	}, exif)
+}
+
+func TestParseEXIFKeepsGPS(t *testing.T) {
+	exif, err := ParseEXIF(sampleEXIF(), true)
+	require.NoError(t, err)
+	assert.InDelta(t, 37.775, exif["GPSLatitude"], 1e-6)
+	assert.InDelta(t, -122.416667, exif["GPSLongitude"], 1e-6)
+	assert.NotContains(t, exif, "GPSLatitudeRef")
+}
+
+func TestParseEXIFRejectsGarbage(t *testing.T) {
+	_, err := ParseEXIF([]byte("not a tiff header"), false)
+	assert.ErrorIs(t, err, errInvalidEXIF)
+
+	// IFD 偏移越界时忽略而不是崩溃
+	truncated := sampleEXIF()[:40]
+	_, err = ParseEXIF(truncated, true)
+	assert.NoError(t, err)
+}
+
+func TestReadImageInfoFromJPEG(t *testing.T) {
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 40, 30)), nil))
+	exif := sampleEXIF()
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(2+6+len(exif)))
+	app1 = append(append(app1, "Exif\x00\x00"...), exif...)
+
+	jpg := append([]byte{0xFF, 0xD8}, app1...)
+	jpg = append(jpg, encoded.Bytes()[2:]...)
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	require.NoError(t, os.WriteFile(path, jpg, 0o644))
+
+	info, err := ReadImageInfo(path, false)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", info.Format)
+	assert.Equal(t, 40, info.Width)
+	assert.Equal(t, 30, info.Height)
+	assert.Equal(t, "Canon", info.EXIF["Make"])
+	assert.NotContains(t, info.EXIF, "GPSLatitude")
+}
+
+func TestReadImageInfoWithoutEXIF(t *testing.T) {
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil))
+	path := filepath.Join(t.TempDir(), "plain.jpg")
+	require.NoError(t, os.WriteFile(path, encoded.Bytes(), 0o644))
+
+	info, err := ReadImageInfo(path, false)
+	require.NoError(t, err)
+	assert.Nil(t, info.EXIF)
+}
+
+type stubOutputRunner struct {
+	out  []byte
+	args []string
+}
+
+func (r *stubOutputRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.args = append([]string{name}, args...)
+	return r.out, nil
+}
+
+func TestFFprobeProberVideo(t *testing.T) {
+	runner := &stubOutputRunner{out: []byte(`{
+		"streams": [
+			{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080},
+			{"codec_type": "audio", "codec_name": "aac", "sample_rate": "48000", "channels": 2}
+		],
+		"format": {"duration": "12.480000", "bit_rate": "5120000"}
+	}`)}
+
+	info, err := NewFFprobeProber("ffprobe", runner).Probe(context.Background(), "/tmp/in.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, &MediaInfo{
+		Duration: 12.48, Bitrate: 5120000,
+		Width: 1920, Height: 1080, VideoCodec: "h264",
+		AudioCodec: "aac", SampleRate: 48000, Channels: 2,
+	}, info)
+	assert.Equal(t, "/tmp/in.mp4", runner.args[len(runner.args)-1])
+}
+
+func TestParseFFprobeAudioWithCoverArt(t *testing.T) {
+	info, err := ParseFFprobe([]byte(`{
+		"streams": [
+			{"codec_type": "audio", "codec_name": "mp3", "sample_rate": "44100", "channels": 2, "duration": "201.3"},
+			{"codec_type": "video", "codec_name": "mjpeg", "width": 500, "height": 500, "disposition": {"attached_pic": 1}}
+		],
+		"format": {"duration": "N/A", "bit_rate": "320000"}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "", info.VideoCodec)
+	assert.Equal(t, 0, info.Width)
+	assert.Equal(t, 201.3, info.Duration)
+	assert.Equal(t, 44100, info.SampleRate)
+
+	_, err = ParseFFprobe([]byte(`{"streams": [], "format": {}}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// MediaInfo 音视频文件的基本信息，取首个视频流与首个音频流
+type MediaInfo struct {
+	Duration   float64 // 秒
+	Bitrate    int64   // 总码率 bit/s
+	Width      int
+	Height     int
+	VideoCodec string
+	AudioCodec string
+	SampleRate int
+	Channels   int
+}
+
+// Prober 读取本地音视频文件的媒体信息
+type Prober interface {
+	Probe(ctx context.Context, inputPath string) (*MediaInfo, error)
+}
+
+// FFprobeProber 基于 ffprobe JSON 输出的实现
+type FFprobeProber struct {
+	ffprobePath string
+	runner      OutputRunner
+}
+
+// NewFFprobeProber 创建 ffprobe 探测器，runner 为 nil 时使用 ExecRunner
+func NewFFprobeProber(ffprobePath string, runner OutputRunner) *FFprobeProber {
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	return &FFprobeProber{ffprobePath: ffprobePath, runner: runner}
+}
+
+func (p *FFprobeProber) Probe(ctx context.Context, inputPath string) (*MediaInfo, error) {
+	out, err := p.runner.Output(ctx, p.ffprobePath,
+		"-v", "error", "-print_format", "json", "-show_format", "-show_streams", inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe media: %w", err)
+	}
+	return ParseFFprobe(out)
+}
+
+type ffprobeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		Width      int    `json:"width"`
+		Height     int    `json:"height"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+		Duration   string `json:"duration"`
+		// 封面图等附加图片也以视频流形式出现
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// ParseFFprobe 解析 ffprobe -print_format json -show_format -show_streams 的输出
+func ParseFFprobe(out []byte) (*MediaInfo, error) {
+	var probe ffprobeOutput
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &MediaInfo{
+		Duration: parseFloat(probe.Format.Duration),
+		Bitrate:  int64(parseFloat(probe.Format.BitRate)),
+	}
+	for _, s := range probe.Streams {
+		switch {
+		case s.CodecType == "video" && s.Disposition.AttachedPic == 0 && info.VideoCodec == "":
+			info.VideoCodec = s.CodecName
+			info.Width, info.Height = s.Width, s.Height
+		case s.CodecType == "audio" && info.AudioCodec == "":
+			info.AudioCodec = s.CodecName
+			info.SampleRate = int(parseFloat(s.SampleRate))
+			info.Channels = s.Channels
+		default:
+			continue
+		}
+		if info.Duration == 0 {
+			info.Duration = parseFloat(s.Duration)
+		}
+	}
+	if info.VideoCodec == "" && info.AudioCodec == "" {
+		return nil, fmt.Errorf("no audio or video stream found")
+	}
+	return info, nil
+}
+
+// parseFloat ffprobe 以字符串输出数值，缺失时为 "N/A"
+func parseFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,192 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+
+	// 注册缩略图支持的源图片格式
+	_ "image/gif"
+	_ "image/png"
+)
+
+// MaxSourcePixels 生成缩略图时允许解码的最大像素数，防止解压炸弹耗尽内存
+const MaxSourcePixels = 100_000_000
+
+// ErrImageTooLarge 源图片像素数超过 MaxSourcePixels
+var ErrImageTooLarge = errors.New("image dimensions too large")
+
+// ThumbnailSize 缩略图规格，按长边等比缩放
+type ThumbnailSize struct {
+	Name    string
+	MaxEdge int
+}
+
+// Thumbnail 生成的缩略图文件
+type Thumbnail struct {
+	Name   string
+	Width  int
+	Height int
+	Path   string
+	Size   int64
+}
+
+// FrameExtractor 从本地视频文件中截取一帧作为封面，输出为 PNG
+type FrameExtractor interface {
+	ExtractFrame(ctx context.Context, inputPath, outputPath string) error
+}
+
+// FFmpegFrameExtractor 基于 ffmpeg thumbnail 滤镜在开头若干帧中挑选有代表性的一帧，避免取到黑屏
+type FFmpegFrameExtractor struct {
+	ffmpegPath string
+	runner     Runner
+}
+
+// NewFFmpegFrameExtractor 创建 ffmpeg 截帧器，runner 为 nil 时使用 ExecRunner
+func NewFFmpegFrameExtractor(ffmpegPath string, runner Runner) *FFmpegFrameExtractor {
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	return &FFmpegFrameExtractor{ffmpegPath: ffmpegPath, runner: runner}
+}
+
+func (e *FFmpegFrameExtractor) ExtractFrame(ctx context.Context, inputPath, outputPath string) error {
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", inputPath,
+		"-map", "0:v:0",
+		"-vf", "thumbnail=50",
+		"-frames:v", "1",
+		outputPath,
+	}
+	if err := e.runner.Run(ctx, e.ffmpegPath, args...); err != nil {
+		return fmt.Errorf("failed to extract poster frame: %w", err)
+	}
+	return nil
+}
+
+// DecodeImage 解码 JPEG/PNG/GIF 图片（GIF 取第一帧），解码前先按文件头检查尺寸
+func DecodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxSourcePixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// WriteThumbnails 按各规格生成 JPEG 缩略图写入 outputDir/<name>.jpg。
+// 小于规格的图片保持原尺寸不放大，透明区域以白色填充。
+func WriteThumbnails(src image.Image, outputDir string, sizes []ThumbnailSize, quality int) ([]Thumbnail, error) {
+	// 统一转为 RGBA 并铺白底，缩放时直接读取像素数组
+	b := src.Bounds()
+	base := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(base, base.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(base, base.Bounds(), src, b.Min, draw.Over)
+
+	thumbs := make([]Thumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		w, h := fitWithin(b.Dx(), b.Dy(), size.MaxEdge)
+		path := filepath.Join(outputDir, size.Name+".jpg")
+		n, err := writeJPEG(path, resizeBox(base, w, h), quality)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write thumbnail %s: %w", size.Name, err)
+		}
+		thumbs = append(thumbs, Thumbnail{Name: size.Name, Width: w, Height: h, Path: path, Size: n})
+	}
+	return thumbs, nil
+}
+
+// fitWithin 等比缩放使长边不超过 maxEdge，不放大
+func fitWithin(w, h, maxEdge int) (int, int) {
+	if w <= maxEdge && h <= maxEdge {
+		return w, h
+	}
+	if w >= h {
+		return maxEdge, max1(h * maxEdge / w)
+	}
+	return max1(w * maxEdge / h), maxEdge
+}
+
+func max1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
+
+// resizeBox 按区域平均缩小图片，目标尺寸与原图相同时直接返回原图
+func resizeBox(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if w == sw && h == sh {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, (y+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, (x+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0], d[1], d[2], d[3] = uint8(r/n), uint8(g/n), uint8(bl/n), uint8(a/n)
+		}
+	}
+	return dst
+}
+
+func writeJPEG(path string, img image.Image, quality int) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: quality}); err != nil {
+		f.Close()
+		return 0, err
+	}
+	info, err := f.Stat()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
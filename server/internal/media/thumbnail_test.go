@@ -0,0 +1,109 @@
+package media
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSizes = []ThumbnailSize{{Name: "small", MaxEdge: 160}, {Name: "large", MaxEdge: 640}}
+
+func TestWriteThumbnailsKeepsAspectRatio(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 1000))
+	out := t.TempDir()
+
+	thumbs, err := WriteThumbnails(src, out, testSizes, 80)
+	require.NoError(t, err)
+	require.Len(t, thumbs, 2)
+
+	assert.Equal(t, Thumbnail{Name: "small", Width: 64, Height: 160, Path: filepath.Join(out, "small.jpg"), Size: thumbs[0].Size}, thumbs[0])
+	assert.Equal(t, 256, thumbs[1].Width)
+	assert.Equal(t, 640, thumbs[1].Height)
+
+	f, err := os.Open(thumbs[0].Path)
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 64, cfg.Width)
+	assert.Equal(t, 160, cfg.Height)
+}
+
+func TestWriteThumbnailsDoesNotUpscale(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 120, 90))
+
+	thumbs, err := WriteThumbnails(src, t.TempDir(), testSizes, 80)
+	require.NoError(t, err)
+	for _, th := range thumbs {
+		assert.Equal(t, 120, th.Width, th.Name)
+		assert.Equal(t, 90, th.Height, th.Name)
+	}
+}
+
+func TestWriteThumbnailsFlattensTransparency(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 320, 320))
+	out := t.TempDir()
+
+	thumbs, err := WriteThumbnails(src, out, testSizes[:1], 90)
+	require.NoError(t, err)
+
+	img, err := DecodeImage(thumbs[0].Path)
+	require.NoError(t, err)
+	r, g, b, _ := img.At(80, 80).RGBA()
+	assert.Greater(t, r>>8, uint32(240))
+	assert.Greater(t, g>>8, uint32(240))
+	assert.Greater(t, b>>8, uint32(240))
+}
+
+func TestResizeBoxAverages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.RGBA{R: 200, A: 255})
+	src.Set(1, 0, color.RGBA{B: 100, A: 255})
+
+	dst := resizeBox(src, 1, 1)
+	assert.Equal(t, color.RGBA{R: 100, B: 50, A: 255}, dst.RGBAAt(0, 0))
+}
+
+func TestDecodeImageRejectsHugeDimensions(t *testing.T) {
+	// 仅包含文件头的 GIF，声明 65535x65535 的画布
+	header := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+	path := filepath.Join(t.TempDir(), "bomb.gif")
+	require.NoError(t, os.WriteFile(path, header, 0o644))
+
+	_, err := DecodeImage(path)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestFFmpegFrameExtractorArgs(t *testing.T) {
+	runner := &recordingRunner{}
+	e := NewFFmpegFrameExtractor("/usr/bin/ffmpeg", runner)
+
+	require.NoError(t, e.ExtractFrame(context.Background(), "/tmp/in.mp4", "/tmp/poster.png"))
+
+	require.Len(t, runner.calls, 1)
+	call := runner.calls[0]
+	assert.Equal(t, "/usr/bin/ffmpeg", call[0])
+	assert.Contains(t, call, "thumbnail=50")
+	assert.Equal(t, "/tmp/poster.png", call[len(call)-1])
+}
+
+func TestFakeFrameExtractor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "poster.png")
+	require.NoError(t, (&FakeFrameExtractor{Width: 64, Height: 48}).ExtractFrame(context.Background(), "in.mp4", path))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, err := png.DecodeConfig(f)
+	require.NoError(t, err)
+	assert.Equal(t, 64, cfg.Width)
+	assert.Equal(t, 48, cfg.Height)
+}
@@ -0,0 +1,137 @@
+// Package media 封装音视频处理工具（ffmpeg 等），处理过程只读写本地文件，不依赖对象存储
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+)
+
+// MasterPlaylist HLS 主播放列表文件名
+const MasterPlaylist = "master.m3u8"
+
+// Rendition HLS 输出的一路码率
+type Rendition struct {
+	Name      string
+	Height    int
+	VideoKbps int
+	AudioKbps int
+}
+
+// Transcoder 将本地视频文件转为 HLS。
+// 输出目录结构：<outputDir>/master.m3u8，<outputDir>/<rendition>/index.m3u8 及分片。
+type Transcoder interface {
+	TranscodeHLS(ctx context.Context, inputPath, outputDir string, renditions []Rendition) error
+}
+
+// Runner 执行外部命令，测试中可替换为不启动进程的实现
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) error
+}
+
+// OutputRunner 执行外部命令并返回标准输出
+type OutputRunner interface {
+	Output(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner 使用 os/exec 执行命令，失败时附带命令的错误输出
+type ExecRunner struct{}
+
+func (ExecRunner) Run(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, tail(out, 2048))
+	}
+	return nil
+}
+
+func (ExecRunner) Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, tail(stderr.Bytes(), 2048))
+	}
+	return out, nil
+}
+
+// FFmpegTranscoder 基于 ffmpeg 的 HLS 转码实现，每路码率单独执行一次 ffmpeg
+type FFmpegTranscoder struct {
+	ffmpegPath     string
+	segmentSeconds int
+	runner         Runner
+}
+
+// NewFFmpegTranscoder 创建 ffmpeg 转码器，runner 为 nil 时使用 ExecRunner
+func NewFFmpegTranscoder(cfg config.TranscodeConfig, runner Runner) *FFmpegTranscoder {
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+	return &FFmpegTranscoder{
+		ffmpegPath:     cfg.FFmpegPath,
+		segmentSeconds: cfg.SegmentSeconds,
+		runner:         runner,
+	}
+}
+
+// TranscodeHLS 依次生成各路码率的分片与播放列表，最后写入主播放列表
+func (t *FFmpegTranscoder) TranscodeHLS(ctx context.Context, inputPath, outputDir string, renditions []Rendition) error {
+	for _, r := range renditions {
+		dir := filepath.Join(outputDir, r.Name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create rendition dir: %w", err)
+		}
+		if err := t.runner.Run(ctx, t.ffmpegPath, t.renditionArgs(inputPath, dir, r)...); err != nil {
+			return fmt.Errorf("failed to transcode rendition %s: %w", r.Name, err)
+		}
+	}
+	return WriteMasterPlaylist(outputDir, renditions)
+}
+
+func (t *FFmpegTranscoder) renditionArgs(inputPath, dir string, r Rendition) []string {
+	kbps := strconv.Itoa(r.VideoKbps) + "k"
+	return []string{
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", inputPath,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		// 宽度取偶数以满足 H.264 要求
+		"-vf", fmt.Sprintf("scale=-2:%d", r.Height),
+		"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main",
+		"-b:v", kbps, "-maxrate", kbps, "-bufsize", strconv.Itoa(r.VideoKbps*2) + "k",
+		"-c:a", "aac", "-ac", "2", "-b:a", strconv.Itoa(r.AudioKbps) + "k",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(t.segmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, "seg_%04d.ts"),
+		filepath.Join(dir, "index.m3u8"),
+	}
+}
+
+// WriteMasterPlaylist 写入引用各路码率播放列表的主播放列表
+func WriteMasterPlaylist(outputDir string, renditions []Rendition) error {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range renditions {
+		bandwidth := (r.VideoKbps + r.AudioKbps) * 1000
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,NAME=\"%s\"\n%s/index.m3u8\n", bandwidth, r.Name, r.Name)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, MasterPlaylist), []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write master playlist: %w", err)
+	}
+	return nil
+}
+
+func tail(b []byte, n int) string {
+	if len(b) > n {
+		b = b[len(b)-n:]
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,54 @@
+package media
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingRunner struct {
+	calls [][]string
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) error {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return nil
+}
+
+func TestFFmpegTranscoderWritesMasterPlaylist(t *testing.T) {
+	runner := &recordingRunner{}
+	tr := NewFFmpegTranscoder(config.TranscodeConfig{FFmpegPath: "ffmpeg", SegmentSeconds: 4}, runner)
+	out := t.TempDir()
+	renditions := []Rendition{
+		{Name: "720p", Height: 720, VideoKbps: 2800, AudioKbps: 128},
+		{Name: "480p", Height: 480, VideoKbps: 1400, AudioKbps: 96},
+	}
+
+	require.NoError(t, tr.TranscodeHLS(context.Background(), "/tmp/in.mkv", out, renditions))
+
+	require.Len(t, runner.calls, 2)
+	assert.Equal(t, "ffmpeg", runner.calls[0][0])
+	assert.Contains(t, runner.calls[0], "scale=-2:720")
+	assert.Contains(t, runner.calls[1], filepath.Join(out, "480p", "index.m3u8"))
+
+	master, err := os.ReadFile(filepath.Join(out, MasterPlaylist))
+	require.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\n#EXT-X-VERSION:3\n"+
+		"#EXT-X-STREAM-INF:BANDWIDTH=2928000,NAME=\"720p\"\n720p/index.m3u8\n"+
+		"#EXT-X-STREAM-INF:BANDWIDTH=1496000,NAME=\"480p\"\n480p/index.m3u8\n", string(master))
+}
+
+func TestFakeTranscoder(t *testing.T) {
+	fake := &FakeTranscoder{}
+	out := t.TempDir()
+
+	require.NoError(t, fake.TranscodeHLS(context.Background(), "in.mov", out, []Rendition{{Name: "480p", Height: 480}}))
+	assert.FileExists(t, filepath.Join(out, MasterPlaylist))
+	assert.FileExists(t, filepath.Join(out, "480p", "seg_0000.ts"))
+	assert.Equal(t, []string{"in.mov"}, fake.Inputs())
+}
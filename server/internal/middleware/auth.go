@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/auth"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	currentUserKey   = "auth.current_user"
+	currentClaimsKey = "auth.current_claims"
+
+	accessTokenQueryParam = "access_token"
+)
+
+// AuthMiddleware 校验 Bearer 访问令牌，并将令牌声明和对应用户写入上下文
+func AuthMiddleware(sessions *services.SessionService) gin.HandlerFunc {
+	userService := services.NewUserService()
+
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		// <video>/<img> 等标签无法携带请求头，只读请求允许通过 access_token 查询参数传递令牌
+		if authHeader == "" && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+			if token := c.Query(accessTokenQueryParam); token != "" {
+				authHeader = "Bearer " + token
+			}
+		}
+		if authHeader == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Authorization header is required",
+			})
+			return
+		}
+
+		tokenParts := strings.Split(authHeader, " ")
+		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid authorization header format",
+			})
+			return
+		}
+
+		claims, err := sessions.VerifyAccessToken(tokenParts[1])
+		if err != nil {
+			msg := "Invalid token"
+			switch {
+			case errors.Is(err, auth.ErrExpiredToken):
+				msg = "Token expired"
+			case errors.Is(err, services.ErrSessionRevoked):
+				msg = "Session revoked"
+			case !errors.Is(err, auth.ErrInvalidToken):
+				logger.Logger.Error("failed to verify access token", zap.Error(err))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"error": "Internal server error",
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": msg,
+			})
+			return
+		}
+
+		uid, _ := claims.UID()
+		user, err := userService.GetUserByUID(uid)
+		if err != nil {
+			logger.Logger.Warn("token subject not resolvable",
+				zap.Int("uid", uid),
+				zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid token",
+			})
+			return
+		}
+
+		c.Set(currentClaimsKey, claims)
+		SetCurrentUser(c, user)
+		c.Next()
+	}
+}
+
+// SetCurrentUser 将已鉴权的用户写入上下文，处理器测试可借此绕过令牌校验
+func SetCurrentUser(c *gin.Context, user *models.User) {
+	c.Set(currentUserKey, user)
+}
+
+// CurrentUser 返回 AuthMiddleware 解析出的当前用户
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	v, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := v.(*models.User)
+	return user, ok
+}
+
+// MustCurrentUser 返回当前用户，路由未挂载 AuthMiddleware 时 panic
+func MustCurrentUser(c *gin.Context) *models.User {
+	user, ok := CurrentUser(c)
+	if !ok {
+		panic("middleware: current user missing, route is not behind AuthMiddleware")
+	}
+	return user
+}
+
+// CurrentClaims 返回当前请求的访问令牌声明
+func CurrentClaims(c *gin.Context) (*auth.Claims, bool) {
+	v, ok := c.Get(currentClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*auth.Claims)
+	return claims, ok
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/auth"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/services"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func newTokens(ttl time.Duration) *auth.TokenManager {
+	return auth.NewTokenManager(config.AuthConfig{JWTSecret: testSecret, AccessTokenTTL: ttl})
+}
+
+type authTest struct {
+	engine *gin.Engine
+	mock   sqlmock.Sqlmock
+	tokens *auth.TokenManager
+}
+
+// newAuthTest 创建挂载 AuthMiddleware 的路由，处理器返回当前用户名
+func newAuthTest(t *testing.T) *authTest {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+
+	tokens := newTokens(time.Minute)
+	r := gin.New()
+	r.Use(AuthMiddleware(services.NewSessionService(tokens, time.Hour)))
+	handler := func(c *gin.Context) {
+		user := MustCurrentUser(c)
+		claims, _ := CurrentClaims(c)
+		c.JSON(http.StatusOK, gin.H{"username": user.Username, "sid": claims.SessionID})
+	}
+	r.GET("/me", handler)
+	r.HEAD("/me", handler)
+	r.POST("/me", handler)
+	return &authTest{engine: r, mock: mock, tokens: tokens}
+}
+
+func (a *authTest) issue(t *testing.T, uid int, sid string) string {
+	token, _, err := a.tokens.IssueAccessToken(uid, sid)
+	require.NoError(t, err)
+	return token
+}
+
+func (a *authTest) do(method, target, authorization string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	a.engine.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func (a *authTest) expectSession(sid string, revoked bool) {
+	count := 0
+	if revoked {
+		count = 1
+	}
+	a.mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM refresh_tokens WHERE family_id = \\?").WithArgs(sid).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func (a *authTest) expectUser(uid int, exists bool) {
+	rows := sqlmock.NewRows([]string{"uid", "username", "password", "created_at", "updated_at"})
+	if exists {
+		rows.AddRow(uid, "alice", "hash", time.Now(), time.Now())
+	}
+	a.mock.ExpectQuery("SELECT (.+) FROM users WHERE uid = \\?").WithArgs(uid).WillReturnRows(rows)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	a := newAuthTest(t)
+	token := a.issue(t, 7, "sid-1")
+
+	a.expectSession("sid-1", false)
+	a.expectUser(7, true)
+	w, resp := a.do(http.MethodGet, "/me", "Bearer "+token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "alice", resp["username"])
+	assert.Equal(t, "sid-1", resp["sid"])
+}
+
+func TestAuthMiddlewareRejects(t *testing.T) {
+	a := newAuthTest(t)
+
+	w, resp := a.do(http.MethodGet, "/me", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Authorization header is required", resp["error"])
+
+	for _, header := range []string{"Token abc", "Bearer", "Bearer a b", "bearer " + a.issue(t, 7, "")} {
+		w, resp = a.do(http.MethodGet, "/me", header)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+		assert.Equal(t, "Invalid authorization header format", resp["error"], header)
+	}
+
+	// 其他密钥签发的令牌
+	forged, _, err := auth.NewTokenManager(config.AuthConfig{JWTSecret: "ffffffffffffffffffffffffffffffff", AccessTokenTTL: time.Minute}).
+		IssueAccessToken(7, "")
+	require.NoError(t, err)
+	w, resp = a.do(http.MethodGet, "/me", "Bearer "+forged)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Invalid token", resp["error"])
+
+	expired, _, err := newTokens(-time.Minute).IssueAccessToken(7, "")
+	require.NoError(t, err)
+	w, resp = a.do(http.MethodGet, "/me", "Bearer "+expired)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Token expired", resp["error"])
+
+	a.expectSession("sid-1", true)
+	w, resp = a.do(http.MethodGet, "/me", "Bearer "+a.issue(t, 7, "sid-1"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Session revoked", resp["error"])
+
+	// 令牌有效但用户已被删除
+	a.expectUser(8, false)
+	w, resp = a.do(http.MethodGet, "/me", "Bearer "+a.issue(t, 8, ""))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Invalid token", resp["error"])
+}
+
+func TestAuthMiddlewareQueryToken(t *testing.T) {
+	a := newAuthTest(t)
+	token := a.issue(t, 7, "")
+
+	a.expectUser(7, true)
+	w, resp := a.do(http.MethodGet, "/me?access_token="+token, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "alice", resp["username"])
+
+	a.expectUser(7, true)
+	w, _ = a.do(http.MethodHead, "/me?access_token="+token, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 写请求只接受请求头中的令牌
+	w, resp = a.do(http.MethodPost, "/me?access_token="+token, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Authorization header is required", resp["error"])
+}
+
+func TestCurrentUser(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	_, ok := CurrentUser(c)
+	assert.False(t, ok)
+	assert.Panics(t, func() { MustCurrentUser(c) })
+
+	user := &models.User{Uid: 7, Username: "alice"}
+	SetCurrentUser(c, user)
+	got, ok := CurrentUser(c)
+	require.True(t, ok)
+	assert.Same(t, user, got)
+	assert.Same(t, user, MustCurrentUser(c))
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
@@ -12,7 +13,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		c.Next()
 
@@ -29,3 +30,17 @@ func LoggerMiddleware() gin.HandlerFunc {
 		)
 	}
 }
+
+// redactQuery 隐藏查询参数中的访问令牌，避免写入日志
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	// 解析出错时 ParseQuery 仍返回已解析的部分，同样需要脱敏
+	values, _ := url.ParseQuery(rawQuery)
+	if values.Get(accessTokenQueryParam) == "" {
+		return rawQuery
+	}
+	values.Set(accessTokenQueryParam, "REDACTED")
+	return values.Encode()
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// 压缩包检查状态
+const (
+	ArchiveStatusPending     = "pending"
+	ArchiveStatusReady       = "ready"
+	ArchiveStatusRejected    = "rejected"
+	ArchiveStatusUnsupported = "unsupported"
+	ArchiveStatusFailed      = "failed"
+)
+
+// ArchiveEntry 压缩包中的一个条目
+type ArchiveEntry struct {
+	Name           string    `json:"name"`
+	Size           int64     `json:"size"`
+	CompressedSize int64     `json:"compressed_size"`
+	Modified       time.Time `json:"modified"`
+	IsDir          bool      `json:"is_dir"`
+	Encrypted      bool      `json:"encrypted,omitempty"`
+}
+
+// ArchiveManifest 压缩包检查结果，Entries 仅在 ready 时有值
+type ArchiveManifest struct {
+	FileID         int64  `json:"file_id" db:"file_id"`
+	Status         string `json:"status" db:"status"`
+	Format         string `json:"format" db:"format"`
+	EntryCount     int    `json:"entry_count" db:"entry_count"`
+	TotalSize      int64  `json:"total_size" db:"total_size"`
+	CompressedSize int64  `json:"compressed_size" db:"compressed_size"`
+	// RejectCode 被拒绝的原因代码，如 COMPRESSION_RATIO_EXCEEDED、UNSAFE_ENTRY_PATH
+	RejectCode string         `json:"reject_code,omitempty" db:"reject_code"`
+	Error      string         `json:"error,omitempty" db:"error"`
+	Entries    []ArchiveEntry `json:"entries,omitempty" db:"entries"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+}
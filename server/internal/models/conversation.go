@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// Conversation 会话模型，memory_id 为客户端生成的会话标识，在同一用户下唯一
+type Conversation struct {
+	ID        int64     `json:"id" db:"id"`
+	Uid       int       `json:"uid" db:"uid"`
+	MemoryID  string    `json:"memory_id" db:"memory_id"`
+	Title     string    `json:"title" db:"title"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Message 会话中的单条消息
+type Message struct {
+	ID             int64     `json:"id" db:"id"`
+	ConversationID int64     `json:"conversation_id" db:"conversation_id"`
+	Role           string    `json:"role" db:"role"`
+	Content        string    `json:"content" db:"content"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	// Attachments 用户消息随附的文件引用
+	Attachments []MessageAttachment `json:"attachments,omitempty" db:"-"`
+}
+
+// MessageAttachment 消息引用的已上传文件，保存发送时的文件名与类型，文件删除后 FileID 为空
+type MessageAttachment struct {
+	MessageID   int64  `json:"-" db:"message_id"`
+	FileID      *int64 `json:"file_id" db:"file_id"`
+	FileName    string `json:"file_name" db:"file_name"`
+	ContentType string `json:"content_type" db:"content_type"`
+}
+
+// MessageSearchHit 会话搜索命中的消息
+type MessageSearchHit struct {
+	ConversationID int64     `json:"conversation_id" db:"conversation_id"`
+	Title          string    `json:"title" db:"title"`
+	MessageID      int64     `json:"message_id" db:"message_id"`
+	Role           string    `json:"role" db:"role"`
+	Content        string    `json:"content" db:"content"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// 消息角色
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
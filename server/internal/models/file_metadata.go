@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// 元数据提取状态
+const (
+	MetadataStatusPending = "pending"
+	MetadataStatusReady   = "ready"
+	MetadataStatusFailed  = "failed"
+)
+
+// 元数据对应的媒体类别
+const (
+	MediaKindVideo = "video"
+	MediaKindAudio = "audio"
+	MediaKindImage = "image"
+)
+
+// FileMetadata 从上传文件中提取的媒体元数据，不适用于该类别的字段为零值
+type FileMetadata struct {
+	FileID int64  `json:"file_id" db:"file_id"`
+	Status string `json:"status" db:"status"`
+	Kind   string `json:"kind,omitempty" db:"kind"`
+	// Duration 时长（秒），视频与音频
+	Duration float64 `json:"duration,omitempty" db:"duration"`
+	Width    int     `json:"width,omitempty" db:"width"`
+	Height   int     `json:"height,omitempty" db:"height"`
+	// Codec 视频为视频流编码，音频为音频流编码
+	Codec string `json:"codec,omitempty" db:"codec"`
+	// Bitrate 总码率（bit/s）
+	Bitrate    int64 `json:"bitrate,omitempty" db:"bitrate"`
+	SampleRate int   `json:"sample_rate,omitempty" db:"sample_rate"`
+	Channels   int   `json:"channels,omitempty" db:"channels"`
+	// EXIF 图片的常用 EXIF 字段，默认不含 GPS 信息
+	EXIF      map[string]interface{} `json:"exif,omitempty" db:"exif"`
+	Error     string                 `json:"error,omitempty" db:"error"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// FileThumbnail 图片或视频封面的缩略图记录，每个文件每种规格一条
+type FileThumbnail struct {
+	FileID    int64     `json:"-" db:"file_id"`
+	Size      string    `json:"size" db:"size"`
+	Width     int       `json:"width" db:"width"`
+	Height    int       `json:"height" db:"height"`
+	ObjectKey string    `json:"-" db:"object_key"`
+	FileSize  int64     `json:"file_size" db:"file_size"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// URL 经服务端鉴权读取缩略图的地址，由处理器填充
+	URL string `json:"url" db:"-"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MediaClass 一类媒体文件的判定规则：识别出的内容类型以 ContentTypePrefix 开头，
+// 或类型识别之前登记的记录（content_type 为 application/octet-stream 等）扩展名在 Extensions 中。
+// IsVideo 等判断与 dao.ListFilesOptions.MediaClasses 查询共用该规则
+type MediaClass struct {
+	ContentTypePrefix string
+	Extensions        []string
+}
+
+var (
+	VideoClass = MediaClass{ContentTypePrefix: "video/", Extensions: []string{".mp4", ".mov", ".mkv", ".avi"}}
+	ImageClass = MediaClass{ContentTypePrefix: "image/", Extensions: []string{".jpg", ".jpeg", ".png", ".gif"}}
+	AudioClass = MediaClass{ContentTypePrefix: "audio/", Extensions: []string{".mp3", ".wav"}}
+)
+
+// Match 判断文件是否属于该类，扩展名不区分大小写
+func (c MediaClass) Match(f *MinioFile) bool {
+	if strings.HasPrefix(f.ContentType, c.ContentTypePrefix) {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(f.FileName))
+	for _, e := range c.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// 恶意软件扫描状态，pending 与 infected 状态的文件处于隔离中，不能播放或下载
+const (
+	ScanStatusPending  = "pending"
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected"
+	// ScanStatusSkipped 扫描器不可用且配置为放行时未经扫描的文件
+	ScanStatusSkipped = "skipped"
+)
+
+// MinioFile 用户上传到 MinIO 的文件记录
+type MinioFile struct {
+	ID          int64  `json:"id" db:"id"`
+	Uid         int    `json:"uid" db:"uid"`
+	FileName    string `json:"file_name" db:"file_name"`
+	Bucket      string `json:"bucket" db:"bucket"`
+	ObjectKey   string `json:"object_key" db:"object_key"`
+	ContentType string `json:"content_type" db:"content_type"`
+	FileSize    int64  `json:"file_size" db:"file_size"`
+	SHA256      string `json:"sha256,omitempty" db:"sha256"`
+	IsShared    bool   `json:"is_shared" db:"is_shared"`
+	ScanStatus  string `json:"scan_status" db:"scan_status"`
+	// ScanResult 命中的病毒特征名称或跳过扫描的原因
+	ScanResult string    `json:"scan_result,omitempty" db:"scan_result"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// CanBeReadBy 文件所有者或已共享文件可被读取
+func (f *MinioFile) CanBeReadBy(uid int) bool {
+	return f.Uid == uid || f.IsShared
+}
+
+// Quarantined 文件尚未通过扫描或已判定感染
+func (f *MinioFile) Quarantined() bool {
+	return f.ScanStatus != ScanStatusClean && f.ScanStatus != ScanStatusSkipped
+}
+
+// IsVideo 按识别出的内容类型判断是否为视频，类型识别之前登记的记录按扩展名判断
+func (f *MinioFile) IsVideo() bool {
+	return VideoClass.Match(f)
+}
+
+// IsImage 按识别出的内容类型判断是否为图片，类型识别之前登记的记录按扩展名判断
+func (f *MinioFile) IsImage() bool {
+	return ImageClass.Match(f)
+}
+
+// IsAudio 按识别出的内容类型判断是否为音频，类型识别之前登记的记录按扩展名判断
+func (f *MinioFile) IsAudio() bool {
+	return AudioClass.Match(f)
+}
+
+// DerivedPrefix 由原文件生成的衍生对象（转码结果、缩略图等）的键前缀，与原对象相邻存放，随原对象一起删除
+func (f *MinioFile) DerivedPrefix() string {
+	return f.ObjectKey + ".derived/"
+}
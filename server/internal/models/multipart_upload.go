@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// 分片上传状态
+const (
+	MultipartStatusUploading = "uploading"
+	// MultipartStatusAssembled 分片已合并为完整对象，等待登记入库，登记失败时可再次 complete 重试
+	MultipartStatusAssembled = "assembled"
+	MultipartStatusCompleted = "completed"
+	MultipartStatusAborted   = "aborted"
+)
+
+// MultipartUpload 可断点续传的分片上传会话
+type MultipartUpload struct {
+	ID            string    `json:"upload_id" db:"id"`
+	Uid           int       `json:"uid" db:"uid"`
+	StorageUpload string    `json:"-" db:"storage_upload_id"`
+	ObjectKey     string    `json:"object_key" db:"object_key"`
+	FileName      string    `json:"file_name" db:"file_name"`
+	FileSize      int64     `json:"file_size" db:"file_size"`
+	ContentType   string    `json:"content_type" db:"content_type"`
+	PartSize      int64     `json:"part_size" db:"part_size"`
+	Status        string    `json:"status" db:"status"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PartCount 按分片大小计算总分片数
+func (u *MultipartUpload) PartCount() int {
+	return int((u.FileSize + u.PartSize - 1) / u.PartSize)
+}
+
+// ExpectedPartSize 返回指定分片应有的字节数，最后一个分片可以较小
+func (u *MultipartUpload) ExpectedPartSize(partNumber int) int64 {
+	if partNumber < u.PartCount() {
+		return u.PartSize
+	}
+	return u.FileSize - int64(u.PartCount()-1)*u.PartSize
+}
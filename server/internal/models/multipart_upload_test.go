@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipartUploadPartSizes(t *testing.T) {
+	u := &MultipartUpload{FileSize: 20, PartSize: 8}
+	assert.Equal(t, 3, u.PartCount())
+	assert.Equal(t, int64(8), u.ExpectedPartSize(1))
+	assert.Equal(t, int64(8), u.ExpectedPartSize(2))
+	assert.Equal(t, int64(4), u.ExpectedPartSize(3))
+
+	exact := &MultipartUpload{FileSize: 16, PartSize: 8}
+	assert.Equal(t, 2, exact.PartCount())
+	assert.Equal(t, int64(8), exact.ExpectedPartSize(2))
+
+	single := &MultipartUpload{FileSize: 3, PartSize: 8}
+	assert.Equal(t, 1, single.PartCount())
+	assert.Equal(t, int64(3), single.ExpectedPartSize(1))
+}
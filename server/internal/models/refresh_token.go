@@ -0,0 +1,18 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RefreshToken 刷新令牌记录，仅保存令牌哈希
+type RefreshToken struct {
+	ID        int64        `json:"id" db:"id"`
+	Uid       int          `json:"uid" db:"uid"`
+	FamilyID  string       `json:"family_id" db:"family_id"`
+	TokenHash string       `json:"-" db:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at" db:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at" db:"used_at"`
+	RevokedAt sql.NullTime `json:"revoked_at" db:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// StorageUsage 用户存储用量，QuotaBytes 为 0 表示不限额
+type StorageUsage struct {
+	Uid            int   `json:"uid" db:"uid"`
+	UsedBytes      int64 `json:"used_bytes" db:"used_bytes"`
+	FileCount      int   `json:"file_count" db:"file_count"`
+	QuotaBytes     int64 `json:"quota_bytes"`
+	RemainingBytes int64 `json:"remaining_bytes"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// 视频转码状态
+const (
+	TranscodeStatusPending    = "pending"
+	TranscodeStatusProcessing = "processing"
+	TranscodeStatusReady      = "ready"
+	TranscodeStatusFailed     = "failed"
+)
+
+// VideoTranscode 视频 HLS 转码记录
+type VideoTranscode struct {
+	FileID      int64     `json:"file_id" db:"file_id"`
+	Status      string    `json:"status" db:"status"`
+	PlaylistKey string    `json:"-" db:"playlist_key"`
+	Error       string    `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize INSTREAM 每个数据块的大小
+const clamdChunkSize = 64 << 10
+
+// ClamdScanner 通过 clamd 的 INSTREAM 命令扫描内容，每次扫描使用一个新连接
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner 创建 clamd 扫描器，address 形如 tcp://clamav:3310、unix:///run/clamav/clamd.sock 或 host:port。
+// timeout 为单次扫描的默认超时，ctx 带截止时间时以较早者为准。
+func NewClamdScanner(address string, timeout time.Duration) (*ClamdScanner, error) {
+	network, addr := "tcp", address
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clamd address: %w", err)
+		}
+		switch u.Scheme {
+		case "tcp":
+			addr = u.Host
+		case "unix":
+			network, addr = "unix", u.Path
+		default:
+			return nil, fmt.Errorf("unsupported clamd address scheme %q", u.Scheme)
+		}
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("clamd address is required")
+	}
+	return &ClamdScanner{network: network, address: addr, timeout: timeout}, nil
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	// ctx 取消时立即中断读写
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	writeErr := s.stream(conn, r)
+	if writeErr != nil && !errors.Is(writeErr, ErrUnavailable) {
+		return nil, writeErr
+	}
+	// clamd 超出 StreamMaxLength 时会先回复错误再断开，写入失败后仍尝试读取回复
+	reply, err := bufio.NewReader(io.LimitReader(conn, 4096)).ReadString(0)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if writeErr != nil {
+			return nil, writeErr
+		}
+		return nil, fmt.Errorf("%w: failed to read reply: %v", ErrUnavailable, err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00"))
+}
+
+// stream 发送 INSTREAM 命令与长度前缀的数据块，以长度为 0 的块结束
+func (s *ClamdScanner) stream(conn net.Conn, r io.Reader) error {
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	buf := make([]byte, clamdChunkSize)
+	var size [4]byte
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, werr := w.Write(size[:]); werr != nil {
+				return fmt.Errorf("%w: %v", ErrUnavailable, werr)
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return fmt.Errorf("%w: %v", ErrUnavailable, werr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			// 读取待扫描内容失败不是扫描器的问题
+			return fmt.Errorf("failed to read content: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// parseClamdReply 解析 "stream: OK"、"stream: <signature> FOUND" 或错误回复
+func parseClamdReply(reply string) (*Result, error) {
+	body := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case body == "OK":
+		return &Result{Clean: true}, nil
+	case strings.HasSuffix(body, " FOUND"):
+		return &Result{Signature: strings.TrimSuffix(body, " FOUND")}, nil
+	case strings.Contains(reply, "size limit exceeded"):
+		return nil, ErrStreamTooLarge
+	default:
+		return nil, fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startFakeClamd 启动实现 INSTREAM 的 clamd 替身，maxLength 为允许的最大内容长度
+func startFakeClamd(t *testing.T, maxLength int) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxLength)
+		}
+	}()
+	return "tcp://" + ln.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxLength int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil || cmd != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+	var data bytes.Buffer
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n == 0 {
+			break
+		}
+		if data.Len()+int(n) > maxLength {
+			conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+			return
+		}
+		if _, err := io.CopyN(&data, r, int64(n)); err != nil {
+			return
+		}
+	}
+	if bytes.Contains(data.Bytes(), []byte(EICAR)) {
+		conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+		return
+	}
+	conn.Write([]byte("stream: OK\x00"))
+}
+
+func TestClamdScanner(t *testing.T) {
+	addr := startFakeClamd(t, 1<<20)
+	s, err := NewClamdScanner(addr, 5*time.Second)
+	require.NoError(t, err)
+
+	res, err := s.Scan(context.Background(), strings.NewReader(strings.Repeat("hello ", 50000)))
+	require.NoError(t, err)
+	assert.True(t, res.Clean)
+
+	res, err = s.Scan(context.Background(), strings.NewReader("prefix "+EICAR))
+	require.NoError(t, err)
+	assert.False(t, res.Clean)
+	assert.Equal(t, "Eicar-Test-Signature", res.Signature)
+}
+
+func TestClamdScannerSizeLimit(t *testing.T) {
+	addr := startFakeClamd(t, 100<<10)
+	s, err := NewClamdScanner(addr, 5*time.Second)
+	require.NoError(t, err)
+
+	_, err = s.Scan(context.Background(), bytes.NewReader(make([]byte, 1<<20)))
+	assert.ErrorIs(t, err, ErrStreamTooLarge)
+}
+
+func TestClamdScannerUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s, err := NewClamdScanner(addr, time.Second)
+	require.NoError(t, err)
+	_, err = s.Scan(context.Background(), strings.NewReader("data"))
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestNewClamdScannerAddress(t *testing.T) {
+	s, err := NewClamdScanner("unix:///run/clamav/clamd.sock", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "unix", s.network)
+	assert.Equal(t, "/run/clamav/clamd.sock", s.address)
+
+	s, err = NewClamdScanner("clamav:3310", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "tcp", s.network)
+
+	_, err = NewClamdScanner("http://clamav:3310", time.Second)
+	assert.Error(t, err)
+}
+
+func TestFake(t *testing.T) {
+	f := NewFake()
+	res, err := f.Scan(context.Background(), strings.NewReader(EICAR))
+	require.NoError(t, err)
+	assert.False(t, res.Clean)
+
+	f.Err = ErrUnavailable
+	_, err = f.Scan(context.Background(), strings.NewReader("x"))
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 2, f.Scanned())
+}
@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// EICAR 标准杀毒测试文件内容，所有扫描器都应识别为病毒
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Fake 内存中的扫描器，内容包含 Signatures 中任一特征时判定为感染，供测试使用
+type Fake struct {
+	// Signatures 特征内容到病毒名称的映射
+	Signatures map[string]string
+	// Err 非 nil 时 Scan 直接返回该错误，用于模拟扫描器不可用
+	Err error
+
+	mu      sync.Mutex
+	scanned int
+}
+
+// NewFake 创建能识别 EICAR 测试文件的扫描器
+func NewFake() *Fake {
+	return &Fake{Signatures: map[string]string{EICAR: "Eicar-Test-Signature"}}
+}
+
+func (f *Fake) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	f.mu.Lock()
+	f.scanned++
+	f.mu.Unlock()
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	for pattern, name := range f.Signatures {
+		if bytes.Contains(data, []byte(pattern)) {
+			return &Result{Signature: name}, nil
+		}
+	}
+	return &Result{Clean: true}, nil
+}
+
+// Scanned 返回已扫描的次数
+func (f *Fake) Scanned() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.scanned
+}
@@ -0,0 +1,25 @@
+// Package scanner 对上传内容做恶意软件扫描
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrUnavailable 扫描器无法连接或通信中断，调用方按配置决定放行或拒绝
+var ErrUnavailable = errors.New("scanner unavailable")
+
+// ErrStreamTooLarge 内容超出扫描器允许的最大长度（clamd StreamMaxLength）
+var ErrStreamTooLarge = errors.New("stream exceeds scanner size limit")
+
+// Result 扫描结果，Clean 为 false 时 Signature 为命中的病毒特征名称
+type Result struct {
+	Clean     bool
+	Signature string
+}
+
+// Scanner 扫描一段内容
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
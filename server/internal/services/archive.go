@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/archive"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"go.uber.org/zap"
+)
+
+// ArchiveJobType 压缩包检查任务类型
+const ArchiveJobType = "file.archive"
+
+// ErrArchiveNotReady 压缩包尚未检查完成、被拒绝或格式不支持，不能读取条目
+var ErrArchiveNotReady = errors.New("archive manifest not ready")
+
+// archiveBlockSize 从对象存储读取压缩包时每次范围请求的大小
+const archiveBlockSize = 1 << 20
+
+type archivePayload struct {
+	FileID int64 `json:"file_id"`
+}
+
+// ArchiveService 压缩包上传后通过后台任务检查条目清单，并支持读取单个条目
+type ArchiveService struct {
+	minio        *storage.MinioService
+	jobs         *jobs.Pool
+	manifestDAO  *dao.ArchiveManifestDAO
+	minioFileDAO *dao.MinioFileDAO
+	limits       archive.Limits
+	cfg          config.ArchiveConfig
+}
+
+// NewArchiveService 创建新的压缩包服务实例
+func NewArchiveService(minioSvc *storage.MinioService, jobPool *jobs.Pool, cfg config.ArchiveConfig) *ArchiveService {
+	return &ArchiveService{
+		minio:        minioSvc,
+		jobs:         jobPool,
+		manifestDAO:  dao.NewArchiveManifestDAO(),
+		minioFileDAO: dao.NewMinioFileDAO(),
+		limits: archive.Limits{
+			MaxEntries:          cfg.MaxEntries,
+			MaxUncompressedSize: cfg.MaxUncompressedSize,
+			MaxRatio:            cfg.MaxRatio,
+		},
+		cfg: cfg,
+	}
+}
+
+// ArchiveFormat 返回文件的压缩包格式（zip/rar/7z），不是压缩包时返回空字符串
+func ArchiveFormat(file *models.MinioFile) string {
+	switch file.ContentType {
+	case "application/zip":
+		return "zip"
+	case "application/vnd.rar":
+		return "rar"
+	case "application/x-7z-compressed":
+		return "7z"
+	}
+	switch strings.ToLower(filepath.Ext(file.FileName)) {
+	case ".zip":
+		return "zip"
+	case ".rar":
+		return "rar"
+	case ".7z":
+		return "7z"
+	}
+	return ""
+}
+
+// Submit 上传钩子：zip 入队检查任务，rar/7z 没有纯 Go 实现，直接登记为 unsupported
+func (s *ArchiveService) Submit(file *models.MinioFile) {
+	format := ArchiveFormat(file)
+	if format == "" {
+		return
+	}
+	status := models.ArchiveStatusPending
+	if format != "zip" {
+		status = models.ArchiveStatusUnsupported
+	}
+	if err := s.manifestDAO.Upsert(file.ID, format, status); err != nil {
+		logger.Logger.Error("failed to create archive manifest", zap.Int64("file_id", file.ID), zap.Error(err))
+		return
+	}
+	if status != models.ArchiveStatusPending {
+		return
+	}
+	if _, err := s.jobs.Enqueue(context.Background(), ArchiveJobType, archivePayload{FileID: file.ID}); err != nil {
+		logger.Logger.Error("failed to enqueue archive job", zap.Int64("file_id", file.ID), zap.Error(err))
+		if uerr := s.manifestDAO.MarkFailed(file.ID, "failed to enqueue"); uerr != nil {
+			logger.Logger.Error("failed to mark archive failed", zap.Int64("file_id", file.ID), zap.Error(uerr))
+		}
+	}
+}
+
+// HandleJob 压缩包检查任务处理器
+func (s *ArchiveService) HandleJob(ctx context.Context, job *jobs.Job) error {
+	var p archivePayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	err := s.Process(ctx, p.FileID)
+	if err != nil && job.LastAttempt(err) {
+		if uerr := s.manifestDAO.MarkFailed(p.FileID, err.Error()); uerr != nil {
+			logger.Logger.Error("failed to mark archive failed", zap.Int64("file_id", p.FileID), zap.Error(uerr))
+		}
+	}
+	return err
+}
+
+// Manifest 返回文件的压缩包检查记录，未登记时返回 nil
+func (s *ArchiveService) Manifest(fileID int64) (*models.ArchiveManifest, error) {
+	return s.manifestDAO.Get(fileID)
+}
+
+// Process 读取 zip 中央目录生成条目清单；不安全的压缩包记录为 rejected，不视为任务失败，
+// 其它错误只返回，由 HandleJob 在不再重试时记录为 failed
+func (s *ArchiveService) Process(ctx context.Context, fileID int64) error {
+	file, err := s.minioFileDAO.GetByID(fileID)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return nil // 检查前文件已被删除
+	}
+
+	manifest, err := s.inspect(ctx, file)
+	var rejectErr *archive.RejectError
+	switch {
+	case errors.As(err, &rejectErr):
+		logger.Logger.Warn("archive rejected",
+			zap.Int64("file_id", fileID),
+			zap.String("code", rejectErr.Code),
+			zap.String("reason", rejectErr.Reason))
+		return s.manifestDAO.Save(&models.ArchiveManifest{
+			FileID:     fileID,
+			Status:     models.ArchiveStatusRejected,
+			RejectCode: rejectErr.Code,
+			Error:      rejectErr.Reason,
+		})
+	case err != nil:
+		return err
+	}
+	return s.manifestDAO.Save(manifest)
+}
+
+func (s *ArchiveService) inspect(ctx context.Context, file *models.MinioFile) (*models.ArchiveManifest, error) {
+	obj, info, err := s.minio.GetObject(ctx, file.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+
+	m, err := archive.InspectZip(archive.NewBlockReaderAt(obj, info.Size, archiveBlockSize), info.Size, s.limits)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]models.ArchiveEntry, len(m.Entries))
+	for i, e := range m.Entries {
+		entries[i] = models.ArchiveEntry(e)
+	}
+	return &models.ArchiveManifest{
+		FileID:         file.ID,
+		Status:         models.ArchiveStatusReady,
+		EntryCount:     m.EntryCount,
+		TotalSize:      m.TotalSize,
+		CompressedSize: m.CompressedSize,
+		Entries:        entries,
+	}, nil
+}
+
+// OpenEntry 打开已通过检查的压缩包中的单个文件条目。
+// 压缩包未就绪时返回 ErrArchiveNotReady，条目不存在时返回 archive.ErrEntryNotFound。
+func (s *ArchiveService) OpenEntry(ctx context.Context, file *models.MinioFile, name string) (io.ReadCloser, *models.ArchiveEntry, error) {
+	m, err := s.manifestDAO.Get(file.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m == nil || m.Status != models.ArchiveStatusReady {
+		return nil, nil, ErrArchiveNotReady
+	}
+
+	obj, info, err := s.minio.GetObject(ctx, file.ObjectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, entry, err := archive.OpenZipEntry(archive.NewBlockReaderAt(obj, info.Size, archiveBlockSize), info.Size, name)
+	if err != nil {
+		obj.Close()
+		return nil, nil, err
+	}
+	e := models.ArchiveEntry(*entry)
+	return &entryReader{ReadCloser: rc, object: obj}, &e, nil
+}
+
+// entryReader 关闭条目时一并关闭底层对象
+type entryReader struct {
+	io.ReadCloser
+	object io.Closer
+}
+
+func (r *entryReader) Close() error {
+	err := r.ReadCloser.Close()
+	if cerr := r.object.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveFailsOnlyOnLastAttempt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("readme.txt")
+	require.NoError(t, err)
+	w.Write([]byte("hello"))
+	require.NoError(t, zw.Close())
+
+	store, minioSvc := miniotest.Start(t)
+	store.Put("uid_7/sha256/abc", buf.Bytes(), "application/zip")
+	store.Fail = func(r *http.Request) bool { return true }
+	s := NewArchiveService(minioSvc, nil, config.ArchiveConfig{MaxEntries: 10, MaxUncompressedSize: 1 << 20, MaxRatio: 100, Timeout: time.Minute})
+	job := &jobs.Job{ID: 1, Payload: []byte(`{"file_id":3}`), Attempts: 1, MaxAttempts: 2}
+	expectFile := func() {
+		mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+			WillReturnRows(sqlmock.NewRows(minioFileColumns).
+				AddRow(3, 7, "a.zip", miniotest.Bucket, "uid_7/sha256/abc", "application/zip", buf.Len(), "abc", false, "clean", "", time.Now()))
+	}
+
+	// 对象存储暂时不可用：仍会重试时不改状态
+	expectFile()
+	assert.Error(t, s.HandleJob(context.Background(), job))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	job.Attempts = 2
+	expectFile()
+	mock.ExpectExec("UPDATE archive_manifests SET status = \\?, error = \\?").
+		WithArgs(models.ArchiveStatusFailed, sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Error(t, s.HandleJob(context.Background(), job))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 恢复后重新检查得到清单
+	store.Fail = nil
+	expectFile()
+	mock.ExpectExec("UPDATE archive_manifests SET status = \\?, entry_count = \\?").
+		WithArgs(models.ArchiveStatusReady, 1, int64(5), sqlmock.AnyArg(), "", "", sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
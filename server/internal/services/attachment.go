@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"go.uber.org/zap"
+)
+
+// maxInlineImageSize 超过该大小的图片改用缩略图交给模型，避免模型拉取原图超时
+const maxInlineImageSize = 10 << 20
+
+// maxListedEntries 压缩包附件最多列出的条目数
+const maxListedEntries = 50
+
+// ErrFileQuarantined 文件尚未通过恶意软件扫描或已判定感染
+var ErrFileQuarantined = errors.New("file is quarantined")
+
+// AttachmentError 指明哪个附件校验失败，Err 为 ErrFileNotFound 或 ErrFileQuarantined
+type AttachmentError struct {
+	FileID int64
+	Err    error
+}
+
+func (e *AttachmentError) Error() string {
+	return fmt.Sprintf("attachment %d: %v", e.FileID, e.Err)
+}
+
+func (e *AttachmentError) Unwrap() error { return e.Err }
+
+// AttachmentService 校验聊天消息引用的文件，并将其转换为模型可用的内容片段：
+// 图片以预签名URL传递，其他文件以文件信息、媒体元数据或压缩包条目清单等文本描述传递
+type AttachmentService struct {
+	minio        *storage.MinioService
+	thumbnails   *ThumbnailService
+	metadata     *MetadataService
+	archives     *ArchiveService
+	minioFileDAO *dao.MinioFileDAO
+}
+
+// NewAttachmentService 创建新的聊天附件服务实例
+func NewAttachmentService(minioSvc *storage.MinioService, thumbnailSvc *ThumbnailService, metadataSvc *MetadataService, archiveSvc *ArchiveService) *AttachmentService {
+	return &AttachmentService{
+		minio:        minioSvc,
+		thumbnails:   thumbnailSvc,
+		metadata:     metadataSvc,
+		archives:     archiveSvc,
+		minioFileDAO: dao.NewMinioFileDAO(),
+	}
+}
+
+// Resolve 按请求顺序加载附件文件并去重，文件必须属于 uid 且已通过扫描，否则返回 *AttachmentError
+func (s *AttachmentService) Resolve(uid int, ids []int64) ([]*models.MinioFile, error) {
+	files := make([]*models.MinioFile, 0, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		file, err := s.minioFileDAO.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		// 不属于当前用户与不存在同样处理，避免泄露他人文件ID
+		if file == nil || file.Uid != uid {
+			return nil, &AttachmentError{FileID: id, Err: ErrFileNotFound}
+		}
+		if file.Quarantined() {
+			return nil, &AttachmentError{FileID: id, Err: ErrFileQuarantined}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// AttachmentReferences 生成随消息保存的附件引用
+func AttachmentReferences(files []*models.MinioFile) []models.MessageAttachment {
+	refs := make([]models.MessageAttachment, 0, len(files))
+	for _, f := range files {
+		id := f.ID
+		refs = append(refs, models.MessageAttachment{FileID: &id, FileName: f.FileName, ContentType: f.ContentType})
+	}
+	return refs
+}
+
+// ContentParts 生成本轮用户消息的内容片段：先是消息正文，再依次是每个附件的描述与图片
+func (s *AttachmentService) ContentParts(ctx context.Context, message string, files []*models.MinioFile) ([]llm.ContentPart, error) {
+	parts := []llm.ContentPart{{Type: llm.ContentPartText, Text: message}}
+	for _, file := range files {
+		parts = append(parts, llm.ContentPart{Type: llm.ContentPartText, Text: s.describe(file)})
+
+		key, err := s.imageKey(file)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			continue
+		}
+		url, _, err := s.minio.PresignedGetURL(ctx, key, "")
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, llm.ContentPart{Type: llm.ContentPartImageURL, ImageURL: &llm.ImageURL{URL: url}})
+	}
+	return parts, nil
+}
+
+// imageKey 选择交给模型查看的图片对象：较小的图片用原图，较大的图片与视频用最大规格的缩略图，没有可用图片时返回空字符串
+func (s *AttachmentService) imageKey(file *models.MinioFile) (string, error) {
+	if file.IsImage() && file.FileSize <= maxInlineImageSize {
+		return file.ObjectKey, nil
+	}
+	if !file.IsImage() && !file.IsVideo() {
+		return "", nil
+	}
+	thumbs, err := s.thumbnails.ListByFileIDs([]int64{file.ID})
+	if err != nil {
+		return "", err
+	}
+	// 缩略图按宽度升序排列
+	if list := thumbs[file.ID]; len(list) > 0 {
+		return list[len(list)-1].ObjectKey, nil
+	}
+	return "", nil
+}
+
+// describe 生成附件的文本描述，元数据或条目清单读取失败时只给出基本信息
+func (s *AttachmentService) describe(file *models.MinioFile) string {
+	var meta *models.FileMetadata
+	var manifest *models.ArchiveManifest
+	var err error
+	if MediaKind(file) != "" {
+		if meta, err = s.metadata.Get(file.ID); err != nil {
+			logger.Logger.Warn("failed to load attachment metadata", zap.Int64("file_id", file.ID), zap.Error(err))
+		}
+	} else if ArchiveFormat(file) != "" {
+		if manifest, err = s.archives.Manifest(file.ID); err != nil {
+			logger.Logger.Warn("failed to load attachment manifest", zap.Int64("file_id", file.ID), zap.Error(err))
+		}
+	}
+	return DescribeAttachment(file, meta, manifest)
+}
+
+// DescribeAttachment 将文件信息与已提取的元数据、压缩包条目清单整理为提供给模型的文本，meta 与 manifest 可为 nil
+func DescribeAttachment(file *models.MinioFile, meta *models.FileMetadata, manifest *models.ArchiveManifest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[附件 #%d] %s（%s，%s）", file.ID, file.FileName, file.ContentType, formatBytes(file.FileSize))
+
+	if meta != nil && meta.Status == models.MetadataStatusReady {
+		var fields []string
+		if meta.Duration > 0 {
+			fields = append(fields, fmt.Sprintf("时长 %.1f 秒", meta.Duration))
+		}
+		if meta.Width > 0 && meta.Height > 0 {
+			fields = append(fields, fmt.Sprintf("分辨率 %dx%d", meta.Width, meta.Height))
+		}
+		if meta.Codec != "" {
+			fields = append(fields, "编码 "+meta.Codec)
+		}
+		if meta.SampleRate > 0 {
+			fields = append(fields, fmt.Sprintf("采样率 %d Hz", meta.SampleRate))
+		}
+		if len(fields) > 0 {
+			b.WriteString("\n" + strings.Join(fields, "，"))
+		}
+	}
+
+	if manifest != nil && manifest.Status == models.ArchiveStatusReady {
+		fmt.Fprintf(&b, "\n压缩包共 %d 个条目，解压后 %s：", manifest.EntryCount, formatBytes(manifest.TotalSize))
+		for i, e := range manifest.Entries {
+			if i == maxListedEntries {
+				fmt.Fprintf(&b, "\n- ……其余 %d 个条目省略", len(manifest.Entries)-maxListedEntries)
+				break
+			}
+			if e.IsDir {
+				fmt.Fprintf(&b, "\n- %s", e.Name)
+			} else {
+				fmt.Fprintf(&b, "\n- %s（%s）", e.Name, formatBytes(e.Size))
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatBytes 以 1024 进制格式化字节数
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
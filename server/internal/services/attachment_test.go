@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveAttachments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	defer db.Close()
+	s := &AttachmentService{minioFileDAO: dao.NewMinioFileDAO()}
+
+	fileRow := func(id int64, uid int, scanStatus string) *sqlmock.Rows {
+		return sqlmock.NewRows(minioFileColumns).
+			AddRow(id, uid, "a.png", "files", "uid_7/sha256/abc", "image/png", 10, "abc", false, scanStatus, "", time.Now())
+	}
+
+	// 重复的ID只加载一次
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(int64(3)).WillReturnRows(fileRow(3, 7, models.ScanStatusClean))
+	files, err := s.Resolve(7, []int64{3, 3})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, int64(3), files[0].ID)
+
+	// 他人的文件按不存在处理
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(int64(4)).WillReturnRows(fileRow(4, 8, models.ScanStatusClean))
+	_, err = s.Resolve(7, []int64{4})
+	var attErr *AttachmentError
+	require.True(t, errors.As(err, &attErr))
+	assert.Equal(t, int64(4), attErr.FileID)
+	assert.ErrorIs(t, err, ErrFileNotFound)
+
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(int64(5)).WillReturnRows(fileRow(5, 7, models.ScanStatusPending))
+	_, err = s.Resolve(7, []int64{5})
+	assert.ErrorIs(t, err, ErrFileQuarantined)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDescribeAttachment(t *testing.T) {
+	video := &models.MinioFile{ID: 3, FileName: "demo.mp4", ContentType: "video/mp4", FileSize: 3 << 20}
+	meta := &models.FileMetadata{Status: models.MetadataStatusReady, Duration: 12.5, Width: 1280, Height: 720, Codec: "h264"}
+	assert.Equal(t, "[附件 #3] demo.mp4（video/mp4，3.0 MiB）\n时长 12.5 秒，分辨率 1280x720，编码 h264", DescribeAttachment(video, meta, nil))
+
+	// 元数据尚未提取完成时只给出基本信息
+	meta.Status = models.MetadataStatusPending
+	assert.Equal(t, "[附件 #3] demo.mp4（video/mp4，3.0 MiB）", DescribeAttachment(video, meta, nil))
+
+	entries := []models.ArchiveEntry{{Name: "docs/", IsDir: true}}
+	for i := 0; i < maxListedEntries+2; i++ {
+		entries = append(entries, models.ArchiveEntry{Name: fmt.Sprintf("docs/%d.txt", i), Size: 100})
+	}
+	zip := &models.MinioFile{ID: 4, FileName: "docs.zip", ContentType: "application/zip", FileSize: 512}
+	manifest := &models.ArchiveManifest{Status: models.ArchiveStatusReady, EntryCount: len(entries), TotalSize: 5300, Entries: entries}
+	text := DescribeAttachment(zip, nil, manifest)
+	assert.Contains(t, text, "[附件 #4] docs.zip（application/zip，512 B）\n压缩包共 53 个条目，解压后 5.2 KiB：\n- docs/\n- docs/0.txt（100 B）")
+	assert.Contains(t, text, "\n- ……其余 3 个条目省略")
+	assert.NotContains(t, text, "docs/49.txt")
+}
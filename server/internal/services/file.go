@@ -0,0 +1,359 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/filetype"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrFileNotFound 文件不存在或不属于当前用户
+var ErrFileNotFound = errors.New("file not found")
+
+// 文件内容类型校验失败的错误码
+const (
+	CodeFileTypeNotAllowed = "FILE_TYPE_NOT_ALLOWED"
+	CodeFileTypeMismatch   = "FILE_TYPE_MISMATCH"
+)
+
+// ContentTypeError 按魔数识别出的文件类型不在白名单中，或与扩展名不一致
+type ContentTypeError struct {
+	Code      string
+	Detected  string
+	Extension string
+}
+
+func (e *ContentTypeError) Error() string {
+	if e.Code == CodeFileTypeMismatch {
+		return fmt.Sprintf("detected content type %s does not match extension %s", e.Detected, e.Extension)
+	}
+	return fmt.Sprintf("detected content type %s is not allowed", e.Detected)
+}
+
+// FileService 文件库服务，负责内容寻址存储、去重，并保证数据库记录与对象存储的一致性
+type FileService struct {
+	minio        *storage.MinioService
+	minioFileDAO *dao.MinioFileDAO
+	blobDAO      *dao.FileBlobDAO
+	quota        *QuotaService
+	dedupScope   string
+	allowedTypes map[string]bool
+	hooks        []UploadHook
+	// scanStatus 新记录的初始扫描状态，启用扫描后为 pending
+	scanStatus string
+}
+
+// UploadHook 新文件入库后调用，用于挂接转码、缩略图等后续处理；不应阻塞
+type UploadHook func(file *models.MinioFile)
+
+// StoredFile 入库结果，Duplicate 表示内容已存在，返回的是已有记录
+type StoredFile struct {
+	File      *models.MinioFile
+	Duplicate bool
+}
+
+// NewFileService 创建新的文件库服务实例
+func NewFileService(minioSvc *storage.MinioService, quotaSvc *QuotaService, cfg config.UploadConfig) *FileService {
+	allowed := make(map[string]bool, len(cfg.AllowedMIMETypes))
+	for _, t := range cfg.AllowedMIMETypes {
+		allowed[t] = true
+	}
+	return &FileService{
+		minio:        minioSvc,
+		minioFileDAO: dao.NewMinioFileDAO(),
+		blobDAO:      dao.NewFileBlobDAO(),
+		quota:        quotaSvc,
+		dedupScope:   cfg.DedupScope,
+		allowedTypes: allowed,
+		scanStatus:   models.ScanStatusClean,
+	}
+}
+
+// QuarantineUntilScanned 新入库的文件以 pending 状态登记，扫描通过前不能播放或下载
+func (s *FileService) QuarantineUntilScanned() {
+	s.scanStatus = models.ScanStatusPending
+}
+
+// OnStored 注册新文件入库后的处理钩子，重复上传相同内容不会触发
+func (s *FileService) OnStored(hook UploadHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// StagingKey 生成用户上传暂存对象键，暂存对象入库后会被删除
+func StagingKey(uid int) string {
+	return fmt.Sprintf("uid_%d/staging/%s", uid, uuid.New().String())
+}
+
+// IncomingKey 生成预签名直传的暂存对象键，只有该格式的对象键可以登记入库
+func IncomingKey(uid int, fileName string) string {
+	return fmt.Sprintf("uid_%d/incoming/%s/%s", uid, uuid.New().String(), fileName)
+}
+
+// IsIncomingKey 判断对象键是否为 IncomingKey 为该用户生成的格式。
+// 内容寻址对象、衍生文件与历史对象都不在该前缀下，避免登记后被当作暂存对象删除
+func IsIncomingKey(uid int, key string) bool {
+	prefix := fmt.Sprintf("uid_%d/incoming/", uid)
+	if !strings.HasPrefix(key, prefix) || strings.Contains(key, "sha256/") || strings.Contains(key, ".derived/") {
+		return false
+	}
+	id, name, ok := strings.Cut(strings.TrimPrefix(key, prefix), "/")
+	if !ok || name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return false
+	}
+	_, err := uuid.Parse(id)
+	return err == nil && len(id) == 36
+}
+
+// Store 识别文件真实类型后边上传边计算 SHA-256，先写入暂存对象，再按内容哈希入库。
+// 类型不合规时返回 *ContentTypeError，超出配额时返回 ErrQuotaExceeded。
+func (s *FileService) Store(ctx context.Context, record *models.MinioFile, r io.Reader) (*StoredFile, error) {
+	br := bufio.NewReaderSize(r, filetype.SniffLen)
+	head, err := br.Peek(filetype.SniffLen)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	if err := s.checkContentType(record, head); err != nil {
+		return nil, err
+	}
+
+	stagingKey := StagingKey(record.Uid)
+	h := sha256.New()
+	if err := s.minio.Upload(ctx, stagingKey, io.TeeReader(br, h), record.FileSize, record.ContentType); err != nil {
+		return nil, err
+	}
+	record.SHA256 = hex.EncodeToString(h.Sum(nil))
+	stored, err := s.commit(ctx, record, stagingKey)
+	if err != nil && !IsRejected(err) {
+		// 暂存对象由服务端生成，调用方无法重试，失败时一并清理
+		s.removeStaging(stagingKey)
+	}
+	return stored, err
+}
+
+// Register 登记已写入对象存储的对象（直传、分片上传），读取对象识别类型并计算哈希后按内容入库。
+// 类型不合规或超出配额时删除该对象并返回错误；其他错误保留该对象，调用方可用同一对象键重试。
+func (s *FileService) Register(ctx context.Context, record *models.MinioFile, stagingKey string) (*StoredFile, error) {
+	obj, _, err := s.minio.GetObject(ctx, stagingKey)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, filetype.SniffLen)
+	n, err := io.ReadFull(obj, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		obj.Close()
+		return nil, fmt.Errorf("failed to read object header: %w", err)
+	}
+	head = head[:n]
+	if err := s.checkContentType(record, head); err != nil {
+		obj.Close()
+		if rerr := s.minio.RemoveObject(ctx, stagingKey); rerr != nil {
+			logger.Logger.Warn("failed to remove rejected object", zap.String("object_key", stagingKey), zap.Error(rerr))
+		}
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(head)
+	_, err = io.Copy(h, obj)
+	obj.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash object: %w", err)
+	}
+	record.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return s.commit(ctx, record, stagingKey)
+}
+
+// checkContentType 按文件头识别类型并与白名单、扩展名比对，通过后以识别结果作为记录的 content_type
+func (s *FileService) checkContentType(record *models.MinioFile, head []byte) error {
+	detected := filetype.Detect(head)
+	ext := filepath.Ext(record.FileName)
+	if !s.allowedTypes[detected] {
+		return &ContentTypeError{Code: CodeFileTypeNotAllowed, Detected: detected, Extension: ext}
+	}
+	if !filetype.MatchesExtension(ext, detected) {
+		return &ContentTypeError{Code: CodeFileTypeMismatch, Detected: detected, Extension: ext}
+	}
+	record.ContentType = detected
+	return nil
+}
+
+// FindBySHA256 查找当前用户是否已上传过相同内容，不存在时返回 nil
+func (s *FileService) FindBySHA256(uid int, sha256 string) (*models.MinioFile, error) {
+	return s.minioFileDAO.GetByUIDAndSHA256(uid, sha256)
+}
+
+// contentKey 按去重范围生成内容寻址对象键
+func (s *FileService) contentKey(uid int, sha256 string) string {
+	if s.dedupScope == config.DedupScopeGlobal {
+		return fmt.Sprintf("sha256/%s/%s", sha256[:2], sha256)
+	}
+	return fmt.Sprintf("uid_%d/sha256/%s", uid, sha256)
+}
+
+// IsRejected 判断入库错误是否为内容被拒绝（类型不合规、超出配额），此时暂存对象已删除，重试不会成功
+func IsRejected(err error) bool {
+	var ctErr *ContentTypeError
+	return errors.Is(err, ErrQuotaExceeded) || errors.As(err, &ctErr)
+}
+
+// commit 将暂存对象转为内容寻址对象，在同一事务中计入用量并写入记录。
+// 入库成功或被拒绝时删除暂存对象，其他错误保留暂存对象以便重试
+func (s *FileService) commit(ctx context.Context, record *models.MinioFile, stagingKey string) (stored *StoredFile, err error) {
+	defer func() {
+		if err != nil && !IsRejected(err) {
+			return
+		}
+		// 暂存对象与在用对象重合时绝不删除
+		if stagingKey == record.ObjectKey || (stored != nil && stagingKey == stored.File.ObjectKey) {
+			logger.Logger.Warn("staging key refers to a stored object, keeping it", zap.String("object_key", stagingKey))
+			return
+		}
+		s.removeStaging(stagingKey)
+	}()
+
+	// 同一用户重复上传相同内容直接返回已有记录
+	if existing, err := s.minioFileDAO.GetByUIDAndSHA256(record.Uid, record.SHA256); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return &StoredFile{File: existing, Duplicate: true}, nil
+	}
+
+	// 生成内容对象前先预检查配额，避免超额时留下无引用的对象
+	if err := s.quota.Check(record.Uid, record.FileSize); err != nil {
+		return nil, err
+	}
+	record.Bucket = s.minio.Bucket()
+	record.ObjectKey = s.contentKey(record.Uid, record.SHA256)
+	record.ScanStatus = s.scanStatus
+	if err := s.ensureObject(ctx, stagingKey, record.ObjectKey); err != nil {
+		return nil, err
+	}
+
+	var created bool
+	err = database.WithTx(func(tx *sql.Tx) error {
+		if err := s.quota.Charge(tx, record.Uid, record.FileSize); err != nil {
+			return err
+		}
+		var err error
+		if created, err = s.blobDAO.Acquire(tx, record.Bucket, record.ObjectKey, record.SHA256, record.FileSize); err != nil {
+			return err
+		}
+		return s.minioFileDAO.Create(tx, record)
+	})
+	if errors.Is(err, dao.ErrDuplicateFile) {
+		// 并发上传相同内容，以先提交的记录为准
+		existing, err := s.minioFileDAO.GetByUIDAndSHA256(record.Uid, record.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return &StoredFile{File: existing, Duplicate: true}, nil
+		}
+		return nil, dao.ErrDuplicateFile
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 对象记录为新建时，期间可能有并发删除回收了同一对象，需再次确认
+	if created {
+		if err := s.ensureObject(ctx, stagingKey, record.ObjectKey); err != nil {
+			if derr := s.Delete(ctx, record.Uid, record.ID); derr != nil {
+				logger.Logger.Error("failed to roll back file record", zap.Int64("file_id", record.ID), zap.Error(derr))
+			}
+			return nil, err
+		}
+	}
+
+	for _, hook := range s.hooks {
+		hook(record)
+	}
+	return &StoredFile{File: record}, nil
+}
+
+func (s *FileService) removeStaging(stagingKey string) {
+	if err := s.minio.RemoveObject(context.Background(), stagingKey); err != nil {
+		logger.Logger.Warn("failed to remove staging object", zap.String("object_key", stagingKey), zap.Error(err))
+	}
+}
+
+// ensureObject 内容对象不存在时由暂存对象复制生成
+func (s *FileService) ensureObject(ctx context.Context, stagingKey, objectKey string) error {
+	exists, err := s.minio.ObjectExists(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return s.minio.CopyObject(ctx, stagingKey, objectKey)
+}
+
+// Delete 删除用户的文件记录并释放用量，在对象不再被引用时删除对象。
+// 记录在事务中加锁删除，对象在事务提交后才删除，避免回滚时留下指向已删除对象的记录；
+// 对象删除失败只记录日志，留下的孤儿对象不影响任何记录。
+func (s *FileService) Delete(ctx context.Context, uid int, id int64) error {
+	var orphan *models.MinioFile
+	err := database.WithTx(func(tx *sql.Tx) error {
+		file, err := s.minioFileDAO.GetByIDForUpdate(tx, uid, id)
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			return ErrFileNotFound
+		}
+		if err := s.quota.Refund(tx, file.Uid, file.FileSize); err != nil {
+			return err
+		}
+		if err := s.minioFileDAO.Delete(tx, file.ID); err != nil {
+			return err
+		}
+
+		refs, found, err := s.blobDAO.Release(tx, file.Bucket, file.ObjectKey)
+		if err != nil {
+			return err
+		}
+		if !found {
+			// 内容寻址之前上传的文件没有引用计数，按引用该对象的记录数判断
+			if refs, err = s.minioFileDAO.CountByObjectKey(tx, file.Bucket, file.ObjectKey); err != nil {
+				return err
+			}
+		}
+		if refs == 0 {
+			orphan = file
+		}
+		return nil
+	})
+	if err != nil || orphan == nil {
+		return err
+	}
+
+	if err := s.minio.RemoveObject(ctx, orphan.ObjectKey); err != nil {
+		logger.Logger.Error("failed to remove orphaned object",
+			zap.Int64("file_id", orphan.ID),
+			zap.String("object_key", orphan.ObjectKey),
+			zap.Error(err),
+		)
+	}
+	// 衍生对象可由原文件重新生成，删除失败不影响主流程
+	if err := s.minio.RemovePrefix(ctx, orphan.DerivedPrefix()); err != nil {
+		logger.Logger.Warn("failed to remove derived objects", zap.String("prefix", orphan.DerivedPrefix()), zap.Error(err))
+	}
+	return nil
+}
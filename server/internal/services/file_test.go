@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var minioFileColumns = []string{"id", "uid", "file_name", "bucket", "object_key", "content_type", "file_size", "sha256", "is_shared", "scan_status", "scan_result", "created_at"}
+
+func TestContentKeyByDedupScope(t *testing.T) {
+	sha := "ab23456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	user := &FileService{dedupScope: config.DedupScopeUser}
+	assert.Equal(t, "uid_7/sha256/"+sha, user.contentKey(7, sha))
+
+	global := &FileService{dedupScope: config.DedupScopeGlobal}
+	assert.Equal(t, "sha256/ab/"+sha, global.contentKey(7, sha))
+}
+
+func TestDeleteKeepsSharedObject(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	s := NewFileService(nil, NewQuotaService(config.QuotaConfig{}), config.UploadConfig{DedupScope: config.DedupScopeGlobal})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\? AND uid = \\? FOR UPDATE").
+		WithArgs(int64(3), 7).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, "a.mp4", "files", "sha256/ab/abc", "video/mp4", 10, "abc", false, "clean", "", time.Now()))
+	mock.ExpectExec("INSERT IGNORE INTO user_storage_usage").WithArgs(7, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = \\? FOR UPDATE").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "file_count"}).AddRow(30, 2))
+	mock.ExpectExec("UPDATE user_storage_usage").WithArgs(int64(-10), -1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT ref_count FROM file_blobs").WithArgs("files", "sha256/ab/abc").
+		WillReturnRows(sqlmock.NewRows([]string{"ref_count"}).AddRow(2))
+	mock.ExpectExec("UPDATE file_blobs SET ref_count = ref_count - 1").WithArgs("files", "sha256/ab/abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// 对象仍被其它用户引用，不会访问对象存储
+	require.NoError(t, s.Delete(context.Background(), 7, 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectDeleteLastRef 预期删除用户 7 的记录 3，且它是对象 uid_7/sha256/abc 的最后一个引用
+func expectDeleteLastRef(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\? AND uid = \\? FOR UPDATE").
+		WithArgs(int64(3), 7).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, "a.mp4", miniotest.Bucket, "uid_7/sha256/abc", "video/mp4", 10, "abc", false, "clean", "", time.Now()))
+	mock.ExpectExec("INSERT IGNORE INTO user_storage_usage").WithArgs(7, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = \\? FOR UPDATE").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "file_count"}).AddRow(10, 1))
+	mock.ExpectExec("UPDATE user_storage_usage").WithArgs(int64(-10), -1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT ref_count FROM file_blobs").WithArgs(miniotest.Bucket, "uid_7/sha256/abc").
+		WillReturnRows(sqlmock.NewRows([]string{"ref_count"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM file_blobs").WithArgs(miniotest.Bucket, "uid_7/sha256/abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestDeleteRemovesObjectAfterCommit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	store, minioSvc := miniotest.Start(t)
+	store.Put("uid_7/sha256/abc", []byte("video"), "video/mp4")
+	store.Put("uid_7/sha256/abc.derived/thumb.jpg", []byte("thumb"), "image/jpeg")
+	s := NewFileService(minioSvc, NewQuotaService(config.QuotaConfig{}), config.UploadConfig{DedupScope: config.DedupScopeUser})
+
+	// 提交失败时事务回滚，对象保留
+	expectDeleteLastRef(mock)
+	mock.ExpectCommit().WillReturnError(errors.New("connection lost"))
+	require.Error(t, s.Delete(context.Background(), 7, 3))
+	assert.Len(t, store.Keys(), 2)
+
+	expectDeleteLastRef(mock)
+	mock.ExpectCommit()
+	require.NoError(t, s.Delete(context.Background(), 7, 3))
+	assert.Empty(t, store.Keys())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteIgnoresObjectRemovalFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	store, minioSvc := miniotest.Start(t)
+	store.Put("uid_7/sha256/abc", []byte("video"), "video/mp4")
+	store.Fail = func(r *http.Request) bool { return r.Method == http.MethodDelete }
+	s := NewFileService(minioSvc, NewQuotaService(config.QuotaConfig{}), config.UploadConfig{DedupScope: config.DedupScopeUser})
+
+	// 记录已删除，对象删除失败只留下孤儿对象
+	expectDeleteLastRef(mock)
+	mock.ExpectCommit()
+	require.NoError(t, s.Delete(context.Background(), 7, 3))
+	assert.Equal(t, []string{"uid_7/sha256/abc"}, store.Keys())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteMissingFile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	s := NewFileService(nil, NewQuotaService(config.QuotaConfig{}), config.UploadConfig{DedupScope: config.DedupScopeUser})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WillReturnRows(sqlmock.NewRows(minioFileColumns))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, s.Delete(context.Background(), 7, 3), ErrFileNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckContentType(t *testing.T) {
+	s := NewFileService(nil, NewQuotaService(config.QuotaConfig{}), config.UploadConfig{AllowedMIMETypes: []string{"video/mp4", "image/png"}})
+	mp4 := []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00isomiso2")
+
+	record := &models.MinioFile{FileName: "clip.mp4", ContentType: "application/x-msdownload"}
+	require.NoError(t, s.checkContentType(record, mp4))
+	assert.Equal(t, "video/mp4", record.ContentType)
+
+	var ctErr *ContentTypeError
+	err := s.checkContentType(&models.MinioFile{FileName: "setup.mp4"}, []byte("MZ\x90\x00\x03\x00\x00\x00"))
+	require.ErrorAs(t, err, &ctErr)
+	assert.Equal(t, CodeFileTypeNotAllowed, ctErr.Code)
+
+	err = s.checkContentType(&models.MinioFile{FileName: "clip.png"}, mp4)
+	require.ErrorAs(t, err, &ctErr)
+	assert.Equal(t, CodeFileTypeMismatch, ctErr.Code)
+}
+
+func TestRegisterNeverRemovesStoredObject(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	store, minioSvc := miniotest.Start(t)
+	s := NewFileService(minioSvc, NewQuotaService(config.QuotaConfig{}), config.UploadConfig{
+		AllowedMIMETypes: []string{"image/png"},
+		DedupScope:       config.DedupScopeUser,
+	})
+
+	data := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	sum := sha256.Sum256(data)
+	sha := hex.EncodeToString(sum[:])
+	contentKey := "uid_7/sha256/" + sha
+	store.Put(contentKey, data, "")
+
+	// 以已登记的内容对象作为暂存键时命中重复记录，对象不能被当作暂存对象删除
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE uid = \\? AND sha256 = \\?").WithArgs(7, sha).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(12, 7, "a.png", miniotest.Bucket, contentKey, "image/png", len(data), sha, false, "clean", "", time.Now()))
+	stored, err := s.Register(context.Background(), &models.MinioFile{Uid: 7, FileName: "a.png", FileSize: int64(len(data))}, contentKey)
+	require.NoError(t, err)
+	assert.True(t, stored.Duplicate)
+	assert.Equal(t, []string{contentKey}, store.Keys())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
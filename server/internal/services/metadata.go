@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"go.uber.org/zap"
+)
+
+// MetadataJobType 元数据提取任务类型
+const MetadataJobType = "file.metadata"
+
+type metadataPayload struct {
+	FileID int64 `json:"file_id"`
+}
+
+// MetadataService 音视频与图片上传后通过后台任务提取媒体元数据
+type MetadataService struct {
+	minio        *storage.MinioService
+	prober       media.Prober
+	jobs         *jobs.Pool
+	metadataDAO  *dao.FileMetadataDAO
+	minioFileDAO *dao.MinioFileDAO
+	cfg          config.MetadataConfig
+}
+
+// NewMetadataService 创建新的元数据服务实例
+func NewMetadataService(minioSvc *storage.MinioService, prober media.Prober, jobPool *jobs.Pool, cfg config.MetadataConfig) *MetadataService {
+	return &MetadataService{
+		minio:        minioSvc,
+		prober:       prober,
+		jobs:         jobPool,
+		metadataDAO:  dao.NewFileMetadataDAO(),
+		minioFileDAO: dao.NewMinioFileDAO(),
+		cfg:          cfg,
+	}
+}
+
+// MediaKind 返回文件对应的媒体类别，不支持提取元数据的文件返回空字符串
+func MediaKind(file *models.MinioFile) string {
+	switch {
+	case file.IsVideo():
+		return models.MediaKindVideo
+	case file.IsAudio():
+		return models.MediaKindAudio
+	case file.IsImage():
+		return models.MediaKindImage
+	default:
+		return ""
+	}
+}
+
+// Submit 上传钩子：为音视频与图片登记元数据状态并入队提取任务
+func (s *MetadataService) Submit(file *models.MinioFile) {
+	if MediaKind(file) == "" {
+		return
+	}
+	if err := s.metadataDAO.Upsert(file.ID); err != nil {
+		logger.Logger.Error("failed to create metadata record", zap.Int64("file_id", file.ID), zap.Error(err))
+		return
+	}
+	if _, err := s.jobs.Enqueue(context.Background(), MetadataJobType, metadataPayload{FileID: file.ID}); err != nil {
+		logger.Logger.Error("failed to enqueue metadata job", zap.Int64("file_id", file.ID), zap.Error(err))
+		if uerr := s.metadataDAO.MarkFailed(file.ID, "failed to enqueue"); uerr != nil {
+			logger.Logger.Error("failed to mark metadata failed", zap.Int64("file_id", file.ID), zap.Error(uerr))
+		}
+	}
+}
+
+// HandleJob 元数据提取任务处理器
+func (s *MetadataService) HandleJob(ctx context.Context, job *jobs.Job) error {
+	var p metadataPayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	err := s.Process(ctx, p.FileID)
+	if err != nil && job.LastAttempt(err) {
+		if uerr := s.metadataDAO.MarkFailed(p.FileID, err.Error()); uerr != nil {
+			logger.Logger.Error("failed to mark metadata failed", zap.Int64("file_id", p.FileID), zap.Error(uerr))
+		}
+	}
+	return err
+}
+
+// Get 返回文件的元数据记录，未登记时返回 nil
+func (s *MetadataService) Get(fileID int64) (*models.FileMetadata, error) {
+	return s.metadataDAO.Get(fileID)
+}
+
+// Process 下载原文件提取元数据并保存；失败时只返回错误，由 HandleJob 在不再重试时记录为 failed
+func (s *MetadataService) Process(ctx context.Context, fileID int64) error {
+	file, err := s.minioFileDAO.GetByID(fileID)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return nil // 提取前文件已被删除
+	}
+
+	m, err := s.extract(ctx, file)
+	if err != nil {
+		return err
+	}
+	return s.metadataDAO.Save(m)
+}
+
+func (s *MetadataService) extract(ctx context.Context, file *models.MinioFile) (*models.FileMetadata, error) {
+	kind := MediaKind(file)
+	if kind == "" {
+		return nil, jobs.Permanent(fmt.Errorf("unsupported file type %s", file.ContentType))
+	}
+
+	workDir, err := os.MkdirTemp("", "metadata-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "input"+strings.ToLower(filepath.Ext(file.FileName)))
+	if err := s.minio.DownloadFile(ctx, file.ObjectKey, input); err != nil {
+		return nil, err
+	}
+
+	m := &models.FileMetadata{FileID: file.ID, Kind: kind}
+	if kind == models.MediaKindImage {
+		info, err := media.ReadImageInfo(input, s.cfg.KeepGPS)
+		if err != nil {
+			// 文件内容不会变化，重试也无法解析
+			return nil, jobs.Permanent(err)
+		}
+		m.Width, m.Height, m.EXIF = info.Width, info.Height, info.EXIF
+		return m, nil
+	}
+
+	info, err := s.prober.Probe(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	m.Duration, m.Bitrate = info.Duration, info.Bitrate
+	if kind == models.MediaKindVideo {
+		m.Width, m.Height, m.Codec = info.Width, info.Height, info.VideoCodec
+	} else {
+		m.Codec = info.AudioCodec
+	}
+	m.SampleRate, m.Channels = info.SampleRate, info.Channels
+	return m, nil
+}
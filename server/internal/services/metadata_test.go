@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProber struct {
+	info *media.MediaInfo
+	err  error
+}
+
+func (p stubProber) Probe(ctx context.Context, inputPath string) (*media.MediaInfo, error) {
+	return p.info, p.err
+}
+
+func newMetadataTest(t *testing.T, prober media.Prober) (*MetadataService, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	store, minioSvc := miniotest.Start(t)
+	store.Put("uid_7/sha256/abc", []byte("video"), "video/mp4")
+	return NewMetadataService(minioSvc, prober, nil, config.MetadataConfig{Timeout: time.Minute}), mock
+}
+
+func expectMetadataFile(mock sqlmock.Sqlmock, fileName, contentType string) {
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, fileName, miniotest.Bucket, "uid_7/sha256/abc", contentType, 5, "abc", false, "clean", "", time.Now()))
+}
+
+func TestMetadataProcess(t *testing.T) {
+	s, mock := newMetadataTest(t, stubProber{info: &media.MediaInfo{Duration: 1.5, Width: 640, Height: 360, VideoCodec: "h264"}})
+
+	expectMetadataFile(mock, "a.mp4", "video/mp4")
+	mock.ExpectExec("UPDATE file_metadata SET status = \\?, kind = \\?").
+		WithArgs(models.MetadataStatusReady, models.MediaKindVideo, 1.5, 640, 360, "h264", int64(0), 0, 0, nil, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMetadataFailsOnlyOnLastAttempt(t *testing.T) {
+	s, mock := newMetadataTest(t, stubProber{err: errors.New("ffprobe timed out")})
+	job := &jobs.Job{ID: 1, Payload: []byte(`{"file_id":3}`), Attempts: 1, MaxAttempts: 2}
+
+	// 仍会重试时不改状态
+	expectMetadataFile(mock, "a.mp4", "video/mp4")
+	assert.Error(t, s.HandleJob(context.Background(), job))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	job.Attempts = 2
+	expectMetadataFile(mock, "a.mp4", "video/mp4")
+	mock.ExpectExec("UPDATE file_metadata SET status = \\?, error = \\?").
+		WithArgs(models.MetadataStatusFailed, "ffprobe timed out", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Error(t, s.HandleJob(context.Background(), job))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 永久错误第一次就记录为 failed
+	job.Attempts = 1
+	expectMetadataFile(mock, "a.zip", "application/zip")
+	mock.ExpectExec("UPDATE file_metadata SET status = \\?, error = \\?").
+		WithArgs(models.MetadataStatusFailed, "unsupported file type application/zip", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	err := s.HandleJob(context.Background(), job)
+	assert.True(t, jobs.IsPermanent(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
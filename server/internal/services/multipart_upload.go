@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUploadNotActive 分片上传已完成或已取消
+	ErrUploadNotActive = errors.New("upload is not active")
+	// ErrInvalidPart 分片号越界或分片大小与约定不符
+	ErrInvalidPart = errors.New("invalid part")
+	// ErrIncompleteUpload 仍有分片未上传
+	ErrIncompleteUpload = errors.New("upload is incomplete")
+)
+
+// UploadProgress 分片上传进度
+type UploadProgress struct {
+	Upload        *models.MultipartUpload `json:"upload"`
+	PartCount     int                     `json:"part_count"`
+	UploadedParts []UploadedPart          `json:"uploaded_parts"`
+	UploadedBytes int64                   `json:"uploaded_bytes"`
+}
+
+// UploadedPart 已上传的分片
+type UploadedPart struct {
+	PartNumber int    `json:"part_number"`
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag"`
+}
+
+// MultipartUploadService 基于 MinIO 分片上传的断点续传服务
+type MultipartUploadService struct {
+	minio     *storage.MinioService
+	uploadDAO *dao.MultipartUploadDAO
+	cfg       config.MultipartConfig
+}
+
+// NewMultipartUploadService 创建新的分片上传服务实例
+func NewMultipartUploadService(minioSvc *storage.MinioService, cfg config.MultipartConfig) *MultipartUploadService {
+	return &MultipartUploadService{
+		minio:     minioSvc,
+		uploadDAO: dao.NewMultipartUploadDAO(),
+		cfg:       cfg,
+	}
+}
+
+// Initiate 创建分片上传会话
+func (s *MultipartUploadService) Initiate(ctx context.Context, uid int, fileName string, size int64, contentType string) (*models.MultipartUpload, error) {
+	id := uuid.New().String()
+	objectKey := fmt.Sprintf("uid_%d/%s/%s", uid, id, fileName)
+
+	storageUploadID, err := s.minio.NewMultipartUpload(ctx, objectKey, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &models.MultipartUpload{
+		ID:            id,
+		Uid:           uid,
+		StorageUpload: storageUploadID,
+		ObjectKey:     objectKey,
+		FileName:      fileName,
+		FileSize:      size,
+		ContentType:   contentType,
+		PartSize:      s.cfg.PartSize,
+		Status:        models.MultipartStatusUploading,
+	}
+	if err := s.uploadDAO.Create(upload); err != nil {
+		_ = s.minio.AbortMultipartUpload(ctx, objectKey, storageUploadID)
+		return nil, err
+	}
+	return upload, nil
+}
+
+// Get 获取属于指定用户的上传会话，不存在时返回 nil
+func (s *MultipartUploadService) Get(uid int, id string) (*models.MultipartUpload, error) {
+	return s.uploadDAO.GetByID(uid, id)
+}
+
+// UploadPart 上传单个分片，重复上传同一分片会覆盖旧数据，便于断点重试
+func (s *MultipartUploadService) UploadPart(ctx context.Context, upload *models.MultipartUpload, partNumber int, r io.Reader, size int64) (*UploadedPart, error) {
+	if upload.Status != models.MultipartStatusUploading {
+		return nil, ErrUploadNotActive
+	}
+	if partNumber < 1 || partNumber > upload.PartCount() || size != upload.ExpectedPartSize(partNumber) {
+		return nil, ErrInvalidPart
+	}
+
+	part, err := s.minio.PutObjectPart(ctx, upload.ObjectKey, upload.StorageUpload, partNumber, r, size)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.uploadDAO.Touch(upload.ID); err != nil {
+		logger.Logger.Warn("failed to touch multipart upload", zap.String("upload_id", upload.ID), zap.Error(err))
+	}
+	return &UploadedPart{PartNumber: partNumber, Size: size, ETag: part.ETag}, nil
+}
+
+// Progress 以对象存储中的分片列表为准返回上传进度
+func (s *MultipartUploadService) Progress(ctx context.Context, upload *models.MultipartUpload) (*UploadProgress, error) {
+	progress := &UploadProgress{
+		Upload:        upload,
+		PartCount:     upload.PartCount(),
+		UploadedParts: []UploadedPart{},
+	}
+	if upload.Status != models.MultipartStatusUploading {
+		if upload.Status == models.MultipartStatusAssembled || upload.Status == models.MultipartStatusCompleted {
+			progress.UploadedBytes = upload.FileSize
+		}
+		return progress, nil
+	}
+
+	parts, err := s.minio.ListObjectParts(ctx, upload.ObjectKey, upload.StorageUpload)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parts {
+		progress.UploadedParts = append(progress.UploadedParts, UploadedPart{PartNumber: p.PartNumber, Size: p.Size, ETag: p.ETag})
+		progress.UploadedBytes += p.Size
+	}
+	sort.Slice(progress.UploadedParts, func(i, j int) bool {
+		return progress.UploadedParts[i].PartNumber < progress.UploadedParts[j].PartNumber
+	})
+	return progress, nil
+}
+
+// Complete 校验全部分片齐全后合并为完整对象，会话进入 assembled 状态，登记入库后再调用 MarkCompleted。
+// 已合并的会话直接返回，便于登记失败后重试
+func (s *MultipartUploadService) Complete(ctx context.Context, upload *models.MultipartUpload) error {
+	if upload.Status == models.MultipartStatusAssembled {
+		return nil
+	}
+	if upload.Status != models.MultipartStatusUploading {
+		return ErrUploadNotActive
+	}
+
+	parts, err := s.minio.ListObjectParts(ctx, upload.ObjectKey, upload.StorageUpload)
+	if err != nil {
+		return err
+	}
+	byNumber := make(map[int]minio.ObjectPart, len(parts))
+	for _, p := range parts {
+		byNumber[p.PartNumber] = p
+	}
+
+	complete := make([]minio.CompletePart, 0, upload.PartCount())
+	for n := 1; n <= upload.PartCount(); n++ {
+		p, ok := byNumber[n]
+		if !ok || p.Size != upload.ExpectedPartSize(n) {
+			return ErrIncompleteUpload
+		}
+		complete = append(complete, minio.CompletePart{PartNumber: n, ETag: p.ETag})
+	}
+
+	// 先抢占状态，避免并发的 complete/abort 重复处理
+	ok, err := s.uploadDAO.UpdateStatus(upload.ID, models.MultipartStatusUploading, models.MultipartStatusAssembled)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUploadNotActive
+	}
+	if err := s.minio.CompleteMultipartUpload(ctx, upload.ObjectKey, upload.StorageUpload, complete); err != nil {
+		if _, rerr := s.uploadDAO.UpdateStatus(upload.ID, models.MultipartStatusAssembled, models.MultipartStatusUploading); rerr != nil {
+			logger.Logger.Error("failed to roll back multipart upload status", zap.String("upload_id", upload.ID), zap.Error(rerr))
+		}
+		return err
+	}
+	upload.Status = models.MultipartStatusAssembled
+	return nil
+}
+
+// MarkCompleted 合并后的对象已登记入库，结束会话
+func (s *MultipartUploadService) MarkCompleted(upload *models.MultipartUpload) error {
+	ok, err := s.uploadDAO.UpdateStatus(upload.ID, models.MultipartStatusAssembled, models.MultipartStatusCompleted)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUploadNotActive
+	}
+	upload.Status = models.MultipartStatusCompleted
+	return nil
+}
+
+// Abort 取消上传并释放对象存储中的分片；已合并但未登记的会话删除合并后的对象
+func (s *MultipartUploadService) Abort(ctx context.Context, upload *models.MultipartUpload) error {
+	if upload.Status == models.MultipartStatusAssembled {
+		ok, err := s.uploadDAO.UpdateStatus(upload.ID, models.MultipartStatusAssembled, models.MultipartStatusAborted)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrUploadNotActive
+		}
+		upload.Status = models.MultipartStatusAborted
+		return s.minio.RemoveObject(ctx, upload.ObjectKey)
+	}
+
+	ok, err := s.uploadDAO.UpdateStatus(upload.ID, models.MultipartStatusUploading, models.MultipartStatusAborted)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUploadNotActive
+	}
+	upload.Status = models.MultipartStatusAborted
+	return s.minio.AbortMultipartUpload(ctx, upload.ObjectKey, upload.StorageUpload)
+}
+
+// CleanupStale 取消超过 stale_after 未活跃的上传（含合并后迟迟未登记的会话），返回清理数量
+func (s *MultipartUploadService) CleanupStale(ctx context.Context) (int, error) {
+	stale, err := s.uploadDAO.ListStale(time.Now().Add(-s.cfg.StaleAfter), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	cleaned := 0
+	for i := range stale {
+		if err := s.Abort(ctx, &stale[i]); err != nil && !errors.Is(err, ErrUploadNotActive) {
+			logger.Logger.Warn("failed to abort stale multipart upload",
+				zap.String("upload_id", stale[i].ID),
+				zap.Error(err))
+			continue
+		}
+		cleaned++
+	}
+	return cleaned, nil
+}
+
+// RunJanitor 按 gc_interval 周期清理过期上传，直到 ctx 取消
+func (s *MultipartUploadService) RunJanitor(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.GCInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.CleanupStale(ctx)
+			if err != nil {
+				logger.Logger.Error("failed to clean up stale multipart uploads", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				logger.Logger.Info("cleaned up stale multipart uploads", zap.Int("count", n))
+			}
+		}
+	}
+}
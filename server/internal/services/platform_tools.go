@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/mcp"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/tools"
+)
+
+// 平台 MCP 工具的分页与搜索限制
+const (
+	platformDefaultPageSize = 20
+	platformMaxPageSize     = 50
+	// searchSnippetRunes 搜索结果中消息内容保留的字符数，以命中位置为中心截取
+	searchSnippetRunes = 200
+)
+
+// PlatformInfo 平台作为 MCP 服务器时上报的信息
+var PlatformInfo = mcp.Implementation{Name: "ai-hackathon", Version: "1.0.0"}
+
+// PlatformInstructions 在 initialize 中提示调用方如何使用平台工具
+const PlatformInstructions = "访问当前登录用户上传的文件与聊天记录。先用 list_files 或 search_conversations 查找，再用 get_file_metadata 查看文件详情。"
+
+// PlatformToolService 平台自身以 MCP 工具提供的能力：列出文件、查看文件元数据、搜索会话。
+// 每个工具只访问调用方 uid 有权访问的数据，与 /api/v1 对应接口的授权规则一致
+type PlatformToolService struct {
+	minioFileDAO    *dao.MinioFileDAO
+	conversationDAO *dao.ConversationDAO
+	metadata        *MetadataService
+	archives        *ArchiveService
+}
+
+// NewPlatformToolService 创建平台工具服务
+func NewPlatformToolService(metadataSvc *MetadataService, archiveSvc *ArchiveService) *PlatformToolService {
+	return &PlatformToolService{
+		minioFileDAO:    dao.NewMinioFileDAO(),
+		conversationDAO: dao.NewConversationDAO(),
+		metadata:        metadataSvc,
+		archives:        archiveSvc,
+	}
+}
+
+// NewPlatformServer 创建注册了平台工具的 MCP 服务端
+func NewPlatformServer(s *PlatformToolService) (*mcp.Server, error) {
+	server := mcp.NewServer(PlatformInfo, PlatformInstructions)
+	for _, t := range s.Tools() {
+		if err := server.AddTool(t); err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+// Tools 返回平台工具
+func (s *PlatformToolService) Tools() []mcp.ServerTool {
+	one, maxPage, maxPageNum := 1.0, float64(platformMaxPageSize), 10000.0
+	minQuery, maxQuery := 1, 255
+	return []mcp.ServerTool{
+		{
+			Name:        "list_files",
+			Description: "分页列出我上传的文件，按上传时间倒序，可按文件名关键字与 MIME 类型前缀筛选",
+			InputSchema: &tools.Schema{
+				Type: "object",
+				Properties: map[string]*tools.Schema{
+					"query":        {Type: "string", Description: "文件名关键字", MaxLength: &maxQuery},
+					"content_type": {Type: "string", Description: "MIME 类型前缀，如 image/、video/、application/zip", MaxLength: &maxQuery},
+					"page":         {Type: "integer", Description: "页码，从 1 开始", Minimum: &one, Maximum: &maxPageNum},
+					"page_size":    {Type: "integer", Description: "每页条数，默认 20", Minimum: &one, Maximum: &maxPage},
+				},
+				AdditionalProperties: boolPtr(false),
+			},
+			Call: s.listFiles,
+		},
+		{
+			Name:        "get_file_metadata",
+			Description: "获取文件详情：文件信息、音视频与图片的媒体元数据（时长、分辨率、编码、EXIF）、压缩包条目摘要",
+			InputSchema: &tools.Schema{
+				Type: "object",
+				Properties: map[string]*tools.Schema{
+					"file_id": {Type: "integer", Description: "文件ID", Minimum: &one},
+				},
+				Required:             []string{"file_id"},
+				AdditionalProperties: boolPtr(false),
+			},
+			Call: s.fileMetadata,
+		},
+		{
+			Name:        "search_conversations",
+			Description: "在我的全部聊天记录中按关键字搜索消息内容与会话标题，按时间倒序返回命中的消息",
+			InputSchema: &tools.Schema{
+				Type: "object",
+				Properties: map[string]*tools.Schema{
+					"query": {Type: "string", Description: "关键字", MinLength: &minQuery, MaxLength: &maxQuery},
+					"limit": {Type: "integer", Description: "最多返回条数，默认 20", Minimum: &one, Maximum: &maxPage},
+				},
+				Required:             []string{"query"},
+				AdditionalProperties: boolPtr(false),
+			},
+			Call: s.searchConversations,
+		},
+	}
+}
+
+// listFiles 只列出调用方自己上传的文件
+func (s *PlatformToolService) listFiles(ctx context.Context, uid int, args map[string]interface{}) (interface{}, error) {
+	page := intArg(args, "page", 1)
+	pageSize := intArg(args, "page_size", platformDefaultPageSize)
+	opts := dao.ListFilesOptions{
+		Query:  strings.TrimSpace(stringArg(args, "query")),
+		SortBy: "created_at",
+		Desc:   true,
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	}
+	if prefix := strings.TrimSpace(stringArg(args, "content_type")); prefix != "" {
+		// 图片、视频、音频按与 IsImage 等相同的规则筛选，包含按扩展名判定的旧记录
+		switch prefix {
+		case models.ImageClass.ContentTypePrefix:
+			opts.MediaClasses = []models.MediaClass{models.ImageClass}
+		case models.VideoClass.ContentTypePrefix:
+			opts.MediaClasses = []models.MediaClass{models.VideoClass}
+		case models.AudioClass.ContentTypePrefix:
+			opts.MediaClasses = []models.MediaClass{models.AudioClass}
+		default:
+			opts.ContentTypePrefixes = []string{prefix}
+		}
+	}
+	files, total, err := s.minioFileDAO.ListByUID(uid, opts)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"files":     files,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}, nil
+}
+
+// fileMetadata 与 GET /files/:id 相同，文件须属于调用方或已共享；无权访问与不存在返回相同错误。
+// 隔离中的文件只返回文件记录，不附带由文件内容提取的元数据与压缩包清单
+func (s *PlatformToolService) fileMetadata(ctx context.Context, uid int, args map[string]interface{}) (interface{}, error) {
+	id := int64(intArg(args, "file_id", 0))
+	file, err := s.minioFileDAO.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil || !file.CanBeReadBy(uid) {
+		return nil, mcp.ToolErrorf("file %d not found", id)
+	}
+
+	result := map[string]interface{}{"file": file}
+	if file.Quarantined() {
+		result["quarantined"] = true
+		return result, nil
+	}
+	meta, err := s.metadata.Get(file.ID)
+	if err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		result["metadata"] = meta
+	}
+	manifest, err := s.archives.Manifest(file.ID)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		if len(manifest.Entries) > maxListedEntries {
+			manifest.Entries = manifest.Entries[:maxListedEntries]
+		}
+		result["archive"] = manifest
+	}
+	return result, nil
+}
+
+// searchConversations 只搜索调用方自己的会话
+func (s *PlatformToolService) searchConversations(ctx context.Context, uid int, args map[string]interface{}) (interface{}, error) {
+	query := strings.TrimSpace(stringArg(args, "query"))
+	if query == "" {
+		return nil, mcp.ToolErrorf("query must not be blank")
+	}
+	hits, err := s.conversationDAO.SearchMessages(uid, query, intArg(args, "limit", platformDefaultPageSize))
+	if err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Content = snippet(hits[i].Content, query, searchSnippetRunes)
+	}
+	return map[string]interface{}{"results": hits}, nil
+}
+
+// snippet 截取以首个命中位置为中心、最多 n 个字符的片段，未命中（仅标题命中）时取开头
+func snippet(content, query string, n int) string {
+	runes := []rune(content)
+	if len(runes) <= n {
+		return content
+	}
+	start := 0
+	// strings.ToLower 逐字符转换，字符位置与原文一致
+	lower := strings.ToLower(content)
+	if i := strings.Index(lower, strings.ToLower(query)); i >= 0 {
+		start = utf8.RuneCountInString(lower[:i]) - n/2
+	}
+	if start < 0 {
+		start = 0
+	}
+	if start > len(runes)-n {
+		start = len(runes) - n
+	}
+	out := string(runes[start : start+n])
+	if start > 0 {
+		out = "…" + out
+	}
+	if start+n < len(runes) {
+		out += "…"
+	}
+	return out
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	v, _ := args[name].(string)
+	return v
+}
+
+// intArg 读取已通过 Schema 校验的整数参数，JSON 数字解码为 float64
+func intArg(args map[string]interface{}, name string, def int) int {
+	if v, ok := args[name].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
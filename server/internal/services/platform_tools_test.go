@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/mcp"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlatformTools(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	defer db.Close()
+
+	// 用例不会读取元数据与压缩包清单
+	s := NewPlatformToolService(nil, nil)
+	ctx := context.Background()
+
+	// list_files 只查询调用方的文件
+	imageArgs := []driver.Value{7, "image/%", "%.jpg", "%.jpeg", "%.png", "%.gif"}
+	mock.ExpectQuery("SELECT COUNT").WithArgs(imageArgs...).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(append(imageArgs, 10, 10)...).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).AddRow(3, 7, "a.png", "files", "k", "image/png", 10, "abc", false, "clean", "", time.Now()))
+	out, err := s.listFiles(ctx, 7, map[string]interface{}{"content_type": "image/", "page": 2.0, "page_size": 10.0})
+	require.NoError(t, err)
+	assert.Equal(t, 1, out.(map[string]interface{})["total"])
+
+	// 他人未共享的文件按不存在处理，不再查询元数据
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).AddRow(4, 8, "b.png", "files", "k", "image/png", 10, "def", false, "clean", "", time.Now()))
+	_, err = s.fileMetadata(ctx, 7, map[string]interface{}{"file_id": 4.0})
+	var toolErr *mcp.ToolError
+	require.ErrorAs(t, err, &toolErr)
+	assert.Equal(t, "file 4 not found", toolErr.Message)
+
+	// 隔离中的文件只返回记录，不读取元数据与压缩包清单
+	mock.ExpectQuery("SELECT (.+) FROM minio_files").WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).AddRow(5, 7, "c.zip", "files", "k", "application/zip", 10, "fed", false, "infected", "Eicar-Test-Signature", time.Now()))
+	out, err = s.fileMetadata(ctx, 7, map[string]interface{}{"file_id": 5.0})
+	require.NoError(t, err)
+	assert.Equal(t, true, out.(map[string]interface{})["quarantined"])
+	assert.NotContains(t, out, "metadata")
+	assert.NotContains(t, out, "archive")
+
+	// search_conversations 只搜索调用方的会话
+	long := strings.Repeat("甲", 300) + "周报" + strings.Repeat("乙", 300)
+	mock.ExpectQuery("SELECT (.+) FROM messages m JOIN conversations c").WithArgs(7, "%周报%", "%周报%", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "id", "role", "content", "created_at"}).
+			AddRow(1, "工作", 11, "user", long, time.Now()))
+	out, err = s.searchConversations(ctx, 7, map[string]interface{}{"query": " 周报 "})
+	require.NoError(t, err)
+	hits := out.(map[string]interface{})["results"].([]models.MessageSearchHit)
+	require.Len(t, hits, 1)
+	assert.Equal(t, int64(11), hits[0].MessageID)
+	assert.Contains(t, hits[0].Content, "周报")
+	assert.Equal(t, searchSnippetRunes+2, utf8.RuneCountInString(hits[0].Content))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSnippet(t *testing.T) {
+	assert.Equal(t, "short", snippet("short", "x", 10))
+
+	content := strings.Repeat("a", 50) + "Needle" + strings.Repeat("b", 50)
+	got := snippet(content, "needle", 20)
+	assert.Equal(t, "…"+strings.Repeat("a", 10)+"Needle"+strings.Repeat("b", 4)+"…", got)
+
+	// 未命中时取开头
+	assert.Equal(t, strings.Repeat("a", 20)+"…", snippet(content, "zzz", 20))
+	// 命中位置靠近结尾时取最后 n 个字符
+	assert.Equal(t, "…"+strings.Repeat("b", 17)+"END", snippet(content+"END", "end", 20))
+}
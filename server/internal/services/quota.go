@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+)
+
+// ErrQuotaExceeded 写入后将超出用户存储配额
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// QuotaService 用户存储配额与用量统计
+type QuotaService struct {
+	cfg      config.QuotaConfig
+	usageDAO *dao.StorageUsageDAO
+}
+
+// NewQuotaService 创建新的配额服务实例
+func NewQuotaService(cfg config.QuotaConfig) *QuotaService {
+	return &QuotaService{
+		cfg:      cfg,
+		usageDAO: dao.NewStorageUsageDAO(),
+	}
+}
+
+// Usage 返回用户当前用量与配额
+func (s *QuotaService) Usage(uid int) (*models.StorageUsage, error) {
+	usage, err := s.usageDAO.Get(uid)
+	if err != nil {
+		return nil, err
+	}
+	s.fill(usage)
+	return usage, nil
+}
+
+// Check 预检查写入 size 字节后是否超出配额，用于在接收文件内容前尽早拒绝；
+// 最终以 Charge 在事务中的检查为准
+func (s *QuotaService) Check(uid int, size int64) error {
+	usage, err := s.Usage(uid)
+	if err != nil {
+		return err
+	}
+	return s.exceeds(usage, size)
+}
+
+// Charge 在事务中锁定用量并计入一个 size 字节的文件，超出配额时返回 ErrQuotaExceeded
+func (s *QuotaService) Charge(q database.Querier, uid int, size int64) error {
+	usage, err := s.usageDAO.GetForUpdate(q, uid)
+	if err != nil {
+		return err
+	}
+	s.fill(usage)
+	if err := s.exceeds(usage, size); err != nil {
+		return err
+	}
+	return s.usageDAO.Add(q, uid, size, 1)
+}
+
+// Refund 在事务中释放一个 size 字节的文件占用的用量
+func (s *QuotaService) Refund(q database.Querier, uid int, size int64) error {
+	if _, err := s.usageDAO.GetForUpdate(q, uid); err != nil {
+		return err
+	}
+	return s.usageDAO.Add(q, uid, -size, -1)
+}
+
+func (s *QuotaService) fill(usage *models.StorageUsage) {
+	usage.QuotaBytes = s.cfg.BytesFor(usage.Uid)
+	usage.RemainingBytes = 0
+	if usage.QuotaBytes > usage.UsedBytes {
+		usage.RemainingBytes = usage.QuotaBytes - usage.UsedBytes
+	}
+}
+
+func (s *QuotaService) exceeds(usage *models.StorageUsage, size int64) error {
+	if usage.QuotaBytes > 0 && usage.UsedBytes+size > usage.QuotaBytes {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQuotaService(t *testing.T) (*QuotaService, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	return NewQuotaService(config.QuotaConfig{Default: 100, Users: map[int]int64{9: 0}}), mock
+}
+
+func expectUsageRow(mock sqlmock.Sqlmock, uid int, used int64, forUpdate bool) {
+	mock.ExpectExec("INSERT IGNORE INTO user_storage_usage").WithArgs(uid, uid).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	query := "SELECT used_bytes, file_count FROM user_storage_usage WHERE uid = \\?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	mock.ExpectQuery(query).WithArgs(uid).
+		WillReturnRows(sqlmock.NewRows([]string{"used_bytes", "file_count"}).AddRow(used, 1))
+}
+
+func TestChargeWithinQuota(t *testing.T) {
+	s, mock := newTestQuotaService(t)
+
+	mock.ExpectBegin()
+	expectUsageRow(mock, 7, 60, true)
+	mock.ExpectExec("UPDATE user_storage_usage").WithArgs(int64(40), 1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := database.DB.Begin()
+	require.NoError(t, err)
+	require.NoError(t, s.Charge(tx, 7, 40))
+	require.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChargeExceedsQuota(t *testing.T) {
+	s, mock := newTestQuotaService(t)
+
+	mock.ExpectBegin()
+	expectUsageRow(mock, 7, 60, true)
+	mock.ExpectRollback()
+
+	tx, err := database.DB.Begin()
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.Charge(tx, 7, 41), ErrQuotaExceeded)
+	require.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsagePerUserOverride(t *testing.T) {
+	s, mock := newTestQuotaService(t)
+	expectUsageRow(mock, 9, 500, false)
+
+	usage, err := s.Usage(9)
+	require.NoError(t, err)
+	// 单独配置为 0 表示不限额
+	assert.Equal(t, int64(0), usage.QuotaBytes)
+	assert.NoError(t, s.exceeds(usage, 1<<40))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/scanner"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"go.uber.org/zap"
+)
+
+// ScanJobType 恶意软件扫描任务类型
+const ScanJobType = "file.scan"
+
+type scanPayload struct {
+	FileID int64 `json:"file_id"`
+}
+
+// ScanService 新入库的文件通过后台任务扫描，扫描通过前文件处于隔离状态
+type ScanService struct {
+	minio        *storage.MinioService
+	scanner      scanner.Scanner
+	jobs         *jobs.Pool
+	minioFileDAO *dao.MinioFileDAO
+	cfg          config.ScanConfig
+	hooks        []UploadHook
+}
+
+// NewScanService 创建新的扫描服务实例
+func NewScanService(minioSvc *storage.MinioService, sc scanner.Scanner, jobPool *jobs.Pool, cfg config.ScanConfig) *ScanService {
+	return &ScanService{
+		minio:        minioSvc,
+		scanner:      sc,
+		jobs:         jobPool,
+		minioFileDAO: dao.NewMinioFileDAO(),
+		cfg:          cfg,
+	}
+}
+
+// OnReleased 注册文件放行（扫描通过或按 fail_open 跳过扫描）后的处理钩子。
+// 启用扫描时转码、缩略图等后续处理挂在这里而不是 FileService.OnStored，隔离中的文件不会被处理
+func (s *ScanService) OnReleased(hook UploadHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// Submit 上传钩子：为待扫描的文件入队扫描任务。
+// 入队失败时按 fail_open 放行，否则文件保持隔离，由 RunJanitor 稍后重新入队
+func (s *ScanService) Submit(file *models.MinioFile) {
+	if file.ScanStatus != models.ScanStatusPending {
+		return
+	}
+	if _, err := s.jobs.Enqueue(context.Background(), ScanJobType, scanPayload{FileID: file.ID}); err != nil {
+		logger.Logger.Error("failed to enqueue scan job", zap.Int64("file_id", file.ID), zap.Error(err))
+		if s.cfg.FailOpen {
+			s.skip(file, "failed to enqueue scan")
+		}
+	}
+}
+
+// RequeueStale 为登记超过 requeue_interval 仍处于 pending 且没有扫描任务的文件重新入队，返回入队数量
+func (s *ScanService) RequeueStale(ctx context.Context) (int, error) {
+	files, err := s.minioFileDAO.ListPendingWithoutJob(ScanJobType, time.Now().Add(-s.cfg.RequeueInterval), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, f := range files {
+		if _, err := s.jobs.Enqueue(ctx, ScanJobType, scanPayload{FileID: f.ID}); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
+// RunJanitor 按 requeue_interval 周期执行 RequeueStale，直到 ctx 取消
+func (s *ScanService) RunJanitor(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.RequeueInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.RequeueStale(ctx)
+			if err != nil {
+				logger.Logger.Error("failed to requeue pending scans", zap.Error(err))
+				continue
+			}
+			if n > 0 {
+				logger.Logger.Info("requeued pending scans", zap.Int("count", n))
+			}
+		}
+	}
+}
+
+// HandleJob 扫描任务处理器
+func (s *ScanService) HandleJob(ctx context.Context, job *jobs.Job) error {
+	var p scanPayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	return s.Process(ctx, p.FileID)
+}
+
+// Process 读取对象交给扫描器并记录结果。
+// 扫描器不可用时按 fail_open 配置放行，或返回错误由任务队列重试，文件保持隔离。
+func (s *ScanService) Process(ctx context.Context, fileID int64) error {
+	file, err := s.minioFileDAO.GetByID(fileID)
+	if err != nil {
+		return err
+	}
+	if file == nil || file.ScanStatus != models.ScanStatusPending {
+		return nil // 文件已删除或已有扫描结果
+	}
+
+	obj, _, err := s.minio.GetObject(ctx, file.ObjectKey)
+	if err != nil {
+		return err
+	}
+	result, err := s.scanner.Scan(ctx, obj)
+	obj.Close()
+	if err != nil {
+		unavailable := errors.Is(err, scanner.ErrUnavailable) || errors.Is(err, scanner.ErrStreamTooLarge)
+		if unavailable && s.cfg.FailOpen {
+			logger.Logger.Warn("scanner unavailable, releasing file unscanned", zap.Int64("file_id", fileID), zap.Error(err))
+			return s.update(file, models.ScanStatusSkipped, err.Error())
+		}
+		if errors.Is(err, scanner.ErrStreamTooLarge) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+
+	if !result.Clean {
+		logger.Logger.Warn("malware detected, file quarantined",
+			zap.Int64("file_id", fileID),
+			zap.Int("uid", file.Uid),
+			zap.String("signature", result.Signature))
+		return s.update(file, models.ScanStatusInfected, result.Signature)
+	}
+	return s.update(file, models.ScanStatusClean, "")
+}
+
+// update 记录扫描结果，由本次调用放行文件时触发 OnReleased 钩子
+func (s *ScanService) update(file *models.MinioFile, status, result string) error {
+	updated, err := s.minioFileDAO.UpdateScanStatus(file.ID, status, result)
+	if err != nil || !updated {
+		return err
+	}
+	file.ScanStatus, file.ScanResult = status, result
+	if !file.Quarantined() {
+		for _, hook := range s.hooks {
+			hook(file)
+		}
+	}
+	return nil
+}
+
+func (s *ScanService) skip(file *models.MinioFile, reason string) {
+	if err := s.update(file, models.ScanStatusSkipped, reason); err != nil {
+		logger.Logger.Error("failed to mark scan skipped", zap.Int64("file_id", file.ID), zap.Error(err))
+	}
+}
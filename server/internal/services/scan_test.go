@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/scanner"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScanTest(t *testing.T, sc scanner.Scanner, cfg config.ScanConfig) (*ScanService, sqlmock.Sqlmock, *miniotest.Server) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	store, minioSvc := miniotest.Start(t)
+	pool := jobs.NewPool(jobs.NewMySQLStore(db), config.JobsConfig{MaxAttempts: 3})
+	return NewScanService(minioSvc, sc, pool, cfg), mock, store
+}
+
+func expectPendingFile(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, "a.txt", miniotest.Bucket, "uid_7/sha256/abc", "text/plain", 5, "abc", false, models.ScanStatusPending, "", time.Now()))
+}
+
+func expectScanResult(mock sqlmock.Sqlmock, status, result string) {
+	mock.ExpectExec("UPDATE minio_files SET scan_status = \\?, scan_result = \\?").
+		WithArgs(status, result, int64(3), models.ScanStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestScanProcess(t *testing.T) {
+	fake := scanner.NewFake()
+	s, mock, store := newScanTest(t, fake, config.ScanConfig{})
+
+	store.Put("uid_7/sha256/abc", []byte("hello"), "text/plain")
+	expectPendingFile(mock)
+	expectScanResult(mock, models.ScanStatusClean, "")
+	require.NoError(t, s.Process(context.Background(), 3))
+
+	store.Put("uid_7/sha256/abc", []byte("prefix "+scanner.EICAR), "text/plain")
+	expectPendingFile(mock)
+	expectScanResult(mock, models.ScanStatusInfected, "Eicar-Test-Signature")
+	require.NoError(t, s.Process(context.Background(), 3))
+
+	// 已有扫描结果的文件不再扫描
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, "a.txt", miniotest.Bucket, "uid_7/sha256/abc", "text/plain", 5, "abc", false, models.ScanStatusClean, "", time.Now()))
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.Equal(t, 2, fake.Scanned())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScanProcessScannerErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		failOpen  bool
+		skipped   bool
+		permanent bool
+	}{
+		{name: "unavailable, fail closed", err: scanner.ErrUnavailable},
+		{name: "unavailable, fail open", err: scanner.ErrUnavailable, failOpen: true, skipped: true},
+		{name: "too large, fail closed", err: scanner.ErrStreamTooLarge, permanent: true},
+		{name: "too large, fail open", err: scanner.ErrStreamTooLarge, failOpen: true, skipped: true},
+		// 其它错误无论 fail_open 都重试，不放行
+		{name: "other error, fail open", err: errors.New("unexpected reply"), failOpen: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := scanner.NewFake()
+			fake.Err = tc.err
+			s, mock, store := newScanTest(t, fake, config.ScanConfig{FailOpen: tc.failOpen})
+			store.Put("uid_7/sha256/abc", []byte("hello"), "text/plain")
+
+			expectPendingFile(mock)
+			if tc.skipped {
+				expectScanResult(mock, models.ScanStatusSkipped, tc.err.Error())
+			}
+			err := s.Process(context.Background(), 3)
+			if tc.skipped {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Equal(t, tc.permanent, jobs.IsPermanent(err))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestScanOnReleased(t *testing.T) {
+	s, mock, store := newScanTest(t, scanner.NewFake(), config.ScanConfig{FailOpen: true})
+	var released []string
+	s.OnReleased(func(f *models.MinioFile) { released = append(released, f.ScanStatus) })
+
+	// 感染的文件不触发后续处理
+	store.Put("uid_7/sha256/abc", []byte(scanner.EICAR), "text/plain")
+	expectPendingFile(mock)
+	expectScanResult(mock, models.ScanStatusInfected, "Eicar-Test-Signature")
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.Empty(t, released)
+
+	store.Put("uid_7/sha256/abc", []byte("hello"), "text/plain")
+	expectPendingFile(mock)
+	expectScanResult(mock, models.ScanStatusClean, "")
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.Equal(t, []string{models.ScanStatusClean}, released)
+
+	// 扫描结果已被并发写入时不重复触发
+	expectPendingFile(mock)
+	mock.ExpectExec("UPDATE minio_files SET scan_status = \\?, scan_result = \\?").
+		WithArgs(models.ScanStatusClean, "", int64(3), models.ScanStatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.Len(t, released, 1)
+
+	// 入队失败按 fail_open 跳过扫描，同样放行
+	mock.ExpectExec("INSERT INTO jobs").WillReturnError(errors.New("db down"))
+	expectScanResult(mock, models.ScanStatusSkipped, "failed to enqueue scan")
+	s.Submit(&models.MinioFile{ID: 3, Uid: 7, ScanStatus: models.ScanStatusPending})
+	assert.Equal(t, []string{models.ScanStatusClean, models.ScanStatusSkipped}, released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScanRequeueStale(t *testing.T) {
+	s, mock, _ := newScanTest(t, scanner.NewFake(), config.ScanConfig{RequeueInterval: 5 * time.Minute})
+
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE scan_status = \\? AND created_at < \\? AND NOT EXISTS \\(SELECT 1 FROM jobs").
+		WithArgs(models.ScanStatusPending, sqlmock.AnyArg(), ScanJobType, 100).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, "a.txt", miniotest.Bucket, "uid_7/sha256/abc", "text/plain", 5, "abc", false, models.ScanStatusPending, "", time.Now()).
+			AddRow(4, 7, "b.txt", miniotest.Bucket, "uid_7/sha256/def", "text/plain", 5, "def", false, models.ScanStatusPending, "", time.Now()))
+	mock.ExpectExec("INSERT INTO jobs").WithArgs(ScanJobType, []byte(`{"file_id":3}`), jobs.StatusQueued, 3, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO jobs").WithArgs(ScanJobType, []byte(`{"file_id":4}`), jobs.StatusQueued, 3, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	n, err := s.RequeueStale(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/auth"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或已被吊销
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用，整个会话已被吊销
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrSessionRevoked 访问令牌所属会话已注销
+	ErrSessionRevoked = errors.New("session revoked")
+)
+
+// TokenPair 一次登录或刷新返回的令牌对
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	SessionID        string
+}
+
+// SessionService 登录会话服务，负责令牌签发、轮换与吊销
+type SessionService struct {
+	tokens          *auth.TokenManager
+	refreshTokenDAO *dao.RefreshTokenDAO
+	refreshTTL      time.Duration
+}
+
+// NewSessionService 创建新的会话服务实例
+func NewSessionService(tokens *auth.TokenManager, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		tokens:          tokens,
+		refreshTokenDAO: dao.NewRefreshTokenDAO(),
+		refreshTTL:      refreshTTL,
+	}
+}
+
+// CreateSession 为登录成功的用户开启新会话
+func (s *SessionService) CreateSession(uid int) (*TokenPair, error) {
+	familyID := uuid.New().String()
+	return s.issuePair(database.DB, uid, familyID)
+}
+
+// Refresh 使用刷新令牌换取新的令牌对；旧令牌被重复使用时吊销整个会话
+func (s *SessionService) Refresh(refreshToken string) (*TokenPair, error) {
+	var pair *TokenPair
+	var reused bool
+
+	err := database.WithTx(func(tx *sql.Tx) error {
+		rt, err := s.refreshTokenDAO.GetByHashForUpdate(tx, auth.HashRefreshToken(refreshToken))
+		if err != nil {
+			return err
+		}
+		if rt == nil || rt.RevokedAt.Valid {
+			return ErrInvalidRefreshToken
+		}
+		if rt.UsedAt.Valid {
+			// 已轮换的令牌再次出现，说明令牌可能被盗用
+			reused = true
+			return s.refreshTokenDAO.RevokeFamily(tx, rt.FamilyID)
+		}
+		if time.Now().After(rt.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := s.refreshTokenDAO.MarkUsed(tx, rt.ID); err != nil {
+			return err
+		}
+		pair, err = s.issuePair(tx, rt.Uid, rt.FamilyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return pair, nil
+}
+
+// Revoke 注销会话，会话下的刷新令牌和访问令牌随即失效
+func (s *SessionService) Revoke(sessionID string) error {
+	return s.refreshTokenDAO.RevokeFamily(database.DB, sessionID)
+}
+
+// VerifyAccessToken 校验访问令牌并确认其会话未被注销
+func (s *SessionService) VerifyAccessToken(accessToken string) (*auth.Claims, error) {
+	claims, err := s.tokens.ParseAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" {
+		return claims, nil
+	}
+
+	revoked, err := s.refreshTokenDAO.IsFamilyRevoked(claims.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if revoked {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
+
+func (s *SessionService) issuePair(q database.Querier, uid int, familyID string) (*TokenPair, error) {
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := time.Now().Add(s.refreshTTL)
+	if err := s.refreshTokenDAO.Create(q, uid, familyID, refreshHash, refreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	accessToken, accessExpiresAt, err := s.tokens.IssueAccessToken(uid, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		SessionID:        familyID,
+	}, nil
+}
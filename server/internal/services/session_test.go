@@ -0,0 +1,86 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/auth"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var refreshTokenColumns = []string{"id", "uid", "family_id", "token_hash", "expires_at", "used_at", "revoked_at", "created_at"}
+
+func newTestSessionService(t *testing.T) (*SessionService, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	tokens := auth.NewTokenManager(config.AuthConfig{
+		JWTSecret:      "0123456789abcdef0123456789abcdef",
+		AccessTokenTTL: time.Minute,
+	})
+	return NewSessionService(tokens, time.Hour), mock
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	s, mock := newTestSessionService(t)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM refresh_tokens WHERE token_hash = \\? FOR UPDATE").
+		WithArgs(auth.HashRefreshToken("old")).
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(1, 7, "family-1", auth.HashRefreshToken("old"), now.Add(time.Hour), sql.NullTime{}, sql.NullTime{}, now))
+	mock.ExpectExec("UPDATE refresh_tokens SET used_at").WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO refresh_tokens").
+		WithArgs(7, "family-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	pair, err := s.Refresh("old")
+	require.NoError(t, err)
+	assert.Equal(t, "family-1", pair.SessionID)
+	assert.NotEqual(t, "old", pair.RefreshToken)
+
+	claims, err := s.tokens.ParseAccessToken(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "family-1", claims.SessionID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s, mock := newTestSessionService(t)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM refresh_tokens").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns).
+			AddRow(1, 7, "family-1", auth.HashRefreshToken("old"), now.Add(time.Hour), sql.NullTime{Time: now, Valid: true}, sql.NullTime{}, now))
+	mock.ExpectExec("UPDATE refresh_tokens SET revoked_at").WithArgs("family-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	_, err := s.Refresh("old")
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	s, mock := newTestSessionService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM refresh_tokens").
+		WillReturnRows(sqlmock.NewRows(refreshTokenColumns))
+	mock.ExpectRollback()
+
+	_, err := s.Refresh("missing")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"go.uber.org/zap"
+)
+
+// ThumbnailJobType 缩略图生成任务类型
+const ThumbnailJobType = "file.thumbnail"
+
+// ThumbnailContentType 缩略图统一输出为 JPEG
+const ThumbnailContentType = "image/jpeg"
+
+type thumbnailPayload struct {
+	FileID int64 `json:"file_id"`
+}
+
+// ThumbnailService 图片与视频上传后通过后台任务生成多规格缩略图，视频取封面帧
+type ThumbnailService struct {
+	minio        *storage.MinioService
+	frames       media.FrameExtractor
+	jobs         *jobs.Pool
+	thumbnailDAO *dao.FileThumbnailDAO
+	minioFileDAO *dao.MinioFileDAO
+	sizes        []media.ThumbnailSize
+	cfg          config.ThumbnailConfig
+}
+
+// NewThumbnailService 创建新的缩略图服务实例
+func NewThumbnailService(minioSvc *storage.MinioService, frames media.FrameExtractor, jobPool *jobs.Pool, cfg config.ThumbnailConfig) *ThumbnailService {
+	sizes := make([]media.ThumbnailSize, 0, len(cfg.Sizes))
+	for _, s := range cfg.Sizes {
+		sizes = append(sizes, media.ThumbnailSize{Name: s.Name, MaxEdge: s.MaxEdge})
+	}
+	return &ThumbnailService{
+		minio:        minioSvc,
+		frames:       frames,
+		jobs:         jobPool,
+		thumbnailDAO: dao.NewFileThumbnailDAO(),
+		minioFileDAO: dao.NewMinioFileDAO(),
+		sizes:        sizes,
+		cfg:          cfg,
+	}
+}
+
+// Submit 上传钩子：为图片和视频文件入队缩略图任务
+func (s *ThumbnailService) Submit(file *models.MinioFile) {
+	if !file.IsImage() && !file.IsVideo() {
+		return
+	}
+	if _, err := s.jobs.Enqueue(context.Background(), ThumbnailJobType, thumbnailPayload{FileID: file.ID}); err != nil {
+		logger.Logger.Error("failed to enqueue thumbnail job", zap.Int64("file_id", file.ID), zap.Error(err))
+	}
+}
+
+// HandleJob 缩略图任务处理器
+func (s *ThumbnailService) HandleJob(ctx context.Context, job *jobs.Job) error {
+	var p thumbnailPayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	return s.Process(ctx, p.FileID)
+}
+
+// Process 下载原文件生成各规格缩略图，上传到原对象旁并替换文件的缩略图记录
+func (s *ThumbnailService) Process(ctx context.Context, fileID int64) error {
+	file, err := s.minioFileDAO.GetByID(fileID)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return nil // 生成前文件已被删除
+	}
+
+	workDir, err := os.MkdirTemp("", "thumbnail-*")
+	if err != nil {
+		return fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	source := filepath.Join(workDir, "input"+strings.ToLower(filepath.Ext(file.FileName)))
+	if err := s.minio.DownloadFile(ctx, file.ObjectKey, source); err != nil {
+		return err
+	}
+	if file.IsVideo() {
+		poster := filepath.Join(workDir, "poster.png")
+		if err := s.frames.ExtractFrame(ctx, source, poster); err != nil {
+			return err
+		}
+		source = poster
+	}
+	img, err := media.DecodeImage(source)
+	if err != nil {
+		// 文件内容不会变化，重试也无法解码
+		return jobs.Permanent(err)
+	}
+
+	outputDir := filepath.Join(workDir, "thumbnails")
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output dir: %w", err)
+	}
+	generated, err := media.WriteThumbnails(img, outputDir, s.sizes, s.cfg.Quality)
+	if err != nil {
+		return err
+	}
+
+	thumbs := make([]models.FileThumbnail, 0, len(generated))
+	for _, g := range generated {
+		key := ThumbnailKey(file, g.Name)
+		if err := s.minio.UploadFile(ctx, key, g.Path, ThumbnailContentType); err != nil {
+			return fmt.Errorf("failed to upload thumbnail %s: %w", g.Name, err)
+		}
+		thumbs = append(thumbs, models.FileThumbnail{
+			FileID:    file.ID,
+			Size:      g.Name,
+			Width:     g.Width,
+			Height:    g.Height,
+			ObjectKey: key,
+			FileSize:  g.Size,
+		})
+	}
+	if err := s.thumbnailDAO.Replace(file.ID, thumbs); err != nil {
+		return err
+	}
+	logger.Logger.Info("thumbnails generated", zap.Int64("file_id", file.ID), zap.Int("count", len(thumbs)))
+	return nil
+}
+
+// Get 返回文件指定规格的缩略图，不存在时返回 nil
+func (s *ThumbnailService) Get(fileID int64, size string) (*models.FileThumbnail, error) {
+	return s.thumbnailDAO.Get(fileID, size)
+}
+
+// ListByFileIDs 批量返回文件的缩略图，没有缩略图的文件不在结果中
+func (s *ThumbnailService) ListByFileIDs(fileIDs []int64) (map[int64][]models.FileThumbnail, error) {
+	return s.thumbnailDAO.ListByFileIDs(fileIDs)
+}
+
+// ThumbnailKey 文件指定规格缩略图的对象键
+func ThumbnailKey(file *models.MinioFile, size string) string {
+	return file.DerivedPrefix() + "thumbnails/" + size + ".jpg"
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newThumbnailTest(t *testing.T) (*ThumbnailService, sqlmock.Sqlmock, *miniotest.Server) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	store, minioSvc := miniotest.Start(t)
+	s := NewThumbnailService(minioSvc, &media.FakeFrameExtractor{Width: 640, Height: 360}, nil, config.ThumbnailConfig{
+		Quality: 80,
+		Timeout: time.Minute,
+		Sizes:   []config.ThumbnailSizeConfig{{Name: "small", MaxEdge: 160}},
+	})
+	return s, mock, store
+}
+
+func expectThumbnailFile(mock sqlmock.Sqlmock, fileName, contentType string) {
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, fileName, miniotest.Bucket, "uid_7/sha256/abc", contentType, 5, "abc", false, "clean", "", time.Now()))
+}
+
+func TestThumbnailProcess(t *testing.T) {
+	s, mock, store := newThumbnailTest(t)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 200))))
+	store.Put("uid_7/sha256/abc", buf.Bytes(), "image/png")
+
+	expectThumbnailFile(mock, "a.png", "image/png")
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM file_thumbnails").WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO file_thumbnails").
+		WithArgs(int64(3), "small", 160, 80, "uid_7/sha256/abc.derived/thumbnails/small.jpg", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, ok := store.Get("uid_7/sha256/abc.derived/thumbnails/small.jpg")
+	assert.True(t, ok)
+}
+
+func TestThumbnailLegacyVideoUsesPosterFrame(t *testing.T) {
+	s, mock, store := newThumbnailTest(t)
+	store.Put("uid_7/sha256/abc", []byte("not really a video"), "")
+
+	// 类型识别之前登记的视频按扩展名判定，取封面帧生成缩略图
+	expectThumbnailFile(mock, "clip.MOV", "application/octet-stream")
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM file_thumbnails").WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO file_thumbnails").
+		WithArgs(int64(3), "small", 160, 90, "uid_7/sha256/abc.derived/thumbnails/small.jpg", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestThumbnailUndecodableImage(t *testing.T) {
+	s, mock, store := newThumbnailTest(t)
+	store.Put("uid_7/sha256/abc", []byte("not an image"), "image/png")
+
+	expectThumbnailFile(mock, "a.png", "image/png")
+	err := s.Process(context.Background(), 3)
+	assert.True(t, jobs.IsPermanent(err), err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"uid_7/sha256/abc"}, store.Keys())
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/dao"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/logger"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+	"go.uber.org/zap"
+)
+
+// TranscodeJobType 视频转码任务类型
+const TranscodeJobType = "video.transcode"
+
+type transcodePayload struct {
+	FileID int64 `json:"file_id"`
+}
+
+// TranscodeService 视频上传后通过后台任务转码为多码率 HLS，结果写回 MinIO
+type TranscodeService struct {
+	minio        *storage.MinioService
+	transcoder   media.Transcoder
+	jobs         *jobs.Pool
+	transcodeDAO *dao.VideoTranscodeDAO
+	minioFileDAO *dao.MinioFileDAO
+	renditions   []media.Rendition
+	cfg          config.TranscodeConfig
+}
+
+// NewTranscodeService 创建新的转码服务实例
+func NewTranscodeService(minioSvc *storage.MinioService, transcoder media.Transcoder, jobPool *jobs.Pool, cfg config.TranscodeConfig) *TranscodeService {
+	renditions := make([]media.Rendition, 0, len(cfg.Renditions))
+	for _, r := range cfg.Renditions {
+		renditions = append(renditions, media.Rendition{Name: r.Name, Height: r.Height, VideoKbps: r.VideoKbps, AudioKbps: r.AudioKbps})
+	}
+	return &TranscodeService{
+		minio:        minioSvc,
+		transcoder:   transcoder,
+		jobs:         jobPool,
+		transcodeDAO: dao.NewVideoTranscodeDAO(),
+		minioFileDAO: dao.NewMinioFileDAO(),
+		renditions:   renditions,
+		cfg:          cfg,
+	}
+}
+
+// Submit 上传钩子：为视频文件登记转码状态并入队转码任务
+func (s *TranscodeService) Submit(file *models.MinioFile) {
+	if !file.IsVideo() {
+		return
+	}
+	if err := s.transcodeDAO.Upsert(file.ID); err != nil {
+		logger.Logger.Error("failed to create transcode task", zap.Int64("file_id", file.ID), zap.Error(err))
+		return
+	}
+	if _, err := s.jobs.Enqueue(context.Background(), TranscodeJobType, transcodePayload{FileID: file.ID}); err != nil {
+		logger.Logger.Error("failed to enqueue transcode job", zap.Int64("file_id", file.ID), zap.Error(err))
+		if uerr := s.transcodeDAO.UpdateStatus(file.ID, models.TranscodeStatusFailed, "", "failed to enqueue"); uerr != nil {
+			logger.Logger.Error("failed to mark transcode failed", zap.Int64("file_id", file.ID), zap.Error(uerr))
+		}
+	}
+}
+
+// HandleJob 转码任务处理器
+func (s *TranscodeService) HandleJob(ctx context.Context, job *jobs.Job) error {
+	var p transcodePayload
+	if err := job.Decode(&p); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	err := s.Process(ctx, p.FileID)
+	// 仍会重试时保持 processing，避免客户端过早看到失败
+	if err != nil && job.LastAttempt(err) {
+		if uerr := s.transcodeDAO.UpdateStatus(p.FileID, models.TranscodeStatusFailed, "", err.Error()); uerr != nil {
+			logger.Logger.Error("failed to mark transcode failed", zap.Int64("file_id", p.FileID), zap.Error(uerr))
+		}
+	}
+	return err
+}
+
+// Status 返回文件的转码记录，未登记时返回 nil
+func (s *TranscodeService) Status(fileID int64) (*models.VideoTranscode, error) {
+	return s.transcodeDAO.Get(fileID)
+}
+
+// Process 下载原视频、转码并上传 HLS 输出；失败时只返回错误，由 HandleJob 在不再重试时记录为 failed
+func (s *TranscodeService) Process(ctx context.Context, fileID int64) error {
+	file, err := s.minioFileDAO.GetByID(fileID)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return nil // 转码开始前文件已被删除
+	}
+	if err := s.transcodeDAO.UpdateStatus(fileID, models.TranscodeStatusProcessing, "", ""); err != nil {
+		return err
+	}
+
+	playlistKey, err := s.transcode(ctx, file)
+	if err != nil {
+		return err
+	}
+	logger.Logger.Info("video transcoded to hls", zap.Int64("file_id", fileID), zap.String("playlist_key", playlistKey))
+	return s.transcodeDAO.UpdateStatus(fileID, models.TranscodeStatusReady, playlistKey, "")
+}
+
+func (s *TranscodeService) transcode(ctx context.Context, file *models.MinioFile) (string, error) {
+	workDir, err := os.MkdirTemp("", "transcode-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "input"+strings.ToLower(filepath.Ext(file.FileName)))
+	if err := s.minio.DownloadFile(ctx, file.ObjectKey, input); err != nil {
+		return "", err
+	}
+	outputDir := filepath.Join(workDir, "hls")
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create output dir: %w", err)
+	}
+	if err := s.transcoder.TranscodeHLS(ctx, input, outputDir, s.renditions); err != nil {
+		return "", err
+	}
+
+	prefix := HLSPrefix(file)
+	err = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		return s.minio.UploadFile(ctx, prefix+filepath.ToSlash(rel), path, HLSContentType(path))
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload hls output: %w", err)
+	}
+	return prefix + media.MasterPlaylist, nil
+}
+
+// HLSPrefix 文件 HLS 输出的对象键前缀
+func HLSPrefix(file *models.MinioFile) string {
+	return file.DerivedPrefix() + "hls/"
+}
+
+// HLSContentType 按扩展名返回 HLS 播放列表与分片的 MIME 类型
+func HLSContentType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	default:
+		return "application/octet-stream"
+	}
+}
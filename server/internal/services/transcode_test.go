@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/database"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/jobs"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/media"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/models"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage/miniotest"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTranscodeTest(t *testing.T, transcoder media.Transcoder) (*TranscodeService, sqlmock.Sqlmock, *miniotest.Server) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	database.DB = db
+	t.Cleanup(func() { db.Close() })
+
+	store, minioSvc := miniotest.Start(t)
+	store.Put("uid_7/sha256/abc", []byte("video"), "video/mp4")
+	s := NewTranscodeService(minioSvc, transcoder, nil, config.TranscodeConfig{
+		Timeout:    time.Minute,
+		Renditions: []config.RenditionConfig{{Name: "480p", Height: 480, VideoKbps: 800, AudioKbps: 96}},
+	})
+	return s, mock, store
+}
+
+func expectTranscodeStart(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns).
+			AddRow(3, 7, "a.mp4", miniotest.Bucket, "uid_7/sha256/abc", "video/mp4", 5, "abc", false, "clean", "", time.Now()))
+	mock.ExpectExec("UPDATE video_transcodes").WithArgs(models.TranscodeStatusProcessing, "", "", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestTranscodeProcess(t *testing.T) {
+	transcoder := &media.FakeTranscoder{}
+	s, mock, store := newTranscodeTest(t, transcoder)
+
+	expectTranscodeStart(mock)
+	mock.ExpectExec("UPDATE video_transcodes").
+		WithArgs(models.TranscodeStatusReady, "uid_7/sha256/abc.derived/hls/master.m3u8", "", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, s.Process(context.Background(), 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Len(t, transcoder.Inputs(), 1)
+
+	playlist, ok := store.Get("uid_7/sha256/abc.derived/hls/480p/index.m3u8")
+	require.True(t, ok)
+	assert.Contains(t, string(playlist), "seg_0000.ts")
+	_, ok = store.Get("uid_7/sha256/abc.derived/hls/480p/seg_0000.ts")
+	assert.True(t, ok)
+}
+
+func TestTranscodeFailsOnlyOnLastAttempt(t *testing.T) {
+	s, mock, _ := newTranscodeTest(t, &media.FakeTranscoder{Err: errors.New("ffmpeg crashed")})
+	job := &jobs.Job{ID: 1, Payload: []byte(`{"file_id":3}`), Attempts: 1, MaxAttempts: 2}
+
+	// 仍会重试时保持 processing
+	expectTranscodeStart(mock)
+	assert.EqualError(t, s.HandleJob(context.Background(), job), "ffmpeg crashed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	job.Attempts = 2
+	expectTranscodeStart(mock)
+	mock.ExpectExec("UPDATE video_transcodes").WithArgs(models.TranscodeStatusFailed, "", "ffmpeg crashed", int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.EqualError(t, s.HandleJob(context.Background(), job), "ffmpeg crashed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTranscodeDeletedFile(t *testing.T) {
+	s, mock, _ := newTranscodeTest(t, &media.FakeTranscoder{})
+
+	mock.ExpectQuery("SELECT (.+) FROM minio_files WHERE id = \\?").WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows(minioFileColumns))
+	assert.NoError(t, s.Process(context.Background(), 3))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -65,3 +65,15 @@ func (s *UserService) AuthenticateUser(username, password string) (*models.User,
 
 	return user, nil
 }
+
+// GetUserByUID 根据 uid 获取用户，用户不存在时返回错误
+func (s *UserService) GetUserByUID(uid int) (*models.User, error) {
+	user, err := s.userDAO.GetUserByUID(uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
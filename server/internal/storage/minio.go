@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
+	"net/url"
 	"time"
 
 	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
@@ -12,22 +14,42 @@ import (
 )
 
 type MinioService struct {
-	client   *minio.Client
-	bucket   string
-	endpoint string
-	useSSL   bool
+	client *minio.Client
+	// presignClient 使用对外可访问的地址签名，签名URL中的 Host 必须与浏览器访问的地址一致
+	presignClient *minio.Client
+	bucket        string
+	presignExpiry time.Duration
 }
 
 func NewMinioService(cfg config.MinioConfig) (*MinioService, error) {
 	client, err := minio.New(cfg.Endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
 		Secure: cfg.UseSSL,
+		Region: cfg.Region,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to init minio client: %w", err)
 	}
 
-	svc := &MinioService{client: client, bucket: cfg.Bucket, endpoint: cfg.Endpoint, useSSL: cfg.UseSSL}
+	presignClient := client
+	if cfg.PublicEndpoint != "" && cfg.PublicEndpoint != cfg.Endpoint {
+		// 指定 Region 后签名不需要访问服务端查询桶位置
+		presignClient, err = minio.New(cfg.PublicEndpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+			Secure: cfg.PublicUseSSL,
+			Region: cfg.Region,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to init minio presign client: %w", err)
+		}
+	}
+
+	svc := &MinioService{
+		client:        client,
+		presignClient: presignClient,
+		bucket:        cfg.Bucket,
+		presignExpiry: cfg.PresignExpiry,
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -46,15 +68,175 @@ func NewMinioService(cfg config.MinioConfig) (*MinioService, error) {
 	return svc, nil
 }
 
-func (m *MinioService) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) (string, error) {
+// Bucket 返回服务使用的存储桶名称
+func (m *MinioService) Bucket() string {
+	return m.bucket
+}
+
+func (m *MinioService) Upload(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) error {
 	_, err := m.client.PutObject(ctx, m.bucket, objectName, reader, size, minio.PutObjectOptions{ContentType: contentType})
 	if err != nil {
-		return "", fmt.Errorf("failed to upload to minio: %w", err)
+		return fmt.Errorf("failed to upload to minio: %w", err)
+	}
+	return nil
+}
+
+// GetObject 打开对象用于读取，返回的 *minio.Object 支持 Seek，可配合 http.ServeContent 处理 Range 请求
+func (m *MinioService) GetObject(ctx context.Context, objectName string) (*minio.Object, minio.ObjectInfo, error) {
+	obj, err := m.client.GetObject(ctx, m.bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, minio.ObjectInfo{}, fmt.Errorf("failed to get object from minio: %w", err)
+	}
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, minio.ObjectInfo{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return obj, info, nil
+}
+
+// StatObject 查询对象元信息
+func (m *MinioService) StatObject(ctx context.Context, objectName string) (minio.ObjectInfo, error) {
+	info, err := m.client.StatObject(ctx, m.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return minio.ObjectInfo{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return info, nil
+}
+
+// PresignedGetURL 生成限时下载URL，downloadName 非空时以附件形式下载
+func (m *MinioService) PresignedGetURL(ctx context.Context, objectName, downloadName string) (string, time.Time, error) {
+	params := url.Values{}
+	if downloadName != "" {
+		params.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": downloadName}))
+	}
+	expiresAt := time.Now().Add(m.presignExpiry)
+	u, err := m.presignClient.PresignedGetObject(ctx, m.bucket, objectName, m.presignExpiry, params)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to presign get url: %w", err)
+	}
+	return u.String(), expiresAt, nil
+}
+
+// PresignedPutURL 生成限时上传URL，客户端可直接 PUT 到对象存储
+func (m *MinioService) PresignedPutURL(ctx context.Context, objectName string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(m.presignExpiry)
+	u, err := m.presignClient.PresignedPutObject(ctx, m.bucket, objectName, m.presignExpiry)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to presign put url: %w", err)
+	}
+	return u.String(), expiresAt, nil
+}
+
+// CopyObject 在存储桶内服务端复制对象，单个源对象不超过 5GiB
+func (m *MinioService) CopyObject(ctx context.Context, srcObject, dstObject string) error {
+	dst := minio.CopyDestOptions{Bucket: m.bucket, Object: dstObject}
+	src := minio.CopySrcOptions{Bucket: m.bucket, Object: srcObject}
+	if _, err := m.client.CopyObject(ctx, dst, src); err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	return nil
+}
+
+// ObjectExists 判断对象是否存在
+func (m *MinioService) ObjectExists(ctx context.Context, objectName string) (bool, error) {
+	_, err := m.client.StatObject(ctx, m.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return true, nil
+}
+
+// DownloadFile 将对象下载到本地文件
+func (m *MinioService) DownloadFile(ctx context.Context, objectName, filePath string) error {
+	if err := m.client.FGetObject(ctx, m.bucket, objectName, filePath, minio.GetObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to download object: %w", err)
+	}
+	return nil
+}
+
+// UploadFile 上传本地文件
+func (m *MinioService) UploadFile(ctx context.Context, objectName, filePath, contentType string) error {
+	if _, err := m.client.FPutObject(ctx, m.bucket, objectName, filePath, minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		return fmt.Errorf("failed to upload file to minio: %w", err)
+	}
+	return nil
+}
+
+// RemovePrefix 删除指定前缀下的全部对象
+func (m *MinioService) RemovePrefix(ctx context.Context, prefix string) error {
+	objects := m.client.ListObjects(ctx, m.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
+	for res := range m.client.RemoveObjects(ctx, m.bucket, objects, minio.RemoveObjectsOptions{}) {
+		if res.Err != nil {
+			return fmt.Errorf("failed to remove object %s: %w", res.ObjectName, res.Err)
+		}
+	}
+	return nil
+}
+
+// RemoveObject 删除对象
+func (m *MinioService) RemoveObject(ctx context.Context, objectName string) error {
+	if err := m.client.RemoveObject(ctx, m.bucket, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to remove object: %w", err)
+	}
+	return nil
+}
+
+// NewMultipartUpload 初始化分片上传，返回对象存储侧的 uploadID
+func (m *MinioService) NewMultipartUpload(ctx context.Context, objectName, contentType string) (string, error) {
+	core := minio.Core{Client: m.client}
+	uploadID, err := core.NewMultipartUpload(ctx, m.bucket, objectName, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+// PutObjectPart 上传单个分片，同一分片号重复上传会覆盖之前的数据
+func (m *MinioService) PutObjectPart(ctx context.Context, objectName, uploadID string, partNumber int, reader io.Reader, size int64) (minio.ObjectPart, error) {
+	core := minio.Core{Client: m.client}
+	part, err := core.PutObjectPart(ctx, m.bucket, objectName, uploadID, partNumber, reader, size, "", "", nil)
+	if err != nil {
+		return minio.ObjectPart{}, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+	return part, nil
+}
+
+// ListObjectParts 列出分片上传中已上传的全部分片
+func (m *MinioService) ListObjectParts(ctx context.Context, objectName, uploadID string) ([]minio.ObjectPart, error) {
+	core := minio.Core{Client: m.client}
+	parts := []minio.ObjectPart{}
+	marker := 0
+	for {
+		res, err := core.ListObjectParts(ctx, m.bucket, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list object parts: %w", err)
+		}
+		parts = append(parts, res.ObjectParts...)
+		if !res.IsTruncated {
+			return parts, nil
+		}
+		marker = res.NextPartNumberMarker
+	}
+}
+
+// CompleteMultipartUpload 按分片号顺序合并分片为完整对象
+func (m *MinioService) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []minio.CompletePart) error {
+	core := minio.Core{Client: m.client}
+	if _, err := core.CompleteMultipartUpload(ctx, m.bucket, objectName, uploadID, parts, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
 	}
-	scheme := "http"
-	if m.useSSL {
-		scheme = "https"
+	return nil
+}
+
+// AbortMultipartUpload 取消分片上传并释放已上传的分片
+func (m *MinioService) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	core := minio.Core{Client: m.client}
+	if err := core.AbortMultipartUpload(ctx, m.bucket, objectName, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
 	}
-	url := fmt.Sprintf("%s://%s/%s/%s", scheme, m.endpoint, m.bucket, objectName)
-	return url, nil
+	return nil
 }
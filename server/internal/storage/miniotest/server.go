@@ -0,0 +1,335 @@
+// Package miniotest 测试用的内存 S3 服务器，实现 storage.MinioService 用到的对象读写（含 Range）、
+// 服务端复制、前缀列举与批量删除；不校验签名，不支持分片上传
+package miniotest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/config"
+	"github.com/ASNMortred/AI-Hackathon/server/internal/storage"
+)
+
+// Bucket Start 创建的 MinioService 使用的存储桶
+const Bucket = "files"
+
+// streamingPayload minio-go 在非 TLS 连接上分片上传时使用的 aws-chunked 签名负载
+const streamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+type object struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func (o object) etag() string {
+	sum := md5.Sum(o.data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Server 内存 S3 服务器，只有一个存储桶，对象键不区分存储桶
+type Server struct {
+	// Fail 非 nil 时对返回 true 的请求返回 403 AccessDenied，用于模拟对象存储故障（403 不会被客户端重试）
+	Fail func(r *http.Request) bool
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+// NewServer 创建空的服务器
+func NewServer() *Server {
+	return &Server{objects: map[string]object{}}
+}
+
+// Start 启动服务器并返回连接到它的 MinioService，测试结束时关闭服务器
+func Start(t testing.TB) (*Server, *storage.MinioService) {
+	t.Helper()
+	s := NewServer()
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	svc, err := storage.NewMinioService(config.MinioConfig{
+		Endpoint:      strings.TrimPrefix(ts.URL, "http://"),
+		AccessKey:     "miniotest",
+		SecretKey:     "miniotest-secret",
+		Bucket:        Bucket,
+		Region:        "us-east-1",
+		PresignExpiry: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("miniotest: %v", err)
+	}
+	return s, svc
+}
+
+// Put 直接写入对象
+func (s *Server) Put(key string, data []byte, contentType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = object{data: append([]byte(nil), data...), contentType: contentType, modTime: time.Now().UTC().Truncate(time.Second)}
+}
+
+// Get 返回对象内容
+func (s *Server) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[key]
+	return o.data, ok
+}
+
+// Keys 按字典序返回全部对象键
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Fail != nil && s.Fail(r) {
+		writeError(w, r, http.StatusForbidden, "AccessDenied", "injected failure")
+		return
+	}
+	// 路径风格：/<bucket>/<key>
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if len(parts) < 2 || parts[1] == "" {
+		s.serveBucket(w, r)
+		return
+	}
+	key := parts[1]
+
+	switch r.Method {
+	case http.MethodPut:
+		s.putObject(w, r, key)
+	case http.MethodGet, http.MethodHead:
+		s.getObject(w, r, key)
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", r.Method+" is not supported")
+	}
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && q.Get("list-type") == "2":
+		s.listObjects(w, q.Get("prefix"))
+	case r.Method == http.MethodPost && q.Has("delete"):
+		s.deleteObjects(w, r)
+	case r.Method == http.MethodHead, r.Method == http.MethodPut:
+		// 存储桶始终存在
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", "bucket operation is not supported")
+	}
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, key string) {
+	if r.URL.Query().Has("uploadId") {
+		writeError(w, r, http.StatusNotImplemented, "NotImplemented", "multipart upload is not supported")
+		return
+	}
+	if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+		s.copyObject(w, r, key, src)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("X-Amz-Content-Sha256") == streamingPayload {
+		body = &chunkedReader{r: bufio.NewReader(r.Body)}
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	o := object{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC().Truncate(time.Second)}
+	s.mu.Lock()
+	s.objects[key] = o
+	s.mu.Unlock()
+	w.Header().Set("ETag", `"`+o.etag()+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, key, src string) {
+	src, _ = url.PathUnescape(src)
+	srcParts := strings.SplitN(strings.TrimPrefix(src, "/"), "/", 2)
+	s.mu.Lock()
+	o, ok := s.objects[srcParts[len(srcParts)-1]]
+	if ok {
+		o.modTime = time.Now().UTC().Truncate(time.Second)
+		s.objects[key] = o
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "copy source does not exist")
+		return
+	}
+	writeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string
+		LastModified string
+	}{ETag: `"` + o.etag() + `"`, LastModified: o.modTime.Format("2006-01-02T15:04:05.000Z")})
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, key string) {
+	s.mu.Lock()
+	o, ok := s.objects[key]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+	contentType := o.contentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", `"`+o.etag()+`"`)
+	// ServeContent 处理 Range 与 HEAD
+	http.ServeContent(w, r, key, o.modTime, bytes.NewReader(o.data))
+}
+
+type listContent struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+	StorageClass string
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, prefix string) {
+	var contents []listContent
+	for _, key := range s.Keys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		s.mu.Lock()
+		o := s.objects[key]
+		s.mu.Unlock()
+		contents = append(contents, listContent{
+			Key:          key,
+			LastModified: o.modTime.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         `"` + o.etag() + `"`,
+			Size:         len(o.data),
+			StorageClass: "STANDARD",
+		})
+	}
+	writeXML(w, struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []listContent
+	}{Name: Bucket, Prefix: prefix, KeyCount: len(contents), MaxKeys: 1000, Contents: contents})
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Objects []struct {
+			Key string
+		} `xml:"Object"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	type deleted struct {
+		Key string
+	}
+	var result []deleted
+	s.mu.Lock()
+	for _, o := range req.Objects {
+		delete(s.objects, o.Key)
+		result = append(result, deleted{Key: o.Key})
+	}
+	s.mu.Unlock()
+	writeXML(w, struct {
+		XMLName xml.Name  `xml:"DeleteResult"`
+		Deleted []deleted `xml:"Deleted"`
+	}{Deleted: result})
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+	xml.NewEncoder(w).Encode(struct {
+		XMLName  xml.Name `xml:"Error"`
+		Code     string
+		Message  string
+		Resource string
+	}{Code: code, Message: message, Resource: r.URL.Path})
+}
+
+// chunkedReader 解码 aws-chunked 负载：<十六进制长度>;chunk-signature=<签名>\r\n<数据>\r\n，以长度 0 的块结束
+type chunkedReader struct {
+	r         *bufio.Reader
+	remaining int64
+	done      bool
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		size := strings.SplitN(strings.TrimSpace(line), ";", 2)[0]
+		n, err := strconv.ParseInt(size, 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid chunk header %q", line)
+		}
+		if n == 0 {
+			c.done = true
+			continue
+		}
+		c.remaining = n
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining == 0 && err == nil {
+		// 跳过块数据之后的 \r\n
+		_, err = c.r.Discard(2)
+	}
+	return n, err
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultTimezone 未指定时区时使用的时区
+const defaultTimezone = "Asia/Shanghai"
+
+// CurrentTime 返回当前时间的内置工具，模型无法自行得知当前日期
+func CurrentTime() Tool {
+	return Tool{
+		Name:        "get_current_time",
+		Description: "获取当前日期与时间",
+		Parameters: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"timezone": {
+					Type:        "string",
+					Description: "IANA 时区名，如 Asia/Shanghai、UTC，默认 " + defaultTimezone,
+				},
+			},
+			AdditionalProperties: boolPtr(false),
+		},
+		Call: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			tz, _ := args["timezone"].(string)
+			if tz == "" {
+				tz = defaultTimezone
+			}
+			loc, err := time.LoadLocation(tz)
+			if err != nil {
+				return "", fmt.Errorf("unknown timezone %q", tz)
+			}
+			now := time.Now().In(loc)
+			raw, err := json.Marshal(map[string]string{
+				"timezone": tz,
+				"time":     now.Format(time.RFC3339),
+				"weekday":  now.Weekday().String(),
+			})
+			return string(raw), err
+		},
+	}
+}
+
+// 知识库检索返回条数
+const (
+	defaultKnowledgeLimit = 5
+	maxKnowledgeLimit     = 10
+)
+
+// KnowledgeHit 知识库检索命中的条目
+type KnowledgeHit struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Source  string `json:"source,omitempty"`
+}
+
+// KnowledgeSearcher 知识库检索后端
+type KnowledgeSearcher interface {
+	Search(ctx context.Context, query string, limit int) ([]KnowledgeHit, error)
+}
+
+// SearchKnowledge 检索知识库的内置工具。
+// searcher 为 nil 时为占位实现：始终返回空结果并说明知识库未配置，让模型如实告知用户而不是编造检索结果
+func SearchKnowledge(searcher KnowledgeSearcher) Tool {
+	minLimit, maxLimit := 1.0, float64(maxKnowledgeLimit)
+	minLength := 1
+	return Tool{
+		Name:        "search_knowledge",
+		Description: "在知识库中检索与问题相关的资料",
+		Parameters: &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"query": {Type: "string", Description: "检索关键词或问题", MinLength: &minLength},
+				"limit": {
+					Type:        "integer",
+					Description: fmt.Sprintf("返回条数，默认 %d", defaultKnowledgeLimit),
+					Minimum:     &minLimit,
+					Maximum:     &maxLimit,
+				},
+			},
+			Required:             []string{"query"},
+			AdditionalProperties: boolPtr(false),
+		},
+		Call: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			query := strings.TrimSpace(args["query"].(string))
+			limit := defaultKnowledgeLimit
+			if v, ok := args["limit"].(float64); ok {
+				limit = int(v)
+			}
+
+			result := map[string]interface{}{"query": query}
+			if searcher == nil {
+				result["results"] = []KnowledgeHit{}
+				result["note"] = "知识库未配置，没有可检索的资料"
+			} else {
+				hits, err := searcher.Search(ctx, query, limit)
+				if err != nil {
+					return "", err
+				}
+				if hits == nil {
+					hits = []KnowledgeHit{}
+				}
+				result["results"] = hits
+			}
+			raw, err := json.Marshal(result)
+			return string(raw), err
+		},
+	}
+}
+
+// RegisterBuiltins 注册内置工具。知识库尚无检索后端，search_knowledge 以占位实现注册
+func RegisterBuiltins(r *Registry) error {
+	for _, t := range []Tool{CurrentTime(), SearchKnowledge(nil)} {
+		if err := r.Register(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,160 @@
+// Package tools 模型函数调用：工具注册表、参数校验与多轮调用编排
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
+)
+
+var (
+	// ErrUnknownTool 模型请求了未注册的工具
+	ErrUnknownTool = errors.New("tools: unknown tool")
+	// ErrInvalidArguments 参数不是 JSON 对象或不符合工具的 Schema
+	ErrInvalidArguments = errors.New("tools: invalid arguments")
+	// ErrTimeout 工具执行超时
+	ErrTimeout = errors.New("tools: timed out")
+)
+
+// namePattern OpenAI 协议对函数名的限制
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Tool 可供模型调用的工具
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters 参数的 JSON Schema，为 nil 时接受任意对象
+	Parameters *Schema
+	// RawParameters 原始 JSON Schema，非空时原样声明给模型，Parameters 仅用于校验；
+	// 用于外部来源的工具，避免丢失校验未支持的关键字
+	RawParameters json.RawMessage
+	// Timeout 单次调用超时，为 0 时使用注册表的默认值
+	Timeout time.Duration
+	// Call 执行工具，args 已通过 Parameters 校验，返回值原样交给模型
+	Call func(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// Registry 工具注册表，按注册顺序向模型声明工具
+type Registry struct {
+	mu      sync.RWMutex
+	timeout time.Duration
+	tools   map[string]*Tool
+	order   []string
+}
+
+// NewRegistry 创建空的工具注册表，defaultTimeout 为未设置超时的工具的单次调用超时
+func NewRegistry(defaultTimeout time.Duration) *Registry {
+	return &Registry{timeout: defaultTimeout, tools: map[string]*Tool{}}
+}
+
+// Register 注册工具，名称不合法或重复时返回错误
+func (r *Registry) Register(t Tool) error {
+	if !namePattern.MatchString(t.Name) {
+		return fmt.Errorf("tools: invalid tool name %q", t.Name)
+	}
+	if t.Call == nil {
+		return fmt.Errorf("tools: tool %q has no Call", t.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[t.Name]; ok {
+		return fmt.Errorf("tools: tool %q is already registered", t.Name)
+	}
+	r.tools[t.Name] = &t
+	r.order = append(r.order, t.Name)
+	return nil
+}
+
+// Len 已注册的工具数
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.order)
+}
+
+// Definitions 返回发送给模型的工具声明
+func (r *Registry) Definitions() []llm.ToolDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	defs := make([]llm.ToolDefinition, 0, len(r.order))
+	for _, name := range r.order {
+		t := r.tools[name]
+		var params interface{} = t.Parameters
+		switch {
+		case len(t.RawParameters) > 0:
+			params = t.RawParameters
+		case t.Parameters == nil:
+			params = &Schema{Type: "object"}
+		}
+		defs = append(defs, llm.ToolDefinition{
+			Type: llm.ToolTypeFunction,
+			Function: llm.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  params,
+			},
+		})
+	}
+	return defs
+}
+
+// Call 校验模型给出的 JSON 参数并在超时限制内执行工具。
+// 工具不响应取消时也会在超时后返回 ErrTimeout，其结果被丢弃。
+func (r *Registry) Call(ctx context.Context, name, arguments string) (string, error) {
+	r.mu.RLock()
+	t, ok := r.tools[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+
+	args := map[string]interface{}{}
+	// 部分模型在无参数时给出空字符串
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("%w: arguments must be a JSON object", ErrInvalidArguments)
+		}
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+	}
+	if err := t.Parameters.Validate(args); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := t.Call(ctx, args)
+		done <- result{out, err}
+	}()
+	select {
+	case res := <-done:
+		return res.out, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%w: %s after %s", ErrTimeout, name, timeout)
+		}
+		return "", ctx.Err()
+	}
+}
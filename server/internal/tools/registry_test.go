@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoTool(name string) Tool {
+	return Tool{
+		Name:       name,
+		Parameters: &Schema{Type: "object", Properties: map[string]*Schema{"text": {Type: "string"}}, Required: []string{"text"}},
+		Call: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			return args["text"].(string), nil
+		},
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry(time.Second)
+	require.NoError(t, r.Register(echoTool("echo")))
+	assert.Error(t, r.Register(echoTool("echo")))
+	assert.Error(t, r.Register(echoTool("bad name")))
+	assert.Error(t, r.Register(Tool{Name: "no_call"}))
+
+	defs := r.Definitions()
+	require.Len(t, defs, 1)
+	assert.Equal(t, "function", defs[0].Type)
+	assert.Equal(t, "echo", defs[0].Function.Name)
+}
+
+func TestRegistryCall(t *testing.T) {
+	r := NewRegistry(time.Second)
+	require.NoError(t, r.Register(echoTool("echo")))
+
+	out, err := r.Call(context.Background(), "echo", `{"text":"hi"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", out)
+
+	_, err = r.Call(context.Background(), "missing", `{}`)
+	assert.ErrorIs(t, err, ErrUnknownTool)
+	_, err = r.Call(context.Background(), "echo", `not json`)
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+	_, err = r.Call(context.Background(), "echo", `{"text":1}`)
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+	// 空参数按空对象校验
+	_, err = r.Call(context.Background(), "echo", ``)
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+}
+
+func TestRegistryCallTimeout(t *testing.T) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	r := NewRegistry(time.Second)
+	require.NoError(t, r.Register(Tool{
+		Name:    "stuck",
+		Timeout: 20 * time.Millisecond,
+		// 不响应取消的工具也会按时返回
+		Call: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			<-release
+			return "late", nil
+		},
+	}))
+
+	start := time.Now()
+	_, err := r.Call(context.Background(), "stuck", `{}`)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestCurrentTime(t *testing.T) {
+	r := NewRegistry(time.Second)
+	require.NoError(t, RegisterBuiltins(r))
+
+	out, err := r.Call(context.Background(), "get_current_time", `{"timezone":"UTC"}`)
+	require.NoError(t, err)
+	assert.Contains(t, out, `"timezone":"UTC"`)
+
+	_, err = r.Call(context.Background(), "get_current_time", `{"timezone":"Mars/Base"}`)
+	assert.Error(t, err)
+	_, err = r.Call(context.Background(), "get_current_time", `{"tz":"UTC"}`)
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+}
+
+type fakeSearcher struct {
+	query string
+	limit int
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, query string, limit int) ([]KnowledgeHit, error) {
+	f.query, f.limit = query, limit
+	return []KnowledgeHit{{Title: "部署指南", Content: "make deploy"}}, nil
+}
+
+func TestSearchKnowledge(t *testing.T) {
+	r := NewRegistry(time.Second)
+	require.NoError(t, RegisterBuiltins(r))
+
+	// 未配置检索后端时返回空结果并说明原因
+	out, err := r.Call(context.Background(), "search_knowledge", `{"query":"部署"}`)
+	require.NoError(t, err)
+	assert.Contains(t, out, `"results":[]`)
+	assert.Contains(t, out, "知识库未配置")
+
+	_, err = r.Call(context.Background(), "search_knowledge", `{"query":""}`)
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+	_, err = r.Call(context.Background(), "search_knowledge", `{"query":"部署","limit":50}`)
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+
+	searcher := &fakeSearcher{}
+	r = NewRegistry(time.Second)
+	require.NoError(t, r.Register(SearchKnowledge(searcher)))
+	out, err = r.Call(context.Background(), "search_knowledge", `{"query":" 部署 ","limit":3}`)
+	require.NoError(t, err)
+	assert.Contains(t, out, "部署指南")
+	assert.Equal(t, "部署", searcher.query)
+	assert.Equal(t, 3, searcher.limit)
+}
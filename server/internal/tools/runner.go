@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
+)
+
+// TraceEntry 一次工具调用的记录，随回复返回给客户端
+type TraceEntry struct {
+	// Iteration 第几轮工具调用，从 1 开始
+	Iteration  int    `json:"iteration"`
+	CallID     string `json:"call_id"`
+	Tool       string `json:"tool"`
+	Arguments  string `json:"arguments"`
+	Result     string `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Runner 编排函数调用：向模型声明工具，执行模型请求的调用并将结果回传，直到模型给出最终回复。
+// 工具调用轮数达到上限后再请求一次且不再提供工具，迫使模型基于已有结果作答。
+type Runner struct {
+	provider      llm.Provider
+	registry      *Registry
+	maxIterations int
+}
+
+// NewRunner 创建函数调用编排器，registry 为 nil 或为空时等同于直接调用 provider
+func NewRunner(provider llm.Provider, registry *Registry, maxIterations int) *Runner {
+	return &Runner{provider: provider, registry: registry, maxIterations: maxIterations}
+}
+
+// Run 生成完整回复，返回的 Content 为各轮文本依次拼接，Usage 为各轮用量之和
+func (r *Runner) Run(ctx context.Context, req *llm.Request) (*llm.Response, []TraceEntry, error) {
+	return r.run(ctx, req, func(req *llm.Request) (*llm.Response, error) {
+		return r.provider.Chat(ctx, req)
+	}, nil)
+}
+
+// RunStream 流式生成回复，onDelta 接收各轮的增量文本，onTool 在每次工具调用完成后调用；
+// 任一回调返回错误时中止
+func (r *Runner) RunStream(ctx context.Context, req *llm.Request, onDelta func(delta string) error, onTool func(entry TraceEntry) error) (*llm.Response, []TraceEntry, error) {
+	return r.run(ctx, req, func(req *llm.Request) (*llm.Response, error) {
+		return r.provider.ChatStream(ctx, req, onDelta)
+	}, onTool)
+}
+
+func (r *Runner) run(ctx context.Context, req *llm.Request, call func(*llm.Request) (*llm.Response, error), onTool func(TraceEntry) error) (*llm.Response, []TraceEntry, error) {
+	var defs []llm.ToolDefinition
+	if r.registry != nil {
+		defs = r.registry.Definitions()
+	}
+
+	messages := append([]llm.Message(nil), req.Messages...)
+	var trace []TraceEntry
+	var usage *llm.Usage
+	// 请求工具的轮次也可能带有文本，流式时已推送给客户端，因此回复内容包含各轮文本，与推送的增量一致
+	var content strings.Builder
+	for iteration := 1; ; iteration++ {
+		turn := *req
+		turn.Messages = messages
+		turn.Tools = nil
+		if len(defs) > 0 && iteration <= r.maxIterations {
+			turn.Tools = defs
+		}
+
+		resp, err := call(&turn)
+		if err != nil {
+			return nil, trace, err
+		}
+		usage = addUsage(usage, resp.Usage)
+		content.WriteString(resp.Content)
+		if len(turn.Tools) == 0 || len(resp.ToolCalls) == 0 {
+			resp.Content = content.String()
+			resp.Usage = usage
+			resp.ToolCalls = nil
+			return resp, trace, nil
+		}
+
+		messages = append(messages, llm.Message{Role: llm.RoleAssistant, Content: resp.Content, ToolCalls: resp.ToolCalls})
+		for _, tc := range resp.ToolCalls {
+			entry := r.execute(ctx, iteration, tc)
+			if ctx.Err() != nil {
+				return nil, trace, ctx.Err()
+			}
+			trace = append(trace, entry)
+			if onTool != nil {
+				if err := onTool(entry); err != nil {
+					return nil, trace, err
+				}
+			}
+			messages = append(messages, llm.Message{Role: llm.RoleTool, ToolCallID: tc.ID, Content: toolMessage(entry)})
+		}
+	}
+}
+
+// execute 执行一次工具调用，错误记录在 TraceEntry 中交给模型处理，不中止对话
+func (r *Runner) execute(ctx context.Context, iteration int, tc llm.ToolCall) TraceEntry {
+	entry := TraceEntry{
+		Iteration: iteration,
+		CallID:    tc.ID,
+		Tool:      tc.Function.Name,
+		Arguments: tc.Function.Arguments,
+	}
+	start := time.Now()
+	out, err := r.registry.Call(ctx, tc.Function.Name, tc.Function.Arguments)
+	entry.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Result = out
+	}
+	return entry
+}
+
+// toolMessage 工具消息的内容，失败时以 {"error": ...} 告知模型
+func toolMessage(entry TraceEntry) string {
+	if entry.Error == "" {
+		return entry.Result
+	}
+	raw, _ := json.Marshal(map[string]string{"error": entry.Error})
+	return string(raw)
+}
+
+func addUsage(total, u *llm.Usage) *llm.Usage {
+	if u == nil {
+		return total
+	}
+	if total == nil {
+		total = &llm.Usage{}
+	}
+	total.PromptTokens += u.PromptTokens
+	total.CompletionTokens += u.CompletionTokens
+	total.TotalTokens += u.TotalTokens
+	return total
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ASNMortred/AI-Hackathon/server/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toolCallReply(id, name, args string, tokens int) llm.FakeReply {
+	return llm.FakeReply{Response: &llm.Response{
+		FinishReason: "tool_calls",
+		ToolCalls: []llm.ToolCall{{
+			ID:       id,
+			Type:     llm.ToolTypeFunction,
+			Function: llm.FunctionCall{Name: name, Arguments: args},
+		}},
+		Usage: &llm.Usage{TotalTokens: tokens},
+	}}
+}
+
+func newTestRegistry(t *testing.T) *Registry {
+	r := NewRegistry(time.Second)
+	require.NoError(t, r.Register(echoTool("echo")))
+	return r
+}
+
+func TestRunnerLoop(t *testing.T) {
+	fake := llm.NewFake(
+		toolCallReply("call_1", "echo", `{"text":"pong"}`, 10),
+		toolCallReply("call_2", "echo", `{}`, 5),
+		llm.FakeReply{Response: &llm.Response{Content: "done", Usage: &llm.Usage{TotalTokens: 7}}},
+	)
+	runner := NewRunner(fake, newTestRegistry(t), 5)
+
+	resp, trace, err := runner.Run(context.Background(), &llm.Request{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "ping"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", resp.Content)
+	assert.Equal(t, 22, resp.Usage.TotalTokens)
+
+	require.Len(t, trace, 2)
+	assert.Equal(t, TraceEntry{Iteration: 1, CallID: "call_1", Tool: "echo", Arguments: `{"text":"pong"}`, Result: "pong", DurationMs: trace[0].DurationMs}, trace[0])
+	assert.Equal(t, 2, trace[1].Iteration)
+	assert.Contains(t, trace[1].Error, "invalid arguments")
+
+	// 每轮都携带工具声明，结果以 tool 消息回传，参数错误也交给模型处理
+	reqs := fake.Requests()
+	require.Len(t, reqs, 3)
+	assert.Len(t, reqs[0].Tools, 1)
+	last := reqs[2].Messages
+	require.Len(t, last, 5)
+	assert.Equal(t, llm.RoleAssistant, last[1].Role)
+	assert.Equal(t, "call_1", last[1].ToolCalls[0].ID)
+	assert.Equal(t, llm.Message{Role: llm.RoleTool, ToolCallID: "call_1", Content: "pong"}, last[2])
+	assert.Equal(t, llm.RoleTool, last[4].Role)
+	assert.Contains(t, last[4].Content, `"error"`)
+}
+
+func TestRunnerIterationCap(t *testing.T) {
+	// 模型一直请求工具，达到上限后最后一轮不再提供工具
+	fake := llm.NewFake(toolCallReply("call", "echo", `{"text":"again"}`, 1))
+	runner := NewRunner(fake, newTestRegistry(t), 2)
+
+	resp, trace, err := runner.Run(context.Background(), &llm.Request{})
+	require.NoError(t, err)
+	assert.Nil(t, resp.ToolCalls)
+	assert.Len(t, trace, 2)
+
+	reqs := fake.Requests()
+	require.Len(t, reqs, 3)
+	assert.NotEmpty(t, reqs[1].Tools)
+	assert.Empty(t, reqs[2].Tools)
+}
+
+func TestRunnerWithoutTools(t *testing.T) {
+	fake := llm.NewFake(llm.FakeReply{Response: &llm.Response{Content: "hi"}})
+	resp, trace, err := NewRunner(fake, NewRegistry(time.Second), 5).Run(context.Background(), &llm.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", resp.Content)
+	assert.Empty(t, trace)
+	assert.Nil(t, fake.Requests()[0].Tools)
+}
+
+func TestRunnerStream(t *testing.T) {
+	fake := llm.NewFake(
+		toolCallReply("call_1", "echo", `{"text":"pong"}`, 1),
+		llm.FakeReply{Response: &llm.Response{Content: "done"}},
+	)
+	runner := NewRunner(fake, newTestRegistry(t), 5)
+
+	var events []string
+	resp, _, err := runner.RunStream(context.Background(), &llm.Request{}, func(delta string) error {
+		events = append(events, "delta:"+delta)
+		return nil
+	}, func(entry TraceEntry) error {
+		events = append(events, "tool:"+entry.Result)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "done", resp.Content)
+	assert.Equal(t, []string{"tool:pong", "delta:done"}, events)
+
+	// 回调出错时中止
+	stop := errors.New("client gone")
+	fake = llm.NewFake(toolCallReply("call_1", "echo", `{"text":"pong"}`, 1))
+	_, _, err = NewRunner(fake, newTestRegistry(t), 5).RunStream(context.Background(), &llm.Request{},
+		func(string) error { return nil },
+		func(TraceEntry) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
+
+func TestRunnerContentAcrossRounds(t *testing.T) {
+	// 请求工具的轮次带有文本时，回复内容与推送的增量一致，保存的回复不丢失前几轮的文本
+	withText := toolCallReply("call_1", "echo", `{"text":"pong"}`, 1)
+	withText.Response.Content = "Let me check. "
+	fake := llm.NewFake(withText, llm.FakeReply{Response: &llm.Response{Content: "It says pong."}})
+
+	var streamed string
+	resp, _, err := NewRunner(fake, newTestRegistry(t), 5).RunStream(context.Background(), &llm.Request{}, func(delta string) error {
+		streamed += delta
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Let me check. It says pong.", resp.Content)
+	assert.Equal(t, streamed, resp.Content)
+
+	// 回传给模型的 assistant 消息只含该轮文本
+	assert.Equal(t, "Let me check. ", fake.Requests()[1].Messages[0].Content)
+}
+
+func TestRunnerProviderError(t *testing.T) {
+	upstream := errors.New("upstream down")
+	fake := llm.NewFake(toolCallReply("call_1", "echo", `{"text":"pong"}`, 1), llm.FakeReply{Err: upstream})
+	_, trace, err := NewRunner(fake, newTestRegistry(t), 5).Run(context.Background(), &llm.Request{})
+	assert.ErrorIs(t, err, upstream)
+	assert.Len(t, trace, 1)
+}
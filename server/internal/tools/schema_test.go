@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	lo, hi := 1.0, 10.0
+	maxLen := 4
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":  {Type: "string", MaxLength: &maxLen},
+			"count": {Type: "integer", Minimum: &lo, Maximum: &hi},
+			"unit":  {Type: "string", Enum: []interface{}{"kb", "mb"}},
+			"tags":  {Type: "array", Items: &Schema{Type: "string"}},
+		},
+		Required:             []string{"name"},
+		AdditionalProperties: boolPtr(false),
+	}
+
+	cases := []struct {
+		args string
+		path string
+	}{
+		{`{"name":"abc","count":3,"unit":"mb","tags":["x"]}`, ""},
+		{`{"count":3}`, "$.name"},
+		{`{"name":"abcde"}`, "$.name"},
+		{`{"name":"a","count":2.5}`, "$.count"},
+		{`{"name":"a","count":11}`, "$.count"},
+		{`{"name":"a","unit":"gb"}`, "$.unit"},
+		{`{"name":"a","tags":["x",1]}`, "$.tags[1]"},
+		{`{"name":"a","extra":true}`, "$.extra"},
+	}
+	for _, tc := range cases {
+		var value interface{}
+		require.NoError(t, json.Unmarshal([]byte(tc.args), &value))
+		err := schema.Validate(value)
+		if tc.path == "" {
+			assert.NoError(t, err, tc.args)
+			continue
+		}
+		var vErr *ValidationError
+		require.ErrorAs(t, err, &vErr, tc.args)
+		assert.Equal(t, tc.path, vErr.Path, tc.args)
+	}
+
+	// 从 JSON 解析的 Schema 忽略未支持的关键字
+	var parsed Schema
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"object","properties":{"q":{"type":"string","format":"uri"}},"required":["q"],"$schema":"x"}`), &parsed))
+	assert.Error(t, parsed.Validate(map[string]interface{}{}))
+	assert.NoError(t, parsed.Validate(map[string]interface{}{"q": "x"}))
+
+	// nil Schema 不做限制
+	var none *Schema
+	assert.NoError(t, none.Validate(map[string]interface{}{"any": 1.0}))
+}